@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"sort"
+)
+
+// AboutInfo describes the binary for the reviews of air-gapped installs:
+// --about prints it without reading any file nor opening any connection.
+type AboutInfo struct {
+	Version                  string   `json:"version"`
+	Commit                   string   `json:"commit,omitempty"`
+	CommitTime               string   `json:"commit_time,omitempty"`
+	Modified                 bool     `json:"modified,omitempty"`
+	GoVersion                string   `json:"go_version"`
+	Platform                 string   `json:"platform"`
+	Module                   string   `json:"module"`
+	Dependencies             []string `json:"dependencies"`
+	ManifestSchemaVersions   []string `json:"manifest_schema_versions"`
+	CoverageTypes            []string `json:"coverage_types"`
+	ReportFormats            []string `json:"report_formats"`
+	PublishTargets           []string `json:"publish_targets"`
+	Subcommands              []string `json:"subcommands"`
+	SelectorMethods          []string `json:"selector_methods"`
+	TelemetryEnvironmentVars []string `json:"telemetry_environment_variables"`
+}
+
+func aboutInfo() AboutInfo {
+	about := AboutInfo{
+		Version:                  currentVersion(),
+		GoVersion:                runtime.Version(),
+		Platform:                 runtime.GOOS + "/" + runtime.GOARCH,
+		ManifestSchemaVersions:   SupportedManifestSchemaVersions,
+		ReportFormats:            reportFormatNames(),
+		PublishTargets:           append(publisherNames(), "routes", "s3://", "gs://"),
+		SelectorMethods:          selectorMethods,
+		TelemetryEnvironmentVars: []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_HEADERS", "OTEL_SERVICE_NAME"},
+	}
+	for _, t := range registeredCoverageTypes() {
+		about.CoverageTypes = append(about.CoverageTypes, string(t))
+	}
+	for name := range subcommands {
+		about.Subcommands = append(about.Subcommands, name)
+	}
+	sort.Strings(about.Subcommands)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		about.Module = info.Main.Path
+		for _, dep := range info.Deps {
+			about.Dependencies = append(about.Dependencies, dep.Path+"@"+dep.Version)
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				about.Commit = setting.Value
+			case "vcs.time":
+				about.CommitTime = setting.Value
+			case "vcs.modified":
+				about.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return about
+}
+
+func writeAbout(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(aboutInfo())
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strings"
+)
+
+const annotationMetaKey = "goverage"
+
+func runAnnotateDocs(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("annotate-docs")
+	var (
+		projectDir      = fs.String("dbt_dir", ".", "dbt project path")
+		runArtifactsDir = fs.String("target_dir", "target", "dbt target path")
+		output          = fs.String("output", "", "Annotated manifest path (defaults to manifest.annotated.json in the target path)")
+		configPath      = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		caseSensitive   = fs.Bool("case_sensitive", false, "Match column names case-sensitively")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+
+	cfg, err := loadConfig(*configPath, *projectDir)
+	if err != nil {
+		return err
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		return err
+	}
+	if *caseSensitive {
+		settings.Naming.CaseSensitive = true
+	}
+	catalog, err := loadFiles(ctx, *projectDir, *runArtifactsDir, true, settings)
+	if err != nil {
+		return err
+	}
+	manifestPath := artifactPath(*projectDir, *runArtifactsDir, "manifest.json")
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	var manifestJSON map[string]interface{}
+	if err := json.Unmarshal(data, &manifestJSON); err != nil {
+		return err
+	}
+
+	annotated := annotateManifest(manifestJSON, catalog)
+	log.Printf("Coverage written into the meta of %d nodes", annotated)
+
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = artifactPath(*projectDir, *runArtifactsDir, "manifest.annotated.json")
+	}
+	out, err := json.Marshal(manifestJSON)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputPath, out, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Annotated manifest written into %s (%d nodes)\n", outputPath, annotated)
+	return nil
+}
+
+func annotateManifest(manifestJSON map[string]interface{}, catalog Catalog) int {
+	annotated := 0
+	for _, key := range []string{"sources", "nodes"} {
+		group, ok := manifestJSON[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for id, n := range group {
+			table, ok := catalog.Tables[id]
+			if !ok {
+				continue
+			}
+			node, ok := n.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			docCovered, testCovered := 0, 0
+			for _, col := range table.Columns {
+				if col.Doc {
+					docCovered++
+				}
+				if col.Test {
+					testCovered++
+				}
+			}
+			setMeta(node, map[string]interface{}{
+				"doc_coverage":  percentage(docCovered, len(table.Columns)),
+				"test_coverage": percentage(testCovered, len(table.Columns)),
+				"columns":       len(table.Columns),
+			})
+			if cols, ok := node["columns"].(map[string]interface{}); ok {
+				for _, c := range cols {
+					colNode, ok := c.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					col, ok := annotatedColumn(table.Columns, colNode, catalog.Settings.Naming)
+					if !ok {
+						continue
+					}
+					setMeta(colNode, map[string]interface{}{
+						"documented": col.Doc,
+						"tested":     col.Test,
+					})
+				}
+			}
+			annotated++
+		}
+	}
+	return annotated
+}
+
+// annotatedColumn finds the catalog column of a manifest column, matching
+// their names like the coverage computation does.
+func annotatedColumn(columns map[string]Column, colNode map[string]interface{}, naming ColumnNaming) (Column, bool) {
+	name, _ := colNode["name"].(string)
+	key := naming.Key(name)
+	if col, ok := columns[key]; ok || !naming.CaseSensitive {
+		return col, ok
+	}
+	if quoted, _ := colNode["quote"].(bool); quoted {
+		return Column{}, false
+	}
+	for name, col := range columns {
+		if strings.EqualFold(name, key) {
+			return col, true
+		}
+	}
+	return Column{}, false
+}
+
+func setMeta(node map[string]interface{}, value map[string]interface{}) {
+	meta, ok := node["meta"].(map[string]interface{})
+	if !ok {
+		meta = make(map[string]interface{})
+		node["meta"] = meta
+	}
+	meta[annotationMetaKey] = value
+}
+
+func percentage(covered, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(covered)/float64(total)*1000) / 10
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strings"
+)
+
+// archivedArtifacts are the files read from --artifacts_archive.
+var archivedArtifacts = []string{"manifest.json", "catalog.json"}
+
+// maxArtifactSize guards against archives inflating to absurd sizes.
+const maxArtifactSize = 4 << 30
+
+// artifactArchive holds manifest.json and catalog.json read from a .zip,
+// .tar, .tar.gz or .tgz archive. Nothing is written to disk: the other
+// entries are skipped without being inflated and the two artifacts are kept
+// in memory. When an archive holds several copies, the one closest to its
+// root wins, so both target/manifest.json and manifest.json layouts work.
+type artifactArchive struct {
+	Path  string
+	Files map[string][]byte
+}
+
+func readArtifactArchive(archivePath string) (*artifactArchive, error) {
+	archive := &artifactArchive{Path: archivePath, Files: make(map[string][]byte)}
+	depths := make(map[string]int)
+	read := func(name string, r io.Reader) error {
+		name = slashPath(name)
+		base := path.Base(name)
+		if !isArchivedArtifact(base) {
+			return nil
+		}
+		depth := strings.Count(strings.Trim(name, "/"), "/")
+		if known, ok := depths[base]; ok && known <= depth {
+			return nil
+		}
+		data, err := io.ReadAll(io.LimitReader(r, maxArtifactSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > maxArtifactSize {
+			return fmt.Errorf("%s is larger than %d bytes", name, maxArtifactSize)
+		}
+		depths[base] = depth
+		archive.Files[base] = data
+		log.Printf("Read %s from %s", name, archivePath)
+		return nil
+	}
+
+	var err error
+	switch lower := strings.ToLower(archivePath); {
+	case strings.HasSuffix(lower, ".zip"):
+		err = walkZip(archivePath, read)
+	case strings.HasSuffix(lower, ".tar"), strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		err = walkTar(archivePath, !strings.HasSuffix(lower, ".tar"), read)
+	default:
+		err = fmt.Errorf("unsupported archive %s, expected .zip, .tar, .tar.gz or .tgz", archivePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := archive.Files["manifest.json"]; !ok {
+		return nil, fmt.Errorf("no manifest.json in %s", archivePath)
+	}
+	return archive, nil
+}
+
+// name is how an artifact of the archive is shown in the logs and the dry run.
+func (a *artifactArchive) name(artifact string) string {
+	return a.Path + "!" + artifact
+}
+
+// describe is describeFile for the artifacts of the archive.
+func (a *artifactArchive) describe(name, missing string) string {
+	if _, ok := a.Files[strings.TrimPrefix(name, a.Path+"!")]; !ok {
+		return fmt.Sprintf("%s (not found, %s)", name, missing)
+	}
+	return name
+}
+
+func (a *artifactArchive) loadManifest(settings ParseSettings) (*Manifest, error) {
+	return ParseManifest(a.Files["manifest.json"], settings)
+}
+
+// load is loadFiles for the artifacts of the archive.
+func (a *artifactArchive) load(ctx context.Context, withCatalog bool, settings ParseSettings) (Catalog, error) {
+	log.Printf("Loading files from the artifacts archive: %s", a.Path)
+	manifest, err := a.loadManifest(settings)
+	if err != nil {
+		return Catalog{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Catalog{}, err
+	}
+	var catalog Catalog
+	if !withCatalog {
+		log.Printf("Only manifest-derived metrics requested, catalog.json is not loaded")
+		catalog, err = CatalogFromManifest(manifest)
+	} else if data, ok := a.Files["catalog.json"]; ok {
+		catalog, err = ParseCatalog(data, manifest)
+	} else {
+		err = fmt.Errorf("catalog.json not found in %s", a.Path)
+	}
+	if err != nil {
+		return Catalog{}, err
+	}
+	return enrichLoadedCatalog(ctx, catalog, manifest)
+}
+
+func isArchivedArtifact(name string) bool {
+	for _, artifact := range archivedArtifacts {
+		if name == artifact {
+			return true
+		}
+	}
+	return false
+}
+
+func walkZip(archivePath string, visit func(name string, r io.Reader) error) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() || !isArchivedArtifact(path.Base(slashPath(entry.Name))) {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return err
+		}
+		err = visit(entry.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTar(archivePath string, gzipped bool, visit func(name string, r io.Reader) error) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if gzipped {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := visit(header.Name, tr); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// benchmarkPhases are the phases of a run which --benchmark_budget can bound.
+var benchmarkPhases = []string{"load", "evaluate", "report", "total"}
+
+type phaseTiming struct {
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+}
+
+type phaseTimings struct {
+	start  time.Time
+	last   time.Time
+	phases []phaseTiming
+}
+
+func newPhaseTimings() *phaseTimings {
+	now := time.Now()
+	return &phaseTimings{start: now, last: now}
+}
+
+func (t *phaseTimings) done(name string) {
+	now := time.Now()
+	t.phases = append(t.phases, phaseTiming{Name: name, Start: t.last, Duration: now.Sub(t.last)})
+	t.last = now
+}
+
+func (t *phaseTimings) print(w io.Writer, tables, columns int) {
+	var total time.Duration
+	fmt.Fprintf(w, "\n⏱️ Benchmark (%d tables, %d columns)\n", tables, columns)
+	for _, p := range t.phases {
+		total += p.Duration
+		fmt.Fprintf(w, "  %-10s %10s  %12s\n", p.Name, p.Duration.Round(time.Microsecond), throughput(tables, p.Duration))
+	}
+	fmt.Fprintf(w, "  %-10s %10s  %12s\n", "total", total.Round(time.Microsecond), throughput(tables, total))
+}
+
+// parsePhaseBudgets reads --benchmark_budget: evaluate=30s,total=1m.
+func parsePhaseBudgets(items []string) (map[string]time.Duration, error) {
+	budgets := make(map[string]time.Duration, len(items))
+	for _, item := range items {
+		phase, value, _ := strings.Cut(item, "=")
+		if !containsString(benchmarkPhases, phase) {
+			return nil, fmt.Errorf("unknown benchmark phase %q, expected one of: %s", phase, strings.Join(benchmarkPhases, ", "))
+		}
+		budget, err := time.ParseDuration(value)
+		if err != nil || budget <= 0 {
+			return nil, fmt.Errorf("invalid budget %q for the %s phase, expected a duration such as 30s", value, phase)
+		}
+		budgets[phase] = budget
+	}
+	return budgets, nil
+}
+
+// overBudget lists the phases, and the whole run as total, which took longer
+// than their budget.
+func (t *phaseTimings) overBudget(budgets map[string]time.Duration) []string {
+	var over []string
+	var total time.Duration
+	check := func(name string, d time.Duration) {
+		if budget, ok := budgets[name]; ok && d > budget {
+			over = append(over, fmt.Sprintf("%s took %s (budget %s)", name, d.Round(time.Microsecond), budget))
+		}
+	}
+	for _, p := range t.phases {
+		total += p.Duration
+		check(p.Name, p.Duration)
+	}
+	check("total", total)
+	return over
+}
+
+func throughput(nodes int, d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f nodes/s", float64(nodes)/d.Seconds())
+}
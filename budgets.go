@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Budget is a coverage target for the models under a directory, optionally
+// due by a deadline: the run only fails once the deadline has passed.
+type Budget struct {
+	Path     string    `yaml:"path"`
+	Target   float64   `yaml:"target"`
+	Deadline time.Time `yaml:"deadline"`
+}
+
+type BudgetProgress struct {
+	Path     string  `json:"path"`
+	Target   float64 `json:"target"`
+	Deadline string  `json:"deadline,omitempty"`
+	DaysLeft int     `json:"days_left,omitempty"`
+	Covered  int     `json:"covered"`
+	Total    int     `json:"total"`
+	Coverage float64 `json:"coverage"`
+	Met      bool    `json:"met"`
+	Overdue  bool    `json:"overdue,omitempty"`
+}
+
+func loadBudgets(path string) ([]Budget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Budgets []Budget `yaml:"budgets"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid budgets file %s: %w", path, err)
+	}
+	for i, b := range file.Budgets {
+		switch {
+		case b.Path == "":
+			return nil, fmt.Errorf("invalid budgets file %s: budget %d has no path", path, i+1)
+		case b.Target <= 0 || b.Target > 100:
+			return nil, fmt.Errorf("invalid budgets file %s: target of %s must be between 0 and 100, got %g", path, b.Path, b.Target)
+		}
+	}
+	return file.Budgets, nil
+}
+
+// budgetProgress measures each budget on the models whose original_file_path
+// is under its path. A deadline is met until the end of its day.
+func budgetProgress(budgets []Budget, tables []TableReport, now time.Time) []BudgetProgress {
+	var progress []BudgetProgress
+	for _, b := range budgets {
+		p := BudgetProgress{Path: b.Path, Target: b.Target}
+		for _, t := range tables {
+			if hasPathPrefix(t.OriginalFilePath, b.Path) {
+				p.Covered += t.Covered
+				p.Total += t.Total
+			}
+		}
+		p.Coverage = ratio(p.Covered, p.Total)
+		p.Met = p.Coverage*100 >= b.Target
+		if !b.Deadline.IsZero() {
+			p.Deadline = b.Deadline.Format("2006-01-02")
+			end := b.Deadline.AddDate(0, 0, 1)
+			if now.Before(end) {
+				p.DaysLeft = int(end.Sub(now).Hours() / 24)
+			}
+			p.Overdue = !p.Met && !now.Before(end)
+		}
+		progress = append(progress, p)
+	}
+	return progress
+}
+
+func printBudgets(w io.Writer, progress []BudgetProgress) {
+	if len(progress) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n🎯 Coverage budgets\n\n")
+	for _, p := range progress {
+		status := "on track"
+		switch {
+		case p.Met:
+			status = "✅ met"
+		case p.Overdue:
+			status = "⛔ overdue since " + p.Deadline
+		case p.Deadline != "":
+			status = fmt.Sprintf("%d days left, due %s", p.DaysLeft, p.Deadline)
+		}
+		fmt.Fprintf(w, "  %-30s %6s / %5.1f%%  (%d/%d)  %s\n",
+			p.Path, formatCoverage(p.Covered, p.Total), p.Target, p.Covered, p.Total, status)
+	}
+}
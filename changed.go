@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// ChangedFiles lists the files touched by a pull request, as printed by
+// git diff --name-only, in their slash form.
+type ChangedFiles map[string]bool
+
+// loadChangedFiles reads one path per line from path, - meaning stdin. Blank
+// lines and # comments are ignored.
+func loadChangedFiles(path string) (ChangedFiles, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	files := make(ChangedFiles)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		files[slashPath(line)] = true
+	}
+	return files, scanner.Err()
+}
+
+// Touches tells whether the .sql or the .yml file of a table changed. The git
+// paths start at the repository root, which may be above the dbt project:
+// analytics/models/orders.sql matches models/orders.sql.
+func (c ChangedFiles) Touches(t TableReport) bool {
+	return c.touches(t.OriginalFilePath, t.PatchPath)
+}
+
+func (c ChangedFiles) touches(paths ...string) bool {
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		p = slashPath(p)
+		for changed := range c {
+			if changed == p || strings.HasSuffix(changed, "/"+p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterChanged keeps the tables whose .sql or .yml file changed.
+func (c Catalog) FilterChanged(changed ChangedFiles) Catalog {
+	filtered := make(map[string]Table)
+	for id, table := range c.Tables {
+		if changed.touches(table.OriginalFilePath, table.PatchPath) {
+			filtered[id] = table
+		}
+	}
+	log.Printf("Tables after filtering on the changed files: %d", len(filtered))
+	c.Tables = filtered
+	return c
+}
+
+// gatedReport returns the part of the report the thresholds apply to: the
+// whole report, or with --changed_files the changed tables alone.
+func gatedReport(report JSONReport, changed ChangedFiles) JSONReport {
+	if changed == nil {
+		return report
+	}
+	gated := JSONReport{CovType: report.CovType}
+	for _, t := range report.Tables {
+		if changed.Touches(t) {
+			gated.Tables = append(gated.Tables, t)
+			gated.Covered += t.Covered
+			gated.Total += t.Total
+		}
+	}
+	gated.Coverage = ratio(gated.Covered, gated.Total)
+	return gated
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const DefaultConfigFile = ".dbt-goverage.yml"
+
+type FailureClass string
+
+const (
+	FailureError            FailureClass = "error"
+	FailureBelowThreshold   FailureClass = "below_threshold"
+	FailureSeverityError    FailureClass = "severity_error"
+	FailureRegression       FailureClass = "regression"
+	FailureExpiredExemption FailureClass = "expired_exemption"
+	FailureStaleCatalog     FailureClass = "stale_catalog"
+	FailureParseWarnings    FailureClass = "parse_warnings"
+	FailureFreshnessSLA     FailureClass = "freshness_sla"
+	FailureBenchmarkBudget  FailureClass = "benchmark_budget"
+)
+
+var FailureClasses = []FailureClass{
+	FailureError,
+	FailureBelowThreshold,
+	FailureSeverityError,
+	FailureRegression,
+	FailureExpiredExemption,
+	FailureStaleCatalog,
+	FailureParseWarnings,
+	FailureFreshnessSLA,
+	FailureBenchmarkBudget,
+}
+
+var defaultExitCodes = map[FailureClass]int{
+	FailureError:            1,
+	FailureBelowThreshold:   1,
+	FailureSeverityError:    1,
+	FailureRegression:       1,
+	FailureExpiredExemption: 1,
+	FailureStaleCatalog:     0,
+	FailureParseWarnings:    0,
+	FailureFreshnessSLA:     1,
+	FailureBenchmarkBudget:  1,
+}
+
+type Config struct {
+	ExitCodes    map[FailureClass]int `yaml:"exit_codes"`
+	Plugins      []PluginConfig       `yaml:"plugins"`
+	Heuristics   HeuristicsConfig     `yaml:"heuristics"`
+	QualityScore QualityScoreConfig   `yaml:"quality_score"`
+	ColumnNaming ColumnNamingConfig   `yaml:"column_naming"`
+	TestColumns  TestColumnsConfig    `yaml:"test_columns"`
+	Presets      []string             `yaml:"presets"`
+	Exclude      []string             `yaml:"exclude_columns"`
+	NumberFormat NumberFormatConfig   `yaml:"number_format"`
+	MetaMatrix   MetaMatrixConfig     `yaml:"meta_matrix"`
+	OwnersFile   string               `yaml:"owners_file"`
+	Routes       []RouteConfig        `yaml:"routes"`
+	Exemptions   []Exemption          `yaml:"exemptions"`
+	Debt         DebtConfig           `yaml:"coverage_debt"`
+	Severities   SeverityConfig       `yaml:"severities"`
+	TestPackages TestPackagesConfig   `yaml:"test_packages"`
+	FreshnessSLA FreshnessSLA         `yaml:"freshness_sla"`
+	Serve        ServeConfig          `yaml:"serve"`
+}
+
+func (c Config) ExitCode(class FailureClass) int {
+	if code, ok := c.ExitCodes[class]; ok {
+		return code
+	}
+	return defaultExitCodes[class]
+}
+
+func (c Config) validate() error {
+	if err := c.QualityScore.validate(); err != nil {
+		return err
+	}
+	if err := c.ColumnNaming.validate(); err != nil {
+		return err
+	}
+	if err := c.TestColumns.validate(); err != nil {
+		return err
+	}
+	if _, err := columnExclusions(c.Presets, c.Exclude); err != nil {
+		return err
+	}
+	if err := c.NumberFormat.validate(); err != nil {
+		return err
+	}
+	if err := c.MetaMatrix.validate(); err != nil {
+		return err
+	}
+	if err := c.Debt.validate(); err != nil {
+		return err
+	}
+	if err := c.Severities.validate(); err != nil {
+		return err
+	}
+	if err := c.TestPackages.validate(); err != nil {
+		return err
+	}
+	if err := c.FreshnessSLA.validate(); err != nil {
+		return err
+	}
+	if err := c.Serve.validate(); err != nil {
+		return err
+	}
+	for _, e := range c.Exemptions {
+		if err := e.validate(); err != nil {
+			return err
+		}
+	}
+	names := make(map[string]bool)
+	for _, route := range c.Routes {
+		if err := route.validate(); err != nil {
+			return err
+		}
+		if names[route.Name] {
+			return fmt.Errorf("route %s defined twice", route.Name)
+		}
+		names[route.Name] = true
+	}
+	for class, code := range c.ExitCodes {
+		if _, ok := defaultExitCodes[class]; !ok {
+			names := make([]string, len(FailureClasses))
+			for i, fc := range FailureClasses {
+				names[i] = string(fc)
+			}
+			return fmt.Errorf("unknown exit_codes condition %q, expected one of: %s", class, strings.Join(names, ", "))
+		}
+		if code < 0 || code > 125 {
+			return fmt.Errorf("exit code %d for %s must be between 0 and 125", code, class)
+		}
+	}
+	return nil
+}
+
+func loadConfig(path string, projectDir string) (Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(projectDir, DefaultConfigFile)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			return Config{}, nil
+		}
+		return Config{}, err
+	}
+	log.Printf("Loading configuration from %s", path)
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("invalid configuration %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration %s: %w", path, err)
+	}
+	// Accept the owners file written with either separator, on any OS.
+	cfg.OwnersFile = filepath.FromSlash(slashPath(cfg.OwnersFile))
+	if cfg.OwnersFile != "" && !filepath.IsAbs(cfg.OwnersFile) {
+		cfg.OwnersFile = filepath.Join(filepath.Dir(path), cfg.OwnersFile)
+	}
+	return cfg, nil
+}
+
+type RunFailure struct {
+	Class   FailureClass
+	Message string
+}
+
+func exitCodeFor(cfg Config, failures []RunFailure) (int, []RunFailure) {
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failureRank(failures[i].Class) < failureRank(failures[j].Class)
+	})
+	for _, f := range failures {
+		if code := cfg.ExitCode(f.Class); code != 0 {
+			return code, failures
+		}
+	}
+	return 0, failures
+}
+
+// failOnWarnings makes the parse warnings fatal (--fail_on_warning), unless
+// the configuration already gives them a non-zero exit code.
+func (c *Config) failOnWarnings() {
+	if c.ExitCode(FailureParseWarnings) != 0 {
+		return
+	}
+	if c.ExitCodes == nil {
+		c.ExitCodes = make(map[FailureClass]int)
+	}
+	c.ExitCodes[FailureParseWarnings] = defaultExitCodes[FailureError]
+}
+
+func failureRank(class FailureClass) int {
+	for i, fc := range FailureClasses {
+		if fc == class {
+			return i
+		}
+	}
+	return len(FailureClasses)
+}
+
+// ParseSettings change how the artifacts of a run are read. They come from the
+// configuration and the flags, and are kept by the Manifest parsed with them.
+type ParseSettings struct {
+	Naming ColumnNaming
+	// NameFormat is the --name_format template, DefaultNameFormat when empty.
+	NameFormat string
+	// IncludeDisabled analyzes the disabled nodes of the manifest too.
+	IncludeDisabled bool
+	// CreditRelationshipParent also credits a relationships test to the key
+	// column (field) of the referenced table.
+	CreditRelationshipParent bool
+	// TestColumns are the kwargs paths of test_columns, by lowercase macro.
+	TestColumns map[string][]kwargsPath
+	// Exclusions are the presets and exclude_columns patterns, plus --preset.
+	Exclusions []ColumnExclusion
+	// IncludeSnapshotMetaColumns keeps the columns dbt adds to every snapshot
+	// (dbt_scd_id, dbt_valid_from...).
+	IncludeSnapshotMetaColumns bool
+	// Ownership is the owners_file, empty without one.
+	Ownership Ownership
+	// TestPackages selects the packages whose tests count for coverage.
+	TestPackages TestPackagesConfig
+}
+
+// apply sets the package-level settings of the configuration and returns its
+// parsing settings.
+func (c Config) apply() (ParseSettings, error) {
+	naming, err := c.ColumnNaming.naming()
+	if err != nil {
+		return ParseSettings{}, err
+	}
+	paths, err := c.TestColumns.compile()
+	if err != nil {
+		return ParseSettings{}, err
+	}
+	exclusions, err := columnExclusions(c.Presets, c.Exclude)
+	if err != nil {
+		return ParseSettings{}, err
+	}
+	format, err := c.NumberFormat.percentFormat()
+	if err != nil {
+		return ParseSettings{}, err
+	}
+	var owners Ownership
+	if c.OwnersFile != "" {
+		if owners, err = loadOwnership(c.OwnersFile); err != nil {
+			return ParseSettings{}, err
+		}
+	}
+	percentFormat = format
+	return ParseSettings{Naming: naming, TestColumns: paths, Exclusions: exclusions, Ownership: owners, TestPackages: c.TestPackages}, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+type CoverageRenderer interface {
+	Render(w io.Writer, report DetailedCoverageReport) error
+}
+
+type tableRenderer struct {
+	FullNames bool
+}
+
+type heatmapRenderer struct{}
+
+func newConsoleRenderer(heatmap, fullNames bool) CoverageRenderer {
+	if heatmap {
+		return heatmapRenderer{}
+	}
+	return tableRenderer{FullNames: fullNames}
+}
+
+func printDetailedCoverageReport(w io.Writer, report DetailedCoverageReport, renderer CoverageRenderer) error {
+	if renderer == nil {
+		renderer = tableRenderer{}
+	}
+	fmt.Fprintf(w, "%s ✅ Analysis done: %d tables, %d columns.\n\n",
+		currentLogPrefix(), report.TableCount, report.TotalColumns)
+	if err := renderer.Render(w, report); err != nil {
+		return err
+	}
+	printDisabledNodes(w, report.Disabled)
+	printSeedAudits(w, report.Seeds)
+	printUnattributedTests(w, report.Unattributed)
+	printTestPackages(w, report.TestPackages)
+	printWarningsSummary(w, report.Warnings)
+	return nil
+}
+
+func (r tableRenderer) Render(w io.Writer, report DetailedCoverageReport) error {
+	nameWidth := modelNameWidth(r.FullNames, report.QualityScore != nil)
+	fmt.Fprintf(w, "📊 Coverage Report (%s)\n\n", strings.ToUpper(string(report.CovType)))
+
+	if report.GroupBy == GroupByNone {
+		renderCoverageTable(w, report.TableReports, "TOTAL", report.TotalCovered, report.TotalColumns, report.QualityScore, nameWidth)
+		return nil
+	}
+
+	groups := make(map[string][]TableCoverage)
+	var names []string
+	for _, tr := range report.TableReports {
+		if _, ok := groups[tr.Group]; !ok {
+			names = append(names, tr.Group)
+		}
+		groups[tr.Group] = append(groups[tr.Group], tr)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		covered, total := 0, 0
+		for _, tr := range groups[name] {
+			covered += tr.Covered
+			total += tr.Total
+		}
+		label := name
+		if label == "" {
+			label = "(none)"
+		}
+		fmt.Fprintf(w, "📦 %s: %s\n\n", report.GroupBy, label)
+		renderCoverageTable(w, groups[name], "SUBTOTAL", covered, total, averageScore(groups[name]), nameWidth)
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "TOTAL (%d/%d) %s\n", report.TotalCovered, report.TotalColumns, formatCoverage(report.TotalCovered, report.TotalColumns))
+	return nil
+}
+
+func renderCoverageTable(w io.Writer, rows []TableCoverage, footerLabel string, covered, total int, score *float64, nameWidth int) {
+	table := tablewriter.NewWriter(w)
+	header := []string{"Model", "Columns Ratio", "Coverage"}
+	alignment := []int{tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_RIGHT}
+	if score != nil {
+		header = append(header, "Score")
+		alignment = append(alignment, tablewriter.ALIGN_RIGHT)
+	}
+	table.SetHeader(header)
+	table.SetBorder(false)
+	table.SetCenterSeparator("│")
+	table.SetColumnAlignment(alignment)
+
+	for _, tr := range rows {
+		name := tr.ModelName
+		if tr.NodeName != "" {
+			name += " (" + tr.NodeName + ")"
+		}
+		row := []string{truncateName(name, nameWidth), fmt.Sprintf("(%d/%d)", tr.Covered, tr.Total), formatCoverage(tr.Covered, tr.Total)}
+		if score != nil {
+			row = append(row, formatScore(tr.Score))
+		}
+		table.Append(row)
+	}
+
+	footer := []string{footerLabel, fmt.Sprintf("(%d/%d)", covered, total), formatCoverage(covered, total)}
+	if score != nil {
+		footer = append(footer, formatScore(score))
+	}
+	table.SetFooter(footer)
+
+	table.Render()
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// enableVirtualTerminal reports whether f understands the ANSI escape
+// sequences, which Unix terminals always do.
+func enableVirtualTerminal(f *os.File) bool {
+	return true
+}
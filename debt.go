@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// defaultDebtMinutes estimates the time needed to cover one column: writing a
+// description is quicker than writing and running a test.
+var defaultDebtMinutes = map[string]float64{
+	string(CoverageTypeDoc):  5,
+	string(CoverageTypeTest): 10,
+}
+
+const fallbackDebtMinutes = 5
+
+type DebtConfig struct {
+	MinutesPerColumn map[string]float64 `yaml:"minutes_per_column"`
+	GroupBy          string             `yaml:"group_by"`
+}
+
+func (c DebtConfig) validate() error {
+	for covType, minutes := range c.MinutesPerColumn {
+		if minutes <= 0 {
+			return fmt.Errorf("coverage_debt minutes of %s must be positive", covType)
+		}
+	}
+	if _, err := ParseGroupBy(c.GroupBy); err != nil {
+		return fmt.Errorf("coverage_debt: %w", err)
+	}
+	return nil
+}
+
+// minutes returns the remediation time of one uncovered column of covType:
+// the configured value, the configured default, then the built-in one.
+func (c DebtConfig) minutes(covType CoverageType) float64 {
+	for _, key := range []string{string(covType), "default"} {
+		if minutes, ok := c.MinutesPerColumn[key]; ok {
+			return minutes
+		}
+	}
+	if minutes, ok := defaultDebtMinutes[string(covType)]; ok {
+		return minutes
+	}
+	return fallbackDebtMinutes
+}
+
+// groupBy returns the grouping of the debt: the configured one, then the
+// --group_by of the report, then the folders.
+func (c DebtConfig) groupBy(reportGroupBy string) GroupBy {
+	for _, value := range []string{c.GroupBy, reportGroupBy} {
+		if g, _ := ParseGroupBy(value); g != GroupByNone {
+			return g
+		}
+	}
+	return GroupByFolder
+}
+
+type DebtGroup struct {
+	Name      string  `json:"name"`
+	Uncovered int     `json:"uncovered"`
+	Minutes   float64 `json:"minutes"`
+}
+
+type DebtReport struct {
+	MinutesPerColumn float64     `json:"minutes_per_column"`
+	Uncovered        int         `json:"uncovered"`
+	Minutes          float64     `json:"minutes"`
+	GroupBy          string      `json:"group_by"`
+	Groups           []DebtGroup `json:"groups"`
+}
+
+func reportGroupKey(t TableReport, g GroupBy) string {
+	switch g {
+	case GroupByPackage:
+		return t.PackageName
+	case GroupByOwner:
+		if len(t.Owners) == 0 {
+			return UnownedGroup
+		}
+		return t.Owners[0]
+	case GroupByDatabase:
+		return warehouseLocation(t.Database, t.Schema)
+	}
+	return reportFolder(t)
+}
+
+// computeDebt estimates the remediation effort of the report: its uncovered
+// columns (models for the table-level types) times the minutes per column,
+// largest groups first.
+func computeDebt(report JSONReport, cfg DebtConfig) *DebtReport {
+	g := cfg.groupBy(report.GroupBy)
+	debt := &DebtReport{MinutesPerColumn: cfg.minutes(CoverageType(report.CovType)), GroupBy: string(g)}
+	byName := make(map[string]*DebtGroup)
+	for _, t := range report.Tables {
+		uncovered := t.Total - t.Covered
+		if uncovered == 0 {
+			continue
+		}
+		name := reportGroupKey(t, g)
+		group, ok := byName[name]
+		if !ok {
+			group = &DebtGroup{Name: name}
+			byName[name] = group
+		}
+		group.Uncovered += uncovered
+		debt.Uncovered += uncovered
+	}
+	debt.Minutes = float64(debt.Uncovered) * debt.MinutesPerColumn
+	debt.Groups = make([]DebtGroup, 0, len(byName))
+	for _, group := range byName {
+		group.Minutes = float64(group.Uncovered) * debt.MinutesPerColumn
+		debt.Groups = append(debt.Groups, *group)
+	}
+	sort.Slice(debt.Groups, func(i, j int) bool {
+		if debt.Groups[i].Uncovered != debt.Groups[j].Uncovered {
+			return debt.Groups[i].Uncovered > debt.Groups[j].Uncovered
+		}
+		return debt.Groups[i].Name < debt.Groups[j].Name
+	})
+	return debt
+}
+
+// formatEffort prints minutes as 45min, 3h30 or 2h.
+func formatEffort(minutes float64) string {
+	total := int(minutes + 0.5)
+	switch {
+	case total < 60:
+		return fmt.Sprintf("%dmin", total)
+	case total%60 == 0:
+		return fmt.Sprintf("%dh", total/60)
+	}
+	return fmt.Sprintf("%dh%02d", total/60, total%60)
+}
+
+func printDebt(w io.Writer, debt *DebtReport) {
+	if debt == nil {
+		return
+	}
+	fmt.Fprintf(w, "\n⏳ Coverage debt: %d uncovered, about %s (%g min each)\n\n", debt.Uncovered, formatEffort(debt.Minutes), debt.MinutesPerColumn)
+	for _, g := range debt.Groups {
+		fmt.Fprintf(w, "  %-30s %5d  %s\n", g.Name, g.Uncovered, formatEffort(g.Minutes))
+	}
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	DiffImproved  = "improved"
+	DiffRegressed = "regressed"
+	DiffUnchanged = "unchanged"
+	DiffAdded     = "added"
+	DiffRemoved   = "removed"
+	// DiffNotComparable is the status of the models when the older manifest
+	// cannot express the coverage type of the reports.
+	DiffNotComparable = "not_comparable"
+	// DiffRenamed counts the renamed models, which keep the status of their
+	// coverage and carry RenamedFrom.
+	DiffRenamed = "renamed"
+)
+
+// The changes of a column between two reports, for the coverage type of the
+// reports.
+const (
+	ColumnCovered        = "covered"
+	ColumnUncovered      = "uncovered"
+	ColumnAdded          = "added"
+	ColumnAddedUncovered = "added_uncovered"
+	ColumnRemoved        = "removed"
+	ColumnChanged        = "changed"
+)
+
+// renameMinSimilarity is the share of columns a removed and an added model
+// must have in common to be reported as a rename, 1 being the same columns.
+const renameMinSimilarity = 0.8
+
+type ReportSummary struct {
+	GeneratedAt string            `json:"generated_at,omitempty"`
+	GitSHA      string            `json:"git_sha,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Covered     int               `json:"covered"`
+	Total       int               `json:"total"`
+	Coverage    float64           `json:"coverage"`
+}
+
+type TableDiff struct {
+	Name         string  `json:"name"`
+	RenamedFrom  string  `json:"renamed_from,omitempty"`
+	Status       string  `json:"status"`
+	BaseCovered  int     `json:"base_covered"`
+	BaseTotal    int     `json:"base_total"`
+	BaseCoverage float64 `json:"base_coverage"`
+	HeadCovered  int     `json:"head_covered"`
+	HeadTotal    int     `json:"head_total"`
+	HeadCoverage float64 `json:"head_coverage"`
+	Delta        float64 `json:"delta"`
+}
+
+// ColumnDiff is a column whose state changed: covered or uncovered for the
+// coverage type of the reports, added or removed, and the other dimensions
+// it gained (+doc) or lost (-test).
+type ColumnDiff struct {
+	Model   string   `json:"model"`
+	Column  string   `json:"column"`
+	Status  string   `json:"status"`
+	Changes []string `json:"changes,omitempty"`
+}
+
+type ReportDiff struct {
+	CovType string         `json:"cov_type"`
+	Base    ReportSummary  `json:"base"`
+	Head    ReportSummary  `json:"head"`
+	Delta   float64        `json:"delta"`
+	Counts  map[string]int `json:"counts"`
+	Tables  []TableDiff    `json:"tables"`
+	Columns []ColumnDiff   `json:"columns,omitempty"`
+	// Compatibility is set when the reports come from different versions of
+	// the manifest schema, typically across a dbt upgrade.
+	Compatibility *DiffCompatibility `json:"compatibility,omitempty"`
+}
+
+// manifestFeatures are the coverage dimensions introduced by a version of the
+// manifest schema: an older manifest cannot express them.
+var manifestFeatures = map[string]int{
+	string(CoverageTypeContract): 9,  // dbt 1.5
+	string(CoverageTypeUnitTest): 12, // dbt 1.8
+}
+
+// ManifestSummary counts the nodes and the coverage of a report generated
+// from a version of the manifest schema.
+type ManifestSummary struct {
+	ManifestVersion string  `json:"manifest_version"`
+	Nodes           int     `json:"nodes"`
+	Columns         int     `json:"columns"`
+	Covered         int     `json:"covered"`
+	Total           int     `json:"total"`
+	Coverage        float64 `json:"coverage"`
+}
+
+// DiffCompatibility notes a comparison across manifest schema versions, and
+// the dimensions left out of the column changes because the older manifest
+// cannot express them, so upgrading dbt does not show as regressions.
+type DiffCompatibility struct {
+	Base    ManifestSummary `json:"base"`
+	Head    ManifestSummary `json:"head"`
+	Note    string          `json:"note"`
+	Ignored []string        `json:"ignored_dimensions,omitempty"`
+}
+
+func summarizeManifest(report JSONReport) ManifestSummary {
+	s := ManifestSummary{
+		ManifestVersion: report.ManifestVersion,
+		Nodes:           len(report.Tables),
+		Covered:         report.Covered,
+		Total:           report.Total,
+		Coverage:        report.Coverage,
+	}
+	for _, t := range report.Tables {
+		s.Columns += len(t.Columns)
+	}
+	return s
+}
+
+// manifestCompatibility compares the manifest versions of the reports, nil
+// when they are the same or unknown (reports older than the field).
+func manifestCompatibility(base, head JSONReport) *DiffCompatibility {
+	baseVersion, headVersion := manifestVersionNumber(base.ManifestVersion), manifestVersionNumber(head.ManifestVersion)
+	if baseVersion == 0 || headVersion == 0 || baseVersion == headVersion {
+		return nil
+	}
+	c := &DiffCompatibility{Base: summarizeManifest(base), Head: summarizeManifest(head)}
+	oldest := min(baseVersion, headVersion)
+	for _, name := range sortedKeys(manifestFeatures) {
+		if manifestFeatures[name] > oldest {
+			c.Ignored = append(c.Ignored, name)
+		}
+	}
+	c.Note = fmt.Sprintf("reports generated from manifest %s and %s", base.ManifestVersion, head.ManifestVersion)
+	if len(c.Ignored) > 0 {
+		c.Note += fmt.Sprintf(", %s left out of the column changes as manifest v%d does not express them", strings.Join(c.Ignored, ", "), oldest)
+	}
+	if containsString(c.Ignored, head.CovType) {
+		c.Note += fmt.Sprintf(", the %s coverage of manifest v%d is not comparable and the models are marked %s", head.CovType, oldest, DiffNotComparable)
+	}
+	return c
+}
+
+func summarizeReport(report JSONReport) ReportSummary {
+	return ReportSummary{
+		GeneratedAt: report.GeneratedAt,
+		GitSHA:      report.GitSHA,
+		Labels:      report.Labels,
+		Covered:     report.Covered,
+		Total:       report.Total,
+		Coverage:    report.Coverage,
+	}
+}
+
+func diffReports(base, head JSONReport) ReportDiff {
+	diff := ReportDiff{
+		CovType: head.CovType,
+		Base:    summarizeReport(base),
+		Head:    summarizeReport(head),
+		Delta:   head.Coverage - base.Coverage,
+		Counts:  make(map[string]int),
+	}
+	diff.Compatibility = manifestCompatibility(base, head)
+	var ignored []string
+	if diff.Compatibility != nil {
+		ignored = diff.Compatibility.Ignored
+	}
+	comparable := !containsString(ignored, head.CovType)
+	baseTables := make(map[string]TableReport, len(base.Tables))
+	for _, t := range base.Tables {
+		baseTables[t.Name] = t
+	}
+	var added []TableReport
+	headNames := make(map[string]bool, len(head.Tables))
+	for _, h := range head.Tables {
+		headNames[h.Name] = true
+		if _, ok := baseTables[h.Name]; !ok {
+			added = append(added, h)
+		}
+	}
+	// Only the models gone from head may have been renamed: a copy of a model
+	// that still exists is an addition.
+	removed := make(map[string]TableReport)
+	for name, b := range baseTables {
+		if !headNames[name] {
+			removed[name] = b
+		}
+	}
+	renamedFrom := detectRenames(removed, added)
+	for _, h := range head.Tables {
+		td := TableDiff{
+			Name:         h.Name,
+			HeadCovered:  h.Covered,
+			HeadTotal:    h.Total,
+			HeadCoverage: h.Coverage,
+		}
+		b, ok := baseTables[h.Name]
+		if !ok {
+			if from, renamed := renamedFrom[h.Name]; renamed {
+				b, ok = baseTables[from], true
+				td.RenamedFrom = from
+			}
+		}
+		if ok {
+			td.BaseCovered = b.Covered
+			td.BaseTotal = b.Total
+			td.BaseCoverage = b.Coverage
+			td.Delta = h.Coverage - b.Coverage
+			switch {
+			case !comparable:
+				td.Status = DiffNotComparable
+			case td.Delta > 0:
+				td.Status = DiffImproved
+			case td.Delta < 0:
+				td.Status = DiffRegressed
+			default:
+				td.Status = DiffUnchanged
+			}
+			delete(baseTables, b.Name)
+			diff.Columns = append(diff.Columns, diffColumns(h.Name, b.Columns, h.Columns, ignored, comparable)...)
+		} else {
+			td.Status = DiffAdded
+			td.Delta = h.Coverage
+			diff.Columns = append(diff.Columns, diffColumns(h.Name, nil, h.Columns, ignored, comparable)...)
+		}
+		diff.Tables = append(diff.Tables, td)
+	}
+	for _, b := range baseTables {
+		diff.Tables = append(diff.Tables, TableDiff{
+			Name:         b.Name,
+			Status:       DiffRemoved,
+			BaseCovered:  b.Covered,
+			BaseTotal:    b.Total,
+			BaseCoverage: b.Coverage,
+			Delta:        -b.Coverage,
+		})
+	}
+	for _, td := range diff.Tables {
+		diff.Counts[td.Status]++
+		if td.RenamedFrom != "" {
+			diff.Counts[DiffRenamed]++
+		}
+	}
+	sort.SliceStable(diff.Tables, func(i, j int) bool {
+		if diff.Tables[i].Delta != diff.Tables[j].Delta {
+			return diff.Tables[i].Delta < diff.Tables[j].Delta
+		}
+		return diff.Tables[i].Name < diff.Tables[j].Name
+	})
+	return diff
+}
+
+// detectRenames pairs the models added in head with the models removed from
+// base that are likely the same one renamed: same unique_id (new alias or
+// schema), or mostly the same columns, the .sql file having kept its name in
+// a new folder or the columns matching renameMinSimilarity. It returns the
+// base name of each renamed head model.
+func detectRenames(removed map[string]TableReport, added []TableReport) map[string]string {
+	type candidate struct {
+		from, to string
+		score    float64
+	}
+	var candidates []candidate
+	for _, h := range added {
+		for _, b := range removed {
+			similarity := columnSimilarity(b, h)
+			sameFile := b.OriginalFilePath != "" && path.Base(slashPath(b.OriginalFilePath)) == path.Base(slashPath(h.OriginalFilePath))
+			score := similarity
+			switch {
+			case b.UniqueID != "" && b.UniqueID == h.UniqueID:
+				score += 2
+			case sameFile && similarity >= renameMinSimilarity/2:
+				score++
+			case similarity < renameMinSimilarity:
+				continue
+			}
+			candidates = append(candidates, candidate{b.Name, h.Name, score})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		if candidates[i].to != candidates[j].to {
+			return candidates[i].to < candidates[j].to
+		}
+		return candidates[i].from < candidates[j].from
+	})
+	renamed := make(map[string]string)
+	used := make(map[string]bool)
+	for _, c := range candidates {
+		if _, ok := renamed[c.to]; ok || used[c.from] {
+			continue
+		}
+		renamed[c.to] = c.from
+		used[c.from] = true
+	}
+	return renamed
+}
+
+// columnSimilarity is the Jaccard index of the column names of two models, 0
+// when either has no column in the report.
+func columnSimilarity(a, b TableReport) float64 {
+	if len(a.Columns) == 0 || len(b.Columns) == 0 {
+		return 0
+	}
+	names := make(map[string]bool, len(a.Columns))
+	for _, c := range a.Columns {
+		names[c.Name] = true
+	}
+	common := 0
+	for _, c := range b.Columns {
+		if names[c.Name] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a.Columns)+len(b.Columns)-common)
+}
+
+// diffColumns lists the columns of a model that changed between two reports,
+// in the order of the head report, then the removed ones. The ignored
+// dimensions are not compared, nor the coverage when not comparable.
+func diffColumns(model string, base, head []ColumnReport, ignored []string, comparable bool) []ColumnDiff {
+	baseColumns := make(map[string]ColumnReport, len(base))
+	for _, c := range base {
+		baseColumns[c.Name] = c
+	}
+	var diffs []ColumnDiff
+	for _, h := range head {
+		b, ok := baseColumns[h.Name]
+		delete(baseColumns, h.Name)
+		cd := ColumnDiff{Model: model, Column: h.Name}
+		switch {
+		case !ok && h.Covered > 0:
+			cd.Status = ColumnAdded
+		case !ok:
+			cd.Status = ColumnAddedUncovered
+		case !comparable:
+			cd.Status = ColumnChanged
+		case b.Covered == 0 && h.Covered > 0:
+			cd.Status = ColumnCovered
+		case b.Covered > 0 && h.Covered == 0:
+			cd.Status = ColumnUncovered
+		default:
+			cd.Status = ColumnChanged
+		}
+		if ok {
+			cd.Changes = dimensionChanges(b.Dimensions, h.Dimensions, ignored)
+		}
+		if cd.Status == ColumnChanged && len(cd.Changes) == 0 {
+			continue
+		}
+		diffs = append(diffs, cd)
+	}
+	for _, b := range base {
+		if _, ok := baseColumns[b.Name]; ok {
+			diffs = append(diffs, ColumnDiff{Model: model, Column: b.Name, Status: ColumnRemoved})
+		}
+	}
+	return diffs
+}
+
+// dimensionChanges lists the dimensions a column gained (+doc) or lost
+// (-test), sorted by name.
+func dimensionChanges(base, head map[string]bool, ignored []string) []string {
+	var changes []string
+	for _, name := range sortedKeys(head) {
+		if head[name] && !base[name] && !containsString(ignored, name) {
+			changes = append(changes, "+"+name)
+		}
+	}
+	for _, name := range sortedKeys(base) {
+		if base[name] && !head[name] && !containsString(ignored, name) {
+			changes = append(changes, "-"+name)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i][1:] < changes[j][1:] })
+	return changes
+}
+
+// checkNewColumns fails when columns were added between the reports without
+// the coverage of their type (--require_coverage_for_new_columns), be they
+// new columns of a model or the columns of a new model.
+func checkNewColumns(diff ReportDiff) error {
+	var missing []string
+	for _, c := range diff.Columns {
+		if c.Status == ColumnAddedUncovered {
+			missing = append(missing, c.Model+"."+c.Column)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	noun := "columns"
+	if len(missing) == 1 {
+		noun = "column"
+	}
+	return fmt.Errorf("%d new %s without %s coverage: %s", len(missing), noun, diff.CovType, strings.Join(missing, ", "))
+}
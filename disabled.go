@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+type DisabledNode struct {
+	UniqueID   string `json:"unique_id"`
+	Name       string `json:"name"`
+	PatchPath  string `json:"patch_path,omitempty"`
+	Documented bool   `json:"documented"`
+}
+
+func (m *Manifest) excluded(tableID string) bool {
+	_, disabled := m.Disabled[tableID]
+	return disabled && !m.Settings.IncludeDisabled
+}
+
+func parseDisabledNodes(manifestJSON map[string]interface{}, settings ParseSettings) map[string]map[string]interface{} {
+	nodes := make(map[string]map[string]interface{})
+	disabled, _ := manifestJSON["disabled"].(map[string]interface{})
+	for id, v := range disabled {
+		versions, _ := v.([]interface{})
+		if len(versions) == 0 {
+			continue
+		}
+		node, ok := versions[0].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resourceType, _ := node["resource_type"].(string)
+		if !containsString(ResourceTypes, resourceType) {
+			continue
+		}
+		nodes[id] = normalizeTable(node, settings)
+	}
+	return nodes
+}
+
+func disabledNodes(manifest *Manifest) []DisabledNode {
+	if manifest.Settings.IncludeDisabled {
+		return nil
+	}
+	nodes := make([]DisabledNode, 0, len(manifest.Disabled))
+	for id, node := range manifest.Disabled {
+		name, _ := node["name"].(string)
+		patchPath, _ := node["patch_path"].(string)
+		description, _ := node["description"].(string)
+		documented := description != ""
+		columns, _ := node["columns"].(map[string]interface{})
+		for _, v := range columns {
+			if col, ok := v.(map[string]interface{}); ok && IsValidDoc(col["description"]) {
+				documented = true
+			}
+		}
+		nodes = append(nodes, DisabledNode{UniqueID: id, Name: name, PatchPath: patchPath, Documented: documented})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].UniqueID < nodes[j].UniqueID })
+	return nodes
+}
+
+func printDisabledNodes(w io.Writer, nodes []DisabledNode) {
+	if len(nodes) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n🚫 %d disabled nodes excluded (use --include_disabled to audit them):\n", len(nodes))
+	for _, n := range nodes {
+		if n.Documented && n.PatchPath != "" {
+			fmt.Fprintf(w, "  - %s, still documented in %s\n", n.UniqueID, n.PatchPath)
+		} else {
+			fmt.Fprintf(w, "  - %s\n", n.UniqueID)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// dryRun resolves the configuration, artifacts, filters and thresholds of a
+// run and prints what would be analyzed. Only manifest.json is parsed.
+func dryRun(ctx context.Context, w io.Writer, opts Options, configPath string) error {
+	fmt.Fprintln(w, "🔍 Dry run, nothing is computed")
+	fmt.Fprintln(w)
+
+	if configPath == "" {
+		configPath = filepath.Join(opts.ProjectDir, DefaultConfigFile)
+	}
+	fmt.Fprintf(w, "Configuration:  %s\n", describeFile(configPath, "defaults used"))
+	covTypes := []CoverageType{opts.CovType}
+	for component := range opts.QualityWeights {
+		covTypes = append(covTypes, CoverageType(component))
+	}
+	withCatalog := catalogRequired(covTypes...)
+	manifestPath := artifactPath(opts.ProjectDir, opts.RunArtifactsDir, "manifest.json")
+	catalogPath := artifactPath(opts.ProjectDir, opts.RunArtifactsDir, "catalog.json")
+	describe := describeFile
+	if opts.Archive != nil {
+		manifestPath, catalogPath = opts.Archive.name("manifest.json"), opts.Archive.name("catalog.json")
+		describe = opts.Archive.describe
+	}
+	fmt.Fprintf(w, "Manifest:       %s\n", describe(manifestPath, "required"))
+	if withCatalog {
+		fmt.Fprintf(w, "Catalog:        %s\n", describe(catalogPath, "required"))
+	} else {
+		fmt.Fprintf(w, "Catalog:        %s (not needed for %s)\n", catalogPath, opts.CovType)
+	}
+	fmt.Fprintf(w, "Coverage type:  %s\n", opts.CovType)
+	fmt.Fprintf(w, "Output:         %s (%s)\n", opts.Output, opts.Format)
+	for _, o := range opts.ExtraOutputs {
+		fmt.Fprintf(w, "                %s (%s)\n", o.Path, o.Format)
+	}
+	fmt.Fprintln(w)
+
+	var manifest *Manifest
+	var err error
+	if opts.Archive != nil {
+		manifest, err = opts.Archive.loadManifest(opts.Parse)
+	} else {
+		manifest, err = loadManifest(opts.ProjectDir, opts.RunArtifactsDir, opts.Parse)
+	}
+	if err != nil {
+		return err
+	}
+	catalog, err := CatalogFromManifest(manifest)
+	if err != nil {
+		return err
+	}
+	if catalog, err = EnrichCatalog(ctx, catalog, manifest); err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for _, table := range catalog.Tables {
+		counts[table.ResourceType]++
+	}
+	fmt.Fprintf(w, "Nodes in the manifest: %d (%s)\n", len(catalog.Tables), formatCounts(counts))
+	_, external := catalog.SplitExternal()
+	if len(external.Tables) > 0 {
+		fmt.Fprintf(w, "External sources: %d (%s)\n", len(external.Tables), opts.ExternalSources)
+	}
+	if len(catalog.Disabled) > 0 {
+		fmt.Fprintf(w, "Disabled nodes excluded: %d\n", len(catalog.Disabled))
+	}
+
+	if len(opts.ModelPathFilter) > 0 {
+		for _, filter := range opts.ModelPathFilter {
+			fmt.Fprintf(w, "  path_filter %-30q %d tables\n", filter, len(catalog.FilterTables([]string{filter}).Tables))
+		}
+		all := catalog
+		catalog = catalog.FilterTables(opts.ModelPathFilter)
+		fmt.Fprintf(w, "After path_filter: %d tables\n", len(catalog.Tables))
+		if len(catalog.Tables) == 0 {
+			fmt.Fprintf(w, "  ⚠️ no table left, path_filter is matched against original_file_path, e.g. %s\n",
+				strings.Join(sampleFolders(all, 5), ", "))
+		}
+	}
+	if len(opts.ResourceTypes) > 0 {
+		catalog = catalog.FilterResourceTypes(opts.ResourceTypes)
+		fmt.Fprintf(w, "After resource_types %s: %d tables\n", strings.Join(opts.ResourceTypes, ","), len(catalog.Tables))
+	}
+	if !opts.Since.IsZero() {
+		dates, err := creationDates(ctx, opts.CreatedAt, opts.ProjectDir, catalog)
+		if err != nil {
+			return err
+		}
+		unknown := len(catalog.Tables) - len(dates)
+		catalog = catalog.FilterCreatedSince(dates, opts.Since)
+		fmt.Fprintf(w, "After since %s (%s): %d tables, %d without creation date\n", opts.Since.Format("2006-01-02"), opts.CreatedAt, len(catalog.Tables), unknown)
+	}
+	if len(opts.ModelSelector) > 0 {
+		selected := 0
+		for _, table := range catalog.Tables {
+			if opts.ModelSelector.Matches(selectorReport(table)) {
+				selected++
+			}
+		}
+		fmt.Fprintf(w, "Checked by the per-model thresholds (%s): %d tables\n", strings.Join(opts.ModelSelector, ","), selected)
+	}
+	fmt.Fprintf(w, "Tables to analyze: %d\n", len(catalog.Tables))
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Thresholds:")
+	threshold := func(name string, enabled bool, value string) {
+		if !enabled {
+			value = "disabled"
+		}
+		fmt.Fprintf(w, "  %-24s %s\n", name, value)
+	}
+	threshold("fail_under", opts.FailUnder > 0, fmt.Sprintf("%.1f%%", opts.FailUnder))
+	threshold("fail_under_per_model", opts.FailUnderModel > 0, fmt.Sprintf("%.1f%%", opts.FailUnderModel))
+	threshold("max_uncovered", opts.MaxUncovered != nil, optionalInt(opts.MaxUncovered))
+	threshold("max_uncovered_per_model", opts.MaxUncoveredModel != nil, optionalInt(opts.MaxUncoveredModel))
+	threshold("baseline", opts.Baseline != "", describeFile(opts.Baseline, "required"))
+	threshold("budgets", len(opts.Budgets) > 0, fmt.Sprintf("%d", len(opts.Budgets)))
+	threshold("changed_files", opts.ChangedFiles != nil, fmt.Sprintf("%d files", len(opts.ChangedFiles)))
+	threshold("exemptions", len(opts.Exemptions) > 0, fmt.Sprintf("%d", len(opts.Exemptions)))
+	threshold("fail_on_warning", opts.FailOnWarning, "any warning")
+	return nil
+}
+
+func describeFile(path, missing string) string {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Sprintf("%s (not found, %s)", path, missing)
+	}
+	return path
+}
+
+func formatCounts(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, k := range sortedKeys(counts) {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[k], k))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sampleFolders(catalog Catalog, n int) []string {
+	seen := make(map[string]bool)
+	for _, table := range catalog.Tables {
+		seen[tableFolder(table)+"/"] = true
+	}
+	folders := sortedKeys(seen)
+	if len(folders) > n {
+		folders = folders[:n]
+	}
+	return folders
+}
+
+func selectorReport(table Table) TableReport {
+	return TableReport{
+		Name:             table.Name,
+		UniqueID:         table.UniqueID,
+		ResourceType:     table.ResourceType,
+		PackageName:      table.PackageName,
+		OriginalFilePath: table.OriginalFilePath,
+		Owners:           table.Owners,
+		Tags:             table.Tags,
+	}
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
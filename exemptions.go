@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"time"
+)
+
+// Exemption accepts the coverage debt of a model, or of some of its columns,
+// until a date: while it runs the matched columns are left out of the
+// coverage like exclude_columns, once expired they count again and fail the
+// run (expired_exemption).
+type Exemption struct {
+	Model  string    `yaml:"model"`
+	Column string    `yaml:"column"`
+	Reason string    `yaml:"reason"`
+	Until  time.Time `yaml:"until"`
+}
+
+type ExemptionStatus struct {
+	Model    string `json:"model"`
+	Column   string `json:"column,omitempty"`
+	Reason   string `json:"reason"`
+	Until    string `json:"until"`
+	DaysLeft int    `json:"days_left,omitempty"`
+	Expired  bool   `json:"expired,omitempty"`
+	Columns  int    `json:"columns"`
+}
+
+func (e Exemption) validate() error {
+	switch {
+	case e.Model == "":
+		return errors.New("exemption without model")
+	case e.Reason == "":
+		return fmt.Errorf("exemption of %s has no reason", e.Model)
+	case e.Until.IsZero():
+		return fmt.Errorf("exemption of %s has no until date", e.Model)
+	}
+	for _, pattern := range []string{e.Model, e.Column} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("exemption of %s: invalid pattern %q: %w", e.Model, pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchesTable matches the model pattern against the displayed name, the dbt
+// node name and the unique_id of the table.
+func (e Exemption) matchesTable(t Table) bool {
+	for _, name := range []string{t.Name, t.NodeName, t.UniqueID} {
+		if name != "" && matchesAny(name, []string{e.Model}) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyExemptions removes the columns of the running exemptions, and the
+// tables exempted as a whole, from the catalog. An exemption runs until the
+// end of its until day. The statuses count the columns each exemption
+// matched, expired ones included.
+func (c Catalog) ApplyExemptions(exemptions []Exemption, now time.Time) (Catalog, []ExemptionStatus) {
+	if len(exemptions) == 0 {
+		return c, nil
+	}
+	statuses := make([]ExemptionStatus, len(exemptions))
+	for i, e := range exemptions {
+		end := e.Until.AddDate(0, 0, 1)
+		statuses[i] = ExemptionStatus{Model: e.Model, Column: e.Column, Reason: e.Reason, Until: e.Until.Format(time.DateOnly), Expired: !now.Before(end)}
+		if !statuses[i].Expired {
+			statuses[i].DaysLeft = int(end.Sub(now).Hours() / 24)
+		}
+	}
+	tables := make(map[string]Table, len(c.Tables))
+	for id, table := range c.Tables {
+		exempted := false
+		var columns map[string]Column
+		for i, e := range exemptions {
+			if !e.matchesTable(table) {
+				continue
+			}
+			for name := range table.Columns {
+				if e.Column == "" || matchesAny(name, []string{e.Column}) {
+					statuses[i].Columns++
+				}
+			}
+			if statuses[i].Expired {
+				continue
+			}
+			if e.Column == "" {
+				exempted = true
+				continue
+			}
+			if columns == nil {
+				columns = make(map[string]Column, len(table.Columns))
+				for name, col := range table.Columns {
+					columns[name] = col
+				}
+			}
+			for name := range columns {
+				if matchesAny(name, []string{e.Column}) {
+					delete(columns, name)
+				}
+			}
+		}
+		if exempted {
+			continue
+		}
+		if columns != nil {
+			table.Columns = columns
+		}
+		tables[id] = table
+	}
+	for _, s := range statuses {
+		if s.Columns == 0 {
+			log.Printf("Exemption of %s %s matches no column", s.Model, s.Column)
+		}
+	}
+	c.Tables = tables
+	return c, statuses
+}
+
+func printExemptions(w io.Writer, statuses []ExemptionStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n🗓️ Coverage exemptions\n\n")
+	for _, s := range statuses {
+		target := s.Model
+		if s.Column != "" {
+			target += "." + s.Column
+		}
+		status := fmt.Sprintf("%d days left", s.DaysLeft)
+		if s.Expired {
+			status = "⛔ expired"
+		}
+		fmt.Fprintf(w, "  %-30s until %s  %-14s %s\n", target, s.Until, status, s.Reason)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+func runExplain(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("explain")
+	var (
+		projectDir      = fs.String("dbt_dir", ".", "dbt project path")
+		runArtifactsDir = fs.String("target_dir", "target", "dbt target path")
+		configPath      = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		nameFormat      = fs.String("name_format", DefaultNameFormat, "Model display name template (see the main command)")
+		caseSensitive   = fs.Bool("case_sensitive", false, "Match column names case-sensitively")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: dbt-goverage explain [flags] <model>.<column>")
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	if err := ValidateNameFormat(*nameFormat); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath, *projectDir)
+	if err != nil {
+		return err
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		return err
+	}
+	if *caseSensitive {
+		settings.Naming.CaseSensitive = true
+	}
+	settings.NameFormat = *nameFormat
+	// Explaining a disabled model or a snapshot metadata column is
+	// legitimate, they are flagged below.
+	settings.IncludeDisabled = true
+	settings.IncludeSnapshotMetaColumns = true
+	defer closeCoverageProviders()
+	registerHeuristicProviders(cfg.Heuristics)
+	if err := registerPlugins(cfg.Plugins); err != nil {
+		return err
+	}
+
+	manifest, err := loadManifest(*projectDir, *runArtifactsDir, settings)
+	if err != nil {
+		return err
+	}
+	// Explaining an excluded column is legitimate too.
+	exclusions := settings.Exclusions
+	settings.Exclusions = nil
+	var catalog Catalog
+	if _, statErr := os.Stat(artifactPath(*projectDir, *runArtifactsDir, "catalog.json")); statErr == nil {
+		catalog, err = loadCatalog(*projectDir, *runArtifactsDir, manifest)
+	} else {
+		catalog, err = CatalogFromManifest(manifest)
+	}
+	if err != nil {
+		return err
+	}
+	if catalog, err = EnrichCatalog(ctx, catalog, manifest); err != nil {
+		return err
+	}
+	return explainColumn(ctx, os.Stdout, catalog, manifest, exclusions, fs.Arg(0))
+}
+
+// resolveColumnRef splits model.column against the known tables, accepting the
+// display name, the dbt node name or the unique_id of the model.
+func resolveColumnRef(catalog Catalog, ref string) (Table, string, error) {
+	var best Table
+	bestLen := 0
+	for _, table := range catalog.Tables {
+		for _, name := range []string{table.Name, table.NodeName, table.UniqueID, nodeName(table.UniqueID)} {
+			if name == "" || len(name) <= bestLen || !strings.HasPrefix(strings.ToLower(ref), strings.ToLower(name)+".") {
+				continue
+			}
+			best, bestLen = table, len(name)
+		}
+	}
+	if bestLen == 0 {
+		return Table{}, "", fmt.Errorf("no model matches %q, expected <model>.<column>", ref)
+	}
+	return best, ref[bestLen+1:], nil
+}
+
+func nodeName(uniqueID string) string {
+	if i := strings.LastIndex(uniqueID, "."); i >= 0 {
+		return uniqueID[i+1:]
+	}
+	return uniqueID
+}
+
+// explainColumn tells how a column is counted, exclusions being the patterns
+// it was loaded without.
+func explainColumn(ctx context.Context, w io.Writer, catalog Catalog, manifest *Manifest, exclusions []ColumnExclusion, ref string) error {
+	table, rawColumn, err := resolveColumnRef(catalog, ref)
+	if err != nil {
+		return err
+	}
+	naming := manifest.Settings.Naming
+	key := naming.Key(rawColumn)
+	fmt.Fprintf(w, "🔎 %s › %s\n\n", table.UniqueID, rawColumn)
+	fmt.Fprintf(w, "Model:        %s (%s, %s)\n", table.Name, table.ResourceType, table.OriginalFilePath)
+	if table.PatchPath != "" {
+		fmt.Fprintf(w, "Schema file:  %s (edit the docs and tests here)\n", table.PatchPath)
+	}
+	if len(table.Owners) > 0 {
+		fmt.Fprintf(w, "Owners:       %s\n", strings.Join(table.Owners, " "))
+	}
+	if table.Disabled {
+		fmt.Fprintln(w, "Excluded:     disabled node, only analyzed with --include_disabled")
+	}
+	manifestTable, _ := manifest.GetTable(table.UniqueID)
+	if isSnapshotMetaColumn(manifestTable, key) {
+		fmt.Fprintln(w, "Excluded:     snapshot metadata column, only counted with --include_snapshot_meta_columns")
+	}
+	if e, ok := excludedColumn(exclusions, key); ok {
+		fmt.Fprintf(w, "Excluded:     matches %q (%s), so it is not counted\n", e.Pattern, e.Source)
+	}
+	fmt.Fprintf(w, "Normalized:   %q (%s)\n", key, describeNaming(naming))
+
+	col, inCatalog := table.Columns[key]
+	if !inCatalog {
+		fmt.Fprintln(w, "Catalog:      ❌ column not found, so it is not counted")
+		if names := sortedKeys(table.Columns); len(names) > 0 {
+			fmt.Fprintf(w, "              known columns: %s\n", strings.Join(names, ", "))
+		}
+		return nil
+	}
+	fmt.Fprintf(w, "Catalog:      found (index %d)\n", col.Index)
+
+	manifestColumns, _ := manifestTable["columns"].(map[string]interface{})
+	colInfo := lookupColumnInfo(manifestColumns, key, naming)
+	switch {
+	case colInfo == nil && table.PatchPath == "":
+		fmt.Fprintln(w, "Manifest:     ❌ model not documented in any yml file")
+	case colInfo == nil:
+		fmt.Fprintf(w, "Manifest:     ❌ column not declared in %s\n", table.PatchPath)
+	default:
+		rawName, _ := colInfo["name"].(string)
+		fmt.Fprintf(w, "Manifest:     declared as %q in %s\n", rawName, table.PatchPath)
+	}
+	if col.Doc {
+		fmt.Fprintf(w, "Description:  ✅ %q\n", truncateText(col.Description, 80))
+	} else if colInfo != nil {
+		fmt.Fprintln(w, "Description:  ❌ empty")
+	} else {
+		fmt.Fprintln(w, "Description:  ❌ none")
+	}
+
+	tests := lookupColumnTests(manifest.Tests[table.UniqueID], key, naming)
+	fmt.Fprintf(w, "Tests:        %d matched\n", len(tests))
+	for _, t := range tests {
+		node, _ := t.(map[string]interface{})
+		id, _ := node["unique_id"].(string)
+		fmt.Fprintf(w, "              - %s (%s)\n", id, strings.Join(testMacroNames([]interface{}{t}), ""))
+	}
+
+	fmt.Fprintln(w, "\nCoverage:")
+	single := Catalog{Tables: map[string]Table{table.UniqueID: table}}
+	for _, covType := range registeredCoverageTypes() {
+		if err := evaluateCoverage(ctx, single, covType); err != nil {
+			return err
+		}
+		evaluated := single.Tables[table.UniqueID]
+		covered, applicable := evaluated.Columns[key].Coverage[covType]
+		scope := ""
+		if tableCovered, ok := evaluated.Coverage[covType]; ok {
+			covered, applicable, scope = tableCovered, true, " (model level)"
+		}
+		status := "➖ not applicable"
+		switch {
+		case applicable && covered:
+			status = "✅ covered"
+		case applicable:
+			status = "❌ uncovered"
+		}
+		fmt.Fprintf(w, "  %-18s %s%s\n", covType, status, scope)
+	}
+	return nil
+}
+
+func describeNaming(n ColumnNaming) string {
+	rules := []string{"case-insensitive"}
+	if n.CaseSensitive {
+		rules[0] = "case-sensitive"
+	}
+	if !n.KeepQuotes {
+		rules = append(rules, "quotes stripped")
+	}
+	if !n.KeepWhitespace {
+		rules = append(rules, "whitespace trimmed")
+	}
+	if n.Unicode != "" {
+		rules = append(rules, "unicode "+n.Unicode)
+	}
+	for _, r := range n.Replacements {
+		rules = append(rules, fmt.Sprintf("replace %s → %q", r.pattern, r.replacement))
+	}
+	return strings.Join(rules, ", ")
+}
+
+func truncateText(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+func registeredCoverageTypes() []CoverageType {
+	coverageProvidersMu.RLock()
+	defer coverageProvidersMu.RUnlock()
+	types := make([]CoverageType, 0, len(coverageProviders))
+	for name := range coverageProviders {
+		types = append(types, name)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type ExternalSources string
+
+const (
+	ExternalInclude  ExternalSources = "include"
+	ExternalExclude  ExternalSources = "exclude"
+	ExternalSeparate ExternalSources = "separate"
+)
+
+var ExternalSourcesValues = []ExternalSources{ExternalInclude, ExternalExclude, ExternalSeparate}
+
+func ParseExternalSources(value string) (ExternalSources, error) {
+	names := make([]string, len(ExternalSourcesValues))
+	for i, v := range ExternalSourcesValues {
+		if string(v) == value {
+			return v, nil
+		}
+		names[i] = string(v)
+	}
+	return "", fmt.Errorf("unknown external_sources value %q, expected one of: %s", value, strings.Join(names, ", "))
+}
+
+// isExternalSource tells whether a manifest node is a source with an external
+// config (dbt-external-tables): its columns often come from the loader rather
+// than from the warehouse.
+func isExternalSource(node map[string]interface{}) bool {
+	if node["resource_type"] != "source" {
+		return false
+	}
+	external, _ := node["external"].(map[string]interface{})
+	for _, v := range external {
+		if v != nil && v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitExternal separates the external sources from the other tables.
+func (c Catalog) SplitExternal() (Catalog, Catalog) {
+	internal, external := c, c
+	internal.Tables = make(map[string]Table)
+	external.Tables = make(map[string]Table)
+	for id, table := range c.Tables {
+		if table.External {
+			external.Tables[id] = table
+		} else {
+			internal.Tables[id] = table
+		}
+	}
+	return internal, external
+}
+
+func printExternalSources(w io.Writer, report DetailedCoverageReport, nameWidth int) {
+	if len(report.TableReports) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n🌐 External sources, reported separately (%d tables)\n\n", report.TableCount)
+	renderCoverageTable(w, report.TableReports, "EXTERNAL", report.TotalCovered, report.TotalColumns, nil, nameWidth)
+}
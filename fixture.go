@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type FixtureOptions struct {
+	Models    int
+	Columns   int
+	DocRatio  float64
+	TestRatio float64
+	Seed      int64
+}
+
+func runGenFixture(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("gen-fixture")
+	var (
+		outputDir = fs.String("output_dir", "target", "Directory receiving manifest.json and catalog.json")
+		models    = fs.Int("models", 100, "Number of models")
+		columns   = fs.Int("columns", 10, "Number of columns per model")
+		docRatio  = fs.Float64("doc_ratio", 0.5, "Share of documented columns (0-1)")
+		testRatio = fs.Float64("test_ratio", 0.5, "Share of tested columns (0-1)")
+		seed      = fs.Int64("seed", 1, "Random seed, the same seed always produces the same artifacts")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	_, cancel := common.setup(ctx)
+	defer cancel()
+	opts := FixtureOptions{Models: *models, Columns: *columns, DocRatio: *docRatio, TestRatio: *testRatio, Seed: *seed}
+	if opts.Models <= 0 || opts.Columns <= 0 {
+		return errors.New("--models and --columns must be positive")
+	}
+	if opts.DocRatio < 0 || opts.DocRatio > 1 || opts.TestRatio < 0 || opts.TestRatio > 1 {
+		return errors.New("--doc_ratio and --test_ratio must be between 0 and 1")
+	}
+
+	manifest, catalog := generateFixture(opts)
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return err
+	}
+	for name, artifact := range map[string]interface{}{"manifest.json": manifest, "catalog.json": catalog} {
+		data, err := json.Marshal(artifact)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(*outputDir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Fixture written into %s (%d models × %d columns)\n", *outputDir, opts.Models, opts.Columns)
+	return nil
+}
+
+func generateFixture(opts FixtureOptions) (map[string]interface{}, map[string]interface{}) {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	generatedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339Nano)
+	manifestNodes := make(map[string]interface{})
+	catalogNodes := make(map[string]interface{})
+
+	for m := 0; m < opts.Models; m++ {
+		name := fmt.Sprintf("model_%05d", m)
+		folder := fmt.Sprintf("folder_%02d", m%20)
+		uniqueID := "model.fixture." + name
+		manifestColumns := make(map[string]interface{})
+		catalogColumns := make(map[string]interface{})
+		for c := 0; c < opts.Columns; c++ {
+			column := fmt.Sprintf("column_%03d", c)
+			description := ""
+			if rng.Float64() < opts.DocRatio {
+				description = "Description of " + column
+			}
+			manifestColumns[column] = map[string]interface{}{"name": column, "description": description, "meta": map[string]interface{}{}, "tags": []interface{}{}}
+			catalogColumns[column] = map[string]interface{}{"name": column, "index": c + 1, "type": "TEXT"}
+			if rng.Float64() < opts.TestRatio {
+				testID := fmt.Sprintf("test.fixture.not_null_%s_%s", name, column)
+				manifestNodes[testID] = map[string]interface{}{
+					"unique_id":     testID,
+					"resource_type": "test",
+					"package_name":  "fixture",
+					"name":          "not_null_" + name + "_" + column,
+					"column_name":   column,
+					"attached_node": uniqueID,
+					"depends_on":    map[string]interface{}{"nodes": []interface{}{uniqueID}},
+					"test_metadata": map[string]interface{}{"name": "not_null", "kwargs": map[string]interface{}{"column_name": column}},
+				}
+			}
+		}
+		manifestNodes[uniqueID] = map[string]interface{}{
+			"unique_id":          uniqueID,
+			"resource_type":      "model",
+			"package_name":       "fixture",
+			"name":               name,
+			"schema":             "fixture",
+			"original_file_path": fmt.Sprintf("models/%s/%s.sql", folder, name),
+			"patch_path":         fmt.Sprintf("fixture://models/%s/schema.yml", folder),
+			"description":        "",
+			"columns":            manifestColumns,
+			"config":             map[string]interface{}{"meta": map[string]interface{}{}},
+			"tags":               []interface{}{},
+		}
+		catalogNodes[uniqueID] = map[string]interface{}{
+			"unique_id": uniqueID,
+			"metadata":  map[string]interface{}{"schema": "fixture", "name": name, "type": "table"},
+			"columns":   catalogColumns,
+		}
+	}
+
+	manifest := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"dbt_schema_version": SupportedManifestSchemaVersions[len(SupportedManifestSchemaVersions)-1],
+			"generated_at":       generatedAt,
+		},
+		"nodes":   manifestNodes,
+		"sources": map[string]interface{}{},
+	}
+	catalog := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"dbt_schema_version": "https://schemas.getdbt.com/dbt/catalog/v1.json",
+			"generated_at":       generatedAt,
+		},
+		"nodes":   catalogNodes,
+		"sources": map[string]interface{}{},
+	}
+	return manifest, catalog
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const CoverageTypeFreshness CoverageType = "freshness"
+
+// FreshnessThreshold is a warn_after or error_after of a source freshness
+// check: {count: 12, period: hour}.
+type FreshnessThreshold struct {
+	Count  int    `json:"count"`
+	Period string `json:"period"`
+}
+
+var freshnessPeriods = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+}
+
+func (t *FreshnessThreshold) Duration() time.Duration {
+	return time.Duration(t.Count) * freshnessPeriods[t.Period]
+}
+
+func (t *FreshnessThreshold) String() string {
+	if t == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d %s", t.Count, t.Period)
+}
+
+// SourceFreshness is the freshness config of a source table, nil when the
+// source has no freshness check (dbt source freshness skips it).
+type SourceFreshness struct {
+	WarnAfter  *FreshnessThreshold `json:"warn_after,omitempty"`
+	ErrorAfter *FreshnessThreshold `json:"error_after,omitempty"`
+}
+
+func parseFreshnessThreshold(raw interface{}) *FreshnessThreshold {
+	m, _ := raw.(map[string]interface{})
+	count, _ := m["count"].(float64)
+	period, _ := m["period"].(string)
+	if count <= 0 || freshnessPeriods[period] == 0 {
+		return nil
+	}
+	return &FreshnessThreshold{Count: int(count), Period: period}
+}
+
+// parseSourceFreshness reads the freshness of a source node, top-level in the
+// manifest or under config since dbt 1.9.
+func parseSourceFreshness(node map[string]interface{}) *SourceFreshness {
+	raw, _ := node["freshness"].(map[string]interface{})
+	if raw == nil {
+		config, _ := node["config"].(map[string]interface{})
+		raw, _ = config["freshness"].(map[string]interface{})
+	}
+	f := SourceFreshness{WarnAfter: parseFreshnessThreshold(raw["warn_after"]), ErrorAfter: parseFreshnessThreshold(raw["error_after"])}
+	if f.WarnAfter == nil && f.ErrorAfter == nil {
+		return nil
+	}
+	return &f
+}
+
+// freshnessProvider rates the sources with a freshness check.
+type freshnessProvider struct{}
+
+func (freshnessProvider) Name() string { return string(CoverageTypeFreshness) }
+
+func (p freshnessProvider) Evaluate(ctx context.Context, table Table, _ Column) (bool, error) {
+	return p.EvaluateTable(ctx, table)
+}
+
+func (freshnessProvider) EvaluateTable(_ context.Context, table Table) (bool, error) {
+	return table.Freshness != nil, nil
+}
+
+func (freshnessProvider) Applies(table Table, _ Column) bool {
+	return table.ResourceType == "source"
+}
+
+func init() {
+	RegisterCoverageProvider(freshnessProvider{})
+}
+
+// Staleness is a duration of the freshness SLA: 36h, 90m or 2d.
+type Staleness time.Duration
+
+func (s *Staleness) UnmarshalText(text []byte) error {
+	value := string(text)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		*s = Staleness(time.Duration(n * float64(24*time.Hour)))
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q, expected e.g. 12h, 90m or 2d", value)
+	}
+	*s = Staleness(d)
+	return nil
+}
+
+func (s Staleness) String() string {
+	d := time.Duration(s)
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(d.String(), "0s"), "0m")
+}
+
+// FreshnessSLA is the staleness the sources may tolerate: their warn_after
+// and error_after must not exceed the maxima. The critical sources, selected
+// like --per_model_select, fail the run (freshness_sla) when they have no
+// freshness check or exceed the SLA; the others are only reported.
+type FreshnessSLA struct {
+	MaxWarnAfter  Staleness `yaml:"max_warn_after"`
+	MaxErrorAfter Staleness `yaml:"max_error_after"`
+	Critical      []string  `yaml:"critical"`
+}
+
+func (c FreshnessSLA) enabled() bool {
+	return c.MaxWarnAfter > 0 || c.MaxErrorAfter > 0 || len(c.Critical) > 0
+}
+
+func (c FreshnessSLA) validate() error {
+	if _, err := ParseSelector(c.Critical); err != nil {
+		return fmt.Errorf("freshness_sla: %w", err)
+	}
+	return nil
+}
+
+const (
+	FreshnessOK         = "ok"
+	FreshnessMissing    = "missing"
+	FreshnessExceedsSLA = "exceeds_sla"
+)
+
+type SourceFreshnessStatus struct {
+	Source     string              `json:"source"`
+	UniqueID   string              `json:"unique_id"`
+	WarnAfter  *FreshnessThreshold `json:"warn_after,omitempty"`
+	ErrorAfter *FreshnessThreshold `json:"error_after,omitempty"`
+	Critical   bool                `json:"critical,omitempty"`
+	Status     string              `json:"status"`
+	Reason     string              `json:"reason,omitempty"`
+}
+
+type FreshnessReport struct {
+	Configured    int                     `json:"configured"`
+	Total         int                     `json:"total"`
+	MaxWarnAfter  string                  `json:"max_warn_after,omitempty"`
+	MaxErrorAfter string                  `json:"max_error_after,omitempty"`
+	Sources       []SourceFreshnessStatus `json:"sources"`
+}
+
+// check compares the freshness of a source to the SLA. A missing error_after
+// never errors, so it exceeds any max_error_after.
+func (c FreshnessSLA) check(f *SourceFreshness) (status, reason string) {
+	if f == nil {
+		return FreshnessMissing, "no freshness check"
+	}
+	var reasons []string
+	for _, limit := range []struct {
+		name      string
+		threshold *FreshnessThreshold
+		max       Staleness
+	}{{"warn_after", f.WarnAfter, c.MaxWarnAfter}, {"error_after", f.ErrorAfter, c.MaxErrorAfter}} {
+		switch {
+		case limit.max == 0:
+		case limit.threshold == nil:
+			reasons = append(reasons, fmt.Sprintf("no %s (max %s)", limit.name, limit.max))
+		case limit.threshold.Duration() > time.Duration(limit.max):
+			reasons = append(reasons, fmt.Sprintf("%s %s exceeds %s", limit.name, limit.threshold, limit.max))
+		}
+	}
+	if len(reasons) > 0 {
+		return FreshnessExceedsSLA, strings.Join(reasons, ", ")
+	}
+	return FreshnessOK, ""
+}
+
+// computeFreshness reports the freshness of the sources of the catalogs
+// against the SLA, the sources off the SLA first.
+func computeFreshness(sla FreshnessSLA, catalogs ...Catalog) *FreshnessReport {
+	report := &FreshnessReport{Sources: []SourceFreshnessStatus{}}
+	if sla.MaxWarnAfter > 0 {
+		report.MaxWarnAfter = sla.MaxWarnAfter.String()
+	}
+	if sla.MaxErrorAfter > 0 {
+		report.MaxErrorAfter = sla.MaxErrorAfter.String()
+	}
+	for _, catalog := range catalogs {
+		for _, table := range catalog.Tables {
+			if table.ResourceType != "source" {
+				continue
+			}
+			report.Total++
+			if table.Freshness != nil {
+				report.Configured++
+			}
+			s := SourceFreshnessStatus{Source: table.Name, UniqueID: table.UniqueID}
+			if table.Freshness != nil {
+				s.WarnAfter, s.ErrorAfter = table.Freshness.WarnAfter, table.Freshness.ErrorAfter
+			}
+			s.Status, s.Reason = sla.check(table.Freshness)
+			s.Critical = len(sla.Critical) > 0 && Selector(sla.Critical).Matches(TableReport{
+				Name: table.Name, UniqueID: table.UniqueID, ResourceType: table.ResourceType, PackageName: table.PackageName,
+				OriginalFilePath: table.OriginalFilePath, Owners: table.Owners, Tags: table.Tags,
+			})
+			report.Sources = append(report.Sources, s)
+		}
+	}
+	sort.Slice(report.Sources, func(i, j int) bool {
+		a, b := report.Sources[i], report.Sources[j]
+		if (a.Status == FreshnessOK) != (b.Status == FreshnessOK) {
+			return b.Status == FreshnessOK
+		}
+		if a.Critical != b.Critical {
+			return a.Critical
+		}
+		return a.Source < b.Source
+	})
+	return report
+}
+
+// criticalFreshnessFailures lists the critical sources off the SLA.
+func criticalFreshnessFailures(report *FreshnessReport) []string {
+	if report == nil {
+		return nil
+	}
+	var failures []string
+	for _, s := range report.Sources {
+		if s.Critical && s.Status != FreshnessOK {
+			failures = append(failures, fmt.Sprintf("%s (%s)", s.Source, s.Reason))
+		}
+	}
+	return failures
+}
+
+func printFreshness(w io.Writer, report *FreshnessReport) {
+	if report == nil {
+		return
+	}
+	sla := "no SLA"
+	if report.MaxWarnAfter != "" || report.MaxErrorAfter != "" {
+		sla = fmt.Sprintf("SLA warn_after ≤ %s, error_after ≤ %s", orNone(report.MaxWarnAfter), orNone(report.MaxErrorAfter))
+	}
+	fmt.Fprintf(w, "\n🕒 Source freshness: %d/%d sources checked (%s)\n\n", report.Configured, report.Total, sla)
+	for _, s := range report.Sources {
+		marker := "✅"
+		switch {
+		case s.Status != FreshnessOK && s.Critical:
+			marker = "⛔"
+		case s.Status != FreshnessOK:
+			marker = "⚠️"
+		}
+		line := fmt.Sprintf("  %s %-35s warn %-10s error %-10s", marker, s.Source, s.WarnAfter, s.ErrorAfter)
+		if s.Reason != "" {
+			line += " " + s.Reason
+		}
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
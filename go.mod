@@ -2,7 +2,13 @@ module dbt-goverage
 
 go 1.24.0
 
-require github.com/olekukonko/tablewriter v0.0.5 // direct
+require (
+	github.com/olekukonko/tablewriter v0.0.5 // direct
+	golang.org/x/sys v0.31.0 // direct
+	golang.org/x/term v0.30.0 // direct
+	golang.org/x/text v0.23.0 // direct
+	gopkg.in/yaml.v3 v3.0.1 // direct
+)
 
 require (
 	github.com/mattn/go-runewidth v0.0.16 // indirect
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+type GroupBy string
+
+const (
+	GroupByNone     GroupBy = ""
+	GroupByPackage  GroupBy = "package"
+	GroupByFolder   GroupBy = "folder"
+	GroupByOwner    GroupBy = "owner"
+	GroupByDatabase GroupBy = "database"
+)
+
+var GroupByValues = []GroupBy{GroupByPackage, GroupByFolder, GroupByOwner, GroupByDatabase}
+
+func ParseGroupBy(value string) (GroupBy, error) {
+	if value == "" {
+		return GroupByNone, nil
+	}
+	names := make([]string, len(GroupByValues))
+	for i, g := range GroupByValues {
+		if string(g) == value {
+			return g, nil
+		}
+		names[i] = string(g)
+	}
+	return GroupByNone, fmt.Errorf("unknown group_by value %q, expected one of: %s", value, strings.Join(names, ", "))
+}
+
+func (g GroupBy) Key(table Table) string {
+	switch g {
+	case GroupByPackage:
+		return table.PackageName
+	case GroupByFolder:
+		return tableFolder(table)
+	case GroupByOwner:
+		return tableOwner(table)
+	case GroupByDatabase:
+		return warehouseLocation(table.Database, table.Schema)
+	}
+	return ""
+}
+
+// warehouseLocation is where a table lives in the warehouse, database.schema,
+// the way DBAs and security reviewers look at the estate rather than by dbt
+// folder.
+func warehouseLocation(database, schema string) string {
+	if database == "" {
+		return schema
+	}
+	return database + "." + schema
+}
+
+func tableFolder(table Table) string {
+	return path.Dir(slashPath(table.OriginalFilePath))
+}
+
+type GroupReport struct {
+	Name     string  `json:"name"`
+	Tables   int     `json:"tables"`
+	Covered  int     `json:"covered"`
+	Total    int     `json:"total"`
+	Coverage float64 `json:"coverage"`
+}
+
+func computeGroupReports(tables []TableReport) []GroupReport {
+	byName := make(map[string]*GroupReport)
+	for _, t := range tables {
+		g, ok := byName[t.Group]
+		if !ok {
+			g = &GroupReport{Name: t.Group}
+			byName[t.Group] = g
+		}
+		g.Tables++
+		g.Covered += t.Covered
+		g.Total += t.Total
+	}
+	groups := make([]GroupReport, 0, len(byName))
+	for _, g := range byName {
+		g.Coverage = ratio(g.Covered, g.Total)
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
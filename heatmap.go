@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const heatmapCellsPerLine = 50
+
+const (
+	ansiReset  = "\033[0m"
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiGreen  = "\033[32m"
+	ansiGrey   = "\033[90m"
+)
+
+func heatmapColor(covered, total int) string {
+	if total == 0 {
+		return ansiGrey
+	}
+	switch coverage := ratio(covered, total); {
+	case coverage < 0.5:
+		return ansiRed
+	case coverage < 0.8:
+		return ansiYellow
+	}
+	return ansiGreen
+}
+
+// heatmapCell draws the cell of a model: a colored square, or a shade telling
+// the coverage band apart when colors are disabled.
+func heatmapCell(covered, total int, color bool) string {
+	if color {
+		return heatmapColor(covered, total) + "■" + ansiReset
+	}
+	if total == 0 {
+		return "·"
+	}
+	switch coverage := ratio(covered, total); {
+	case coverage < 0.5:
+		return "░"
+	case coverage < 0.8:
+		return "▒"
+	}
+	return "█"
+}
+
+func (heatmapRenderer) Render(w io.Writer, report DetailedCoverageReport) error {
+	fmt.Fprintf(w, "📊 Coverage Heatmap (%s): %d tables, %s covered\n", strings.ToUpper(string(report.CovType)),
+		report.TableCount, formatCoverage(report.TotalCovered, report.TotalColumns))
+	color := colorEnabled(w)
+	fmt.Fprintf(w, "%s < 50%%  %s < 80%%  %s ≥ 80%%  %s no column\n\n",
+		heatmapCell(0, 1, color), heatmapCell(1, 2, color), heatmapCell(1, 1, color), heatmapCell(0, 0, color))
+
+	folders := make(map[string][]TableCoverage)
+	var names []string
+	width := 0
+	for _, tr := range report.TableReports {
+		if _, ok := folders[tr.Folder]; !ok {
+			names = append(names, tr.Folder)
+			width = max(width, len(tr.Folder))
+		}
+		folders[tr.Folder] = append(folders[tr.Folder], tr)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rows := folders[name]
+		covered, total := 0, 0
+		var cells strings.Builder
+		for i, tr := range rows {
+			if i > 0 && i%heatmapCellsPerLine == 0 {
+				fmt.Fprintf(&cells, "\n%*s", width+10, "")
+			}
+			cells.WriteString(heatmapCell(tr.Covered, tr.Total, color))
+			covered += tr.Covered
+			total += tr.Total
+		}
+		fmt.Fprintf(w, "%-*s %7s  %s\n", width, name, formatCoverage(covered, total), cells.String())
+	}
+	return nil
+}
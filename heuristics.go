@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+const (
+	CoverageTypeAcceptedValues CoverageType = "accepted_values"
+	CoverageTypeRelationships  CoverageType = "relationships"
+	CoverageTypeMonitoring     CoverageType = "monitoring"
+)
+
+type HeuristicsConfig struct {
+	EnumColumns         []string `yaml:"enum_columns"`
+	AcceptedValuesTests []string `yaml:"accepted_values_tests"`
+	ForeignKeyColumns   []string `yaml:"foreign_key_columns"`
+	RelationshipsTests  []string `yaml:"relationships_tests"`
+	MonitoringTests     []string `yaml:"monitoring_tests"`
+}
+
+var defaultHeuristics = HeuristicsConfig{
+	EnumColumns:         []string{"*_status", "*_type", "*_category", "*_kind", "*_code", "status", "type", "category", "kind"},
+	AcceptedValuesTests: []string{"accepted_values", "dbt_expectations.expect_column_values_to_be_in_set"},
+	ForeignKeyColumns:   []string{"*_id"},
+	RelationshipsTests:  []string{"relationships", "dbt_utils.relationships_where"},
+	MonitoringTests: []string{
+		"elementary.*_anomalies", "elementary.schema_changes*", "elementary.json_schema",
+		"dbt_expectations.expect_table_row_count_*", "dbt_expectations.expect_*_to_have_recent_data",
+		"dbt_utils.recency",
+	},
+}
+
+func (h HeuristicsConfig) withDefaults() HeuristicsConfig {
+	if len(h.EnumColumns) == 0 {
+		h.EnumColumns = defaultHeuristics.EnumColumns
+	}
+	if len(h.AcceptedValuesTests) == 0 {
+		h.AcceptedValuesTests = defaultHeuristics.AcceptedValuesTests
+	}
+	if len(h.ForeignKeyColumns) == 0 {
+		h.ForeignKeyColumns = defaultHeuristics.ForeignKeyColumns
+	}
+	if len(h.RelationshipsTests) == 0 {
+		h.RelationshipsTests = defaultHeuristics.RelationshipsTests
+	}
+	if len(h.MonitoringTests) == 0 {
+		h.MonitoringTests = defaultHeuristics.MonitoringTests
+	}
+	return h
+}
+
+type ScopedCoverageProvider interface {
+	CoverageProvider
+	Applies(table Table, column Column) bool
+}
+
+type heuristicTestProvider struct {
+	name     CoverageType
+	patterns []string
+	tests    []string
+}
+
+func (p heuristicTestProvider) Name() string { return string(p.name) }
+
+func (p heuristicTestProvider) Applies(_ Table, column Column) bool {
+	return matchesAny(column.Name, p.patterns)
+}
+
+func (p heuristicTestProvider) Evaluate(_ context.Context, _ Table, column Column) (bool, error) {
+	for _, name := range column.TestNames {
+		for _, expected := range p.tests {
+			if strings.EqualFold(name, expected) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func registerHeuristicProviders(h HeuristicsConfig) {
+	for _, p := range heuristicProviders(h) {
+		RegisterCoverageProvider(p)
+	}
+}
+
+// replaceHeuristicProviders swaps the heuristic providers for those of a
+// reloaded configuration.
+func replaceHeuristicProviders(h HeuristicsConfig) {
+	for _, p := range heuristicProviders(h) {
+		replaceCoverageProvider(p)
+	}
+}
+
+func heuristicProviders(h HeuristicsConfig) []CoverageProvider {
+	h = h.withDefaults()
+	return []CoverageProvider{
+		heuristicTestProvider{
+			name:     CoverageTypeAcceptedValues,
+			patterns: h.EnumColumns,
+			tests:    h.AcceptedValuesTests,
+		},
+		heuristicTestProvider{
+			name:     CoverageTypeRelationships,
+			patterns: h.ForeignKeyColumns,
+			tests:    h.RelationshipsTests,
+		},
+		tableCoverageFunc{CoverageTypeMonitoring, func(t Table) bool {
+			return hasMonitoringTest(t, h.MonitoringTests)
+		}},
+	}
+}
+
+// hasMonitoringTest tells whether a table has an anomaly detection test
+// (volume, freshness, schema changes): they watch the table as a whole over
+// time rather than its rows, hence their own coverage type. test_packages
+// does not apply to them, so elementary can be excluded from the test
+// coverage and still counted here.
+func hasMonitoringTest(t Table, patterns []string) bool {
+	for macro := range t.DeclaredTests {
+		if matchesAny(macro, patterns) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
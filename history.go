@@ -0,0 +1,80 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type HistoryEntry struct {
+	Path        string
+	GeneratedAt time.Time
+	Report      JSONReport
+}
+
+func loadHistory(dir string, covType string) ([]HistoryEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []HistoryEntry
+	for _, path := range paths {
+		report, err := readJSONReport(path)
+		if err != nil {
+			warnf("skipping history file %s: %v", path, err)
+			continue
+		}
+		if covType != "" && report.CovType != covType {
+			continue
+		}
+		generatedAt, err := time.Parse(time.RFC3339, report.GeneratedAt)
+		if err != nil {
+			info, statErr := os.Stat(path)
+			if statErr != nil {
+				return nil, statErr
+			}
+			generatedAt = info.ModTime()
+		}
+		entries = append(entries, HistoryEntry{Path: path, GeneratedAt: generatedAt, Report: report})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].GeneratedAt.Before(entries[j].GeneratedAt) })
+	log.Printf("%d %s reports found in the history %s", len(entries), covType, dir)
+	return entries, nil
+}
+
+// historyCovTypeRegexp restricts the coverage types put in the name of a
+// history file, which may come from an uploaded report.
+var historyCovTypeRegexp = regexp.MustCompile(`^[a-z0-9_:]+$`)
+
+// saveToHistory stores the report as <generated_at>-<type>-<random>.json: the
+// random suffix keeps the reports of the same type generated in the same
+// second, by two environments of a CI job for instance.
+func saveToHistory(dir string, report JSONReport) (string, error) {
+	if !historyCovTypeRegexp.MatchString(report.CovType) {
+		return "", fmt.Errorf("invalid coverage type %q, cannot be stored in the history", report.CovType)
+	}
+	generatedAt, err := time.Parse(time.RFC3339, report.GeneratedAt)
+	if err != nil {
+		return "", errors.New("report without generated_at cannot be stored in the history")
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s-%s-%x.json", generatedAt.UTC().Format("20060102T150405Z"), strings.ReplaceAll(report.CovType, ":", "_"), suffix)
+	path := filepath.Join(dir, name)
+	if rel, err := filepath.Rel(dir, path); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("history file %s outside of %s", name, dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return path, writeCoverageReport(report, path)
+}
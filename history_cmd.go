@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+func runHistory(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "diff" {
+		return errors.New("missing history command, expected: diff")
+	}
+	return runHistoryDiff(ctx, args[1:])
+}
+
+func runHistoryDiff(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("history diff")
+	var (
+		historyDir = fs.String("history_dir", "", "Directory of past JSON reports")
+		covType    = fs.String("type", "test", "Coverage type of the compared reports")
+		from       = fs.String("from", "", "Start of the period: date (2006-01-02, today, yesterday) or git sha")
+		to         = fs.String("to", "today", "End of the period: date (2006-01-02, today, yesterday) or git sha")
+		output     = fs.String("output", "", "Also write the diff to this file (JSON)")
+		all        = fs.Bool("all", false, "Also list unchanged models")
+		requireNew = fs.Bool("require_coverage_for_new_columns", false, "Fail when columns added between the reports are not covered, whatever the percentages")
+		projectDir = fs.String("dbt_dir", ".", "dbt project path, for the configuration and the artifacts of --base_target_dir")
+		baseTarget = fs.String("base_target_dir", "", "Compare the artifacts of this dbt target path (e.g. of production) instead of the reports of --history_dir")
+		headTarget = fs.String("head_target_dir", "target", "dbt target path compared with --base_target_dir")
+		configPath = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		labels     = make(labelsFlag)
+	)
+	fs.Var(labels, "label", "Only compare the reports of --history_dir carrying this label, key=value, repeatable (e.g. --label env=prod)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+
+	var base, head JSONReport
+	if *baseTarget != "" {
+		cfg, err := loadConfig(*configPath, *projectDir)
+		if err != nil {
+			return err
+		}
+		settings, err := cfg.apply()
+		if err != nil {
+			return err
+		}
+		defer closeCoverageProviders()
+		registerHeuristicProviders(cfg.Heuristics)
+		if err := registerPlugins(cfg.Plugins); err != nil {
+			return err
+		}
+		if _, err := lookupCoverageProvider(CoverageType(*covType)); err != nil {
+			return err
+		}
+		reports, err := artifactsReports(ctx, *projectDir, CoverageType(*covType), cfg.Exemptions, settings, *baseTarget, *headTarget)
+		if err != nil {
+			return err
+		}
+		base, head = reports[0], reports[1]
+		log.Printf("Comparing the artifacts of %s with %s", *baseTarget, *headTarget)
+	} else {
+		if *historyDir == "" || *from == "" {
+			return errors.New("--history_dir and --from are required, unless comparing artifacts with --base_target_dir")
+		}
+		entries, err := loadHistory(*historyDir, *covType)
+		if err != nil {
+			return err
+		}
+		entries = filterHistoryByLabels(entries, labels)
+		if len(entries) == 0 && len(labels) > 0 {
+			return fmt.Errorf("no %s report labelled %s found in %s", *covType, labels, *historyDir)
+		}
+		if len(entries) == 0 {
+			return fmt.Errorf("no %s report found in %s", *covType, *historyDir)
+		}
+		baseEntry, err := selectHistoryEntry(entries, *from)
+		if err != nil {
+			return fmt.Errorf("--from: %w", err)
+		}
+		headEntry, err := selectHistoryEntry(entries, *to)
+		if err != nil {
+			return fmt.Errorf("--to: %w", err)
+		}
+		base, head = baseEntry.Report, headEntry.Report
+		log.Printf("Comparing %s with %s", baseEntry.Path, headEntry.Path)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	diff := diffReports(base, head)
+	printReportDiff(os.Stdout, diff, *all)
+	if *output != "" {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*output, data, 0644); err != nil {
+			return err
+		}
+	}
+	if *requireNew {
+		return checkNewColumns(diff)
+	}
+	return nil
+}
+
+// artifactsReports computes the report of each dbt target path, so two sets
+// of artifacts are compared without generating their coverage.json first. A
+// report is dated by its manifest.
+func artifactsReports(ctx context.Context, projectDir string, covType CoverageType, exemptions []Exemption, settings ParseSettings, targetDirs ...string) ([]JSONReport, error) {
+	reports := make([]JSONReport, 0, len(targetDirs))
+	for _, dir := range targetDirs {
+		catalog, err := loadFiles(ctx, projectDir, dir, catalogRequired(covType), settings)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		catalog, _ = catalog.ApplyExemptions(exemptions, time.Now())
+		if err := evaluateCoverage(ctx, catalog, covType); err != nil {
+			return nil, err
+		}
+		report := computeJSONReport(catalog, covType, GroupByNone)
+		if !catalog.ManifestGeneratedAt.IsZero() {
+			report.GeneratedAt = catalog.ManifestGeneratedAt.UTC().Format(time.RFC3339)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// gitSHARegexp matches the (abbreviated) git shas accepted by --from and --to.
+var gitSHARegexp = regexp.MustCompile(`^[0-9a-fA-F]{4,40}$`)
+
+func selectHistoryEntry(entries []HistoryEntry, ref string) (HistoryEntry, error) {
+	day, err := parseHistoryDate(ref)
+	if err != nil {
+		if !gitSHARegexp.MatchString(ref) {
+			return HistoryEntry{}, fmt.Errorf("%q is neither a date (2006-01-02, today, yesterday) nor a git sha: %w", ref, err)
+		}
+		ref = strings.ToLower(ref)
+		for i := len(entries) - 1; i >= 0; i-- {
+			if sha := strings.ToLower(entries[i].Report.GitSHA); sha != "" && strings.HasPrefix(sha, ref) {
+				return entries[i], nil
+			}
+		}
+		return HistoryEntry{}, fmt.Errorf("no report found for the git sha %q", ref)
+	}
+	end := day.AddDate(0, 0, 1)
+	var selected *HistoryEntry
+	for i := range entries {
+		if entries[i].GeneratedAt.Before(end) {
+			selected = &entries[i]
+		}
+	}
+	if selected == nil {
+		return HistoryEntry{}, fmt.Errorf("no report on or before %s, the oldest one is from %s",
+			day.Format("2006-01-02"), entries[0].GeneratedAt.Format("2006-01-02"))
+	}
+	return *selected, nil
+}
+
+// parseHistoryDate reads a day: today, yesterday, 2006-01-02 or an RFC 3339
+// timestamp, returning the error of the 2006-01-02 layout otherwise.
+func parseHistoryDate(ref string) (time.Time, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	switch strings.ToLower(ref) {
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	if t, err := time.Parse(time.RFC3339, ref); err == nil {
+		return t.UTC().Truncate(24 * time.Hour), nil
+	}
+	return time.Parse("2006-01-02", ref)
+}
+
+func printReportDiff(w io.Writer, diff ReportDiff, all bool) {
+	label := func(s ReportSummary) string {
+		parts := []string{}
+		if s.GeneratedAt != "" {
+			parts = append(parts, s.GeneratedAt)
+		}
+		if s.GitSHA != "" {
+			parts = append(parts, shortSHA(s.GitSHA))
+		}
+		return strings.Join(parts, " @ ")
+	}
+	fmt.Fprintf(w, "📈 Coverage diff (%s): %s → %s\n\n", strings.ToUpper(diff.CovType), label(diff.Base), label(diff.Head))
+	fmt.Fprintf(w, "Global: %s → %s (%+.1f pts)\n", formatCoverage(diff.Base.Covered, diff.Base.Total),
+		formatCoverage(diff.Head.Covered, diff.Head.Total), diff.Delta*100)
+	fmt.Fprintf(w, "Models: %d improved, %d regressed, %d added, %d removed, %d renamed, %d unchanged\n\n",
+		diff.Counts[DiffImproved], diff.Counts[DiffRegressed], diff.Counts[DiffAdded], diff.Counts[DiffRemoved], diff.Counts[DiffRenamed], diff.Counts[DiffUnchanged])
+	if n := diff.Counts[DiffNotComparable]; n > 0 {
+		fmt.Fprintf(w, "%d models not comparable across the manifest versions\n\n", n)
+	}
+	if c := diff.Compatibility; c != nil {
+		fmt.Fprintf(w, "⚠️  Manifest %s → %s: %d → %d models, %d → %d columns, %s → %s\n   Note: %s\n\n",
+			c.Base.ManifestVersion, c.Head.ManifestVersion, c.Base.Nodes, c.Head.Nodes, c.Base.Columns, c.Head.Columns,
+			formatCoverage(c.Base.Covered, c.Base.Total), formatCoverage(c.Head.Covered, c.Head.Total), c.Note)
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Model", "Status", "Before", "After", "Delta"})
+	table.SetAutoWrapText(false)
+	table.SetBorder(false)
+	table.SetCenterSeparator("│")
+	table.SetColumnAlignment([]int{
+		tablewriter.ALIGN_LEFT, tablewriter.ALIGN_LEFT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT, tablewriter.ALIGN_RIGHT,
+	})
+	rows := 0
+	for _, td := range diff.Tables {
+		if td.Status == DiffUnchanged && td.RenamedFrom == "" && !all {
+			continue
+		}
+		before, after := "-", "-"
+		if td.Status != DiffAdded {
+			before = fmt.Sprintf("%s (%d/%d)", formatCoverage(td.BaseCovered, td.BaseTotal), td.BaseCovered, td.BaseTotal)
+		}
+		if td.Status != DiffRemoved {
+			after = fmt.Sprintf("%s (%d/%d)", formatCoverage(td.HeadCovered, td.HeadTotal), td.HeadCovered, td.HeadTotal)
+		}
+		name := td.Name
+		if td.RenamedFrom != "" {
+			name += " (← " + td.RenamedFrom + ")"
+		}
+		table.Append([]string{name, td.Status, before, after, fmt.Sprintf("%+.1f", td.Delta*100)})
+		rows++
+	}
+	if rows > 0 {
+		table.Render()
+	}
+	printColumnDiffs(w, diff.Columns)
+}
+
+// columnDiffIcons marks the column changes a reviewer should look at first.
+var columnDiffIcons = map[string]string{
+	ColumnUncovered:      "❌",
+	ColumnAddedUncovered: "⚠️",
+	ColumnRemoved:        "➖",
+	ColumnCovered:        "✅",
+	ColumnAdded:          "🆕",
+	ColumnChanged:        "•",
+}
+
+// printColumnDiffs lists the columns that changed state, the regressions and
+// the new uncovered columns first.
+func printColumnDiffs(w io.Writer, columns []ColumnDiff) {
+	if len(columns) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nColumns: %d changed\n", len(columns))
+	for _, status := range []string{ColumnUncovered, ColumnAddedUncovered, ColumnRemoved, ColumnCovered, ColumnAdded, ColumnChanged} {
+		for _, c := range columns {
+			if c.Status != status {
+				continue
+			}
+			line := fmt.Sprintf("  %s %s.%s: %s", columnDiffIcons[status], c.Model, c.Column, status)
+			if len(c.Changes) > 0 {
+				line += " (" + strings.Join(c.Changes, ", ") + ")"
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// IncrementalCatalog keeps the tables built from the previous artifacts and
+// only rebuilds the ones whose manifest node or tests changed, as long as
+// catalog.json itself is unchanged.
+type IncrementalCatalog struct {
+	Settings     ParseSettings
+	mu           sync.Mutex
+	catalogSum   [sha256.Size]byte
+	catalogNodes map[string]interface{}
+	generatedAt  time.Time
+	fingerprints map[string][sha256.Size]byte
+	tables       map[string]Table
+}
+
+func (ic *IncrementalCatalog) Update(ctx context.Context, manifestData, catalogData []byte) (Catalog, int, error) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	manifest, err := ParseManifest(manifestData, ic.Settings)
+	if err != nil {
+		return Catalog{}, 0, err
+	}
+	if sum := sha256.Sum256(catalogData); ic.catalogNodes == nil || sum != ic.catalogSum {
+		nodes, generatedAt, err := parseCatalogNodes(catalogData)
+		if err != nil {
+			return Catalog{}, 0, err
+		}
+		ic.catalogSum = sum
+		ic.catalogNodes = nodes
+		ic.generatedAt = generatedAt
+		ic.fingerprints = nil
+		ic.tables = nil
+	}
+
+	tables := make(map[string]Table, len(ic.catalogNodes))
+	fingerprints := make(map[string][sha256.Size]byte, len(ic.catalogNodes))
+	recomputed := 0
+	for id, raw := range ic.catalogNodes {
+		if err := ctx.Err(); err != nil {
+			return Catalog{}, 0, err
+		}
+		if manifest.excluded(id) {
+			continue
+		}
+		fingerprint := tableFingerprint(manifest, id)
+		if table, ok := ic.tables[id]; ok && ic.fingerprints[id] == fingerprint {
+			tables[id] = table
+			fingerprints[id] = fingerprint
+			continue
+		}
+		node, ok := raw.(map[string]interface{})
+		if !ok {
+			return Catalog{}, 0, errors.New("invalid catalog node " + id)
+		}
+		table, err := NewTableFromNode(node, manifest)
+		if err != nil {
+			return Catalog{}, 0, err
+		}
+		tables[id] = enrichTable(table, manifest)
+		fingerprints[id] = fingerprint
+		recomputed++
+	}
+	ic.tables = tables
+	ic.fingerprints = fingerprints
+
+	catalog := Catalog{
+		Tables:              make(map[string]Table, len(tables)),
+		Disabled:            disabledNodes(manifest),
+		Unattributed:        manifest.Unattributed,
+		GeneratedAt:         ic.generatedAt,
+		ManifestGeneratedAt: manifest.GeneratedAt,
+		ManifestVersion:     manifest.SchemaVersion,
+		Warnings:            manifest.Warnings,
+		Settings:            manifest.Settings,
+	}
+	for id, table := range tables {
+		catalog.Tables[id] = cloneTable(table)
+	}
+	return catalog, recomputed, nil
+}
+
+func tableFingerprint(manifest *Manifest, tableID string) [sha256.Size]byte {
+	node, _ := manifest.GetTable(tableID)
+	data, _ := json.Marshal([]interface{}{node, manifest.Tests[tableID]})
+	return sha256.Sum256(data)
+}
+
+func cloneTable(table Table) Table {
+	columns := make(map[string]Column, len(table.Columns))
+	for name, col := range table.Columns {
+		col.Coverage = nil
+		columns[name] = col
+	}
+	table.Columns = columns
+	table.QualityScore = nil
+	return table
+}
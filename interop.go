@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strings"
+)
+
+const (
+	ReportFormatDBTScore  = "dbt-score"
+	ReportFormatEvaluator = "dbt-project-evaluator"
+
+	// dbtScorePassScore is the default --fail_any_item_under and
+	// --fail_project_under of dbt-score.
+	dbtScorePassScore = 5.0
+)
+
+type dbtScoreRuleResult struct {
+	Result   string  `json:"result"`
+	Severity string  `json:"severity"`
+	Message  *string `json:"message"`
+}
+
+type dbtScoreModel struct {
+	Score   float64                       `json:"score"`
+	Badge   string                        `json:"badge"`
+	Pass    bool                          `json:"pass"`
+	Results map[string]dbtScoreRuleResult `json:"results"`
+}
+
+type dbtScoreOutput struct {
+	Models  map[string]dbtScoreModel `json:"models"`
+	Project struct {
+		Score float64 `json:"score"`
+		Badge string  `json:"badge"`
+		Pass  bool    `json:"pass"`
+	} `json:"project"`
+}
+
+// dbtScoreBadge mirrors the default badges of dbt-score.
+func dbtScoreBadge(score float64) string {
+	switch {
+	case score >= 10:
+		return "🥇"
+	case score >= 8:
+		return "🥈"
+	case score >= 6:
+		return "🥉"
+	}
+	return "🚧"
+}
+
+// writeDBTScoreReport writes the report like the JSON formatter of dbt-score:
+// the coverage, out of 10, is the score of a single rule per model.
+func writeDBTScoreReport(w io.Writer, report JSONReport) error {
+	rule := "dbt_goverage." + strings.ReplaceAll(report.CovType, ":", "_") + "_coverage"
+	out := dbtScoreOutput{Models: make(map[string]dbtScoreModel)}
+	for _, t := range report.Tables {
+		score := math.Round(t.Coverage*100) / 10
+		result := dbtScoreRuleResult{Result: "OK", Severity: "medium"}
+		if t.Covered < t.Total {
+			var missing []string
+			for _, c := range t.Columns {
+				if c.Covered < c.Total {
+					missing = append(missing, c.Name)
+				}
+			}
+			message := fmt.Sprintf("%d/%d %s covered", t.Covered, t.Total, report.CovType)
+			if len(missing) > 0 {
+				message += ", missing: " + strings.Join(missing, ", ")
+			}
+			result = dbtScoreRuleResult{Result: "WARN", Severity: "medium", Message: &message}
+		}
+		out.Models[t.Name] = dbtScoreModel{
+			Score:   score,
+			Badge:   dbtScoreBadge(score),
+			Pass:    score >= dbtScorePassScore,
+			Results: map[string]dbtScoreRuleResult{rule: result},
+		}
+	}
+	out.Project.Score = math.Round(report.Coverage*100) / 10
+	out.Project.Badge = dbtScoreBadge(out.Project.Score)
+	out.Project.Pass = out.Project.Score >= dbtScorePassScore
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+var evaluatorModelTypes = []string{"staging", "intermediate", "marts", "base", "other"}
+
+// evaluatorModelType classifies a model like the default model_types of
+// dbt-project-evaluator, by folder then by prefix.
+func evaluatorModelType(t TableReport) string {
+	name := t.Name
+	if t.NodeName != "" {
+		name = t.NodeName
+	}
+	name = name[strings.LastIndex(name, ".")+1:]
+	folders := strings.Split(path.Dir(strings.ReplaceAll(t.OriginalFilePath, "\\", "/")), "/")
+	for _, modelType := range evaluatorModelTypes[:4] {
+		if containsString(folders, modelType) {
+			return modelType
+		}
+	}
+	for prefix, modelType := range map[string]string{"stg_": "staging", "int_": "intermediate", "fct_": "marts", "dim_": "marts", "base_": "base"} {
+		if strings.HasPrefix(name, prefix) {
+			return modelType
+		}
+	}
+	return "other"
+}
+
+// writeEvaluatorReport writes one row shaped like the fct_documentation_coverage
+// (doc) or fct_test_coverage (test) models of dbt-project-evaluator. A model
+// counts as documented or tested once all its columns are covered.
+func writeEvaluatorReport(w io.Writer, report JSONReport) error {
+	var noun string
+	switch CoverageType(report.CovType) {
+	case CoverageTypeDoc:
+		noun = "documentation"
+	case CoverageTypeTest:
+		noun = "test"
+	default:
+		return fmt.Errorf("the %s format only supports the doc and test coverage types", ReportFormatEvaluator)
+	}
+	total, covered, tests := 0, 0, 0
+	perType := make(map[string][2]int)
+	for _, t := range report.Tables {
+		if t.ResourceType != "model" {
+			continue
+		}
+		modelType := evaluatorModelType(t)
+		counts := perType[modelType]
+		counts[1]++
+		total++
+		if t.Total > 0 && t.Covered == t.Total {
+			counts[0]++
+			covered++
+		}
+		perType[modelType] = counts
+		for _, n := range t.TestTypes {
+			tests += n
+		}
+	}
+	pct := func(covered, total int) string {
+		return fmt.Sprintf("%.2f", ratio(covered, total)*100)
+	}
+	header := []string{"measured_at", "total_models"}
+	row := []string{report.GeneratedAt, fmt.Sprint(total)}
+	if noun == "test" {
+		header = append(header, "total_tests", "tested_models")
+		row = append(row, fmt.Sprint(tests), fmt.Sprint(covered))
+	} else {
+		header = append(header, "documented_models")
+		row = append(row, fmt.Sprint(covered))
+	}
+	header = append(header, noun+"_coverage_pct")
+	row = append(row, pct(covered, total))
+	for _, modelType := range evaluatorModelTypes {
+		header = append(header, modelType+"_"+noun+"_coverage_pct")
+		row = append(row, pct(perType[modelType][0], perType[modelType][1]))
+	}
+	if noun == "test" {
+		header = append(header, "test_to_model_ratio")
+		row = append(row, fmt.Sprintf("%.4f", ratio(tests, total)))
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.Write(row)
+	cw.Flush()
+	return cw.Error()
+}
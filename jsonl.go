@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"sort"
+)
+
+const (
+	ReportFormatJSON  = "json"
+	ReportFormatJSONL = "jsonl"
+)
+
+// ColumnRecord is one line of the JSON Lines report. Table-level coverage
+// types (description, contract, unit_test, model_test) produce one record per model,
+// without column.
+type ColumnRecord struct {
+	Model    string `json:"model"`
+	UniqueID string `json:"unique_id"`
+	Column   string `json:"column,omitempty"`
+	Index    int    `json:"index,omitempty"`
+	CovType  string `json:"cov_type"`
+	Covered  bool   `json:"covered"`
+}
+
+func writeJSONLReport(ctx context.Context, catalog Catalog, covType CoverageType, path string) error {
+	if path == "-" {
+		return streamColumnRecords(ctx, os.Stdout, catalog, covType)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	log.Printf("Writing JSON Lines report into %s", path)
+	if err := streamColumnRecords(ctx, f, catalog, covType); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// streamsJSONL tells whether the JSON Lines records are the only output and
+// nothing else needs the per-model reports: the records are then written
+// straight from the catalog, without building the detailed and JSON reports.
+func (opts Options) streamsJSONL() bool {
+	return opts.Format == ReportFormatJSONL && len(opts.ExtraOutputs) == 0 && opts.HTMLOutput == "" &&
+		opts.HistoryDir == "" && opts.PageSize == 0 && !opts.Benchmark && opts.Telemetry == nil &&
+		opts.ChangedFiles == nil && opts.FailUnderModel == 0 && opts.MaxUncoveredModel == nil &&
+		len(opts.Budgets) == 0 && opts.Debt == nil && opts.FreshnessSLA == nil && opts.Severities == nil
+}
+
+// streamJSONLReport writes the records of the catalog and returns the global
+// totals the run is checked against, the only part of the report it builds.
+func streamJSONLReport(ctx context.Context, opts Options, catalog Catalog, exemptions []ExemptionStatus) (JSONReport, error) {
+	if err := writeJSONLReport(ctx, catalog, opts.CovType, opts.Output); err != nil {
+		return JSONReport{}, err
+	}
+	report := JSONReport{CovType: string(opts.CovType), ManifestVersion: catalog.ManifestVersion, Exemptions: exemptions}
+	for _, table := range catalog.Tables {
+		covered, total := tableLevelCoverage(table, opts.CovType)
+		report.Covered += covered
+		report.Total += total
+		for _, col := range table.Columns {
+			if covered, applicable := col.Coverage[opts.CovType]; applicable {
+				report.Total++
+				if covered {
+					report.Covered++
+				}
+			}
+		}
+	}
+	report.Coverage = ratio(report.Covered, report.Total)
+	return report, nil
+}
+
+// streamColumnRecords encodes the records table by table, so the whole report
+// is never held in memory.
+func streamColumnRecords(ctx context.Context, w io.Writer, catalog Catalog, covType CoverageType) error {
+	ids := make([]string, 0, len(catalog.Tables))
+	for id := range catalog.Tables {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		table := catalog.Tables[id]
+		if covered, applicable := table.Coverage[covType]; applicable {
+			if err := enc.Encode(ColumnRecord{Model: table.Name, UniqueID: id, CovType: string(covType), Covered: covered}); err != nil {
+				return err
+			}
+		}
+		for _, col := range table.SortedColumns() {
+			covered, applicable := col.Coverage[covType]
+			if !applicable {
+				continue
+			}
+			record := ColumnRecord{Model: table.Name, UniqueID: id, Column: col.Name, Index: col.Index, CovType: string(covType), Covered: covered}
+			if err := enc.Encode(record); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
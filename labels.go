@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var labelKeyRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// labelsFlag collects the repeated --label key=value flags, also accepting
+// a list split using ',': --label env=prod --label release=2024.06.
+type labelsFlag map[string]string
+
+func (l labelsFlag) String() string {
+	items := make([]string, 0, len(l))
+	for _, key := range sortedKeys(l) {
+		items = append(items, key+"="+l[key])
+	}
+	return strings.Join(items, ",")
+}
+
+func (l labelsFlag) Set(value string) error {
+	for _, item := range splitList(value) {
+		key, val, ok := strings.Cut(item, "=")
+		if !ok || !labelKeyRegexp.MatchString(key) || val == "" {
+			return fmt.Errorf("invalid label %q, expected key=value", item)
+		}
+		l[key] = val
+	}
+	return nil
+}
+
+// matchLabels tells whether labels has every label of filter.
+func matchLabels(labels, filter map[string]string) bool {
+	for key, val := range filter {
+		if labels[key] != val {
+			return false
+		}
+	}
+	return true
+}
+
+// filterHistoryByLabels keeps the reports of the history carrying every label
+// of filter, so the trends of an environment or a release are not mixed with
+// the others.
+func filterHistoryByLabels(entries []HistoryEntry, filter map[string]string) []HistoryEntry {
+	if len(filter) == 0 {
+		return entries
+	}
+	var filtered []HistoryEntry
+	for _, entry := range entries {
+		if matchLabels(entry.Report.Labels, filter) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// queryLabels reads the ?label=env=prod filters of an API request.
+func queryLabels(query url.Values) (map[string]string, error) {
+	labels := make(labelsFlag)
+	for _, value := range query["label"] {
+		if err := labels.Set(value); err != nil {
+			return nil, err
+		}
+	}
+	return labels, nil
+}
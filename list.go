@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+type ListOptions struct {
+	Selector  Selector
+	Uncovered bool
+	Covered   bool
+}
+
+func runList(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("list")
+	var (
+		projectDir      = fs.String("dbt_dir", ".", "dbt project path")
+		runArtifactsDir = fs.String("target_dir", "target", "dbt target path")
+		covTypeStr      = fs.String("type", "test", "Coverage type (doc, test, accepted_values, relationships, description, contract, unit_test, model_test, monitoring, freshness, meta:<key> or a plugin name)")
+		configPath      = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		selectStr       = fs.String("select", "", "Models to list: name globs or path:, package:, resource_type:, tag: selectors (split using ',')")
+		nameFormat      = fs.String("name_format", DefaultNameFormat, "Model display name template (see the main command)")
+		uncovered       = fs.Bool("uncovered", false, "Only list the uncovered entries")
+		covered         = fs.Bool("covered", false, "Only list the covered entries")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	if *uncovered && *covered {
+		return errors.New("--uncovered and --covered are mutually exclusive")
+	}
+	selector, err := ParseSelector(splitList(*selectStr))
+	if err != nil {
+		return err
+	}
+	if err := ValidateNameFormat(*nameFormat); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath, *projectDir)
+	if err != nil {
+		return err
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		return err
+	}
+	settings.NameFormat = *nameFormat
+	defer closeCoverageProviders()
+	registerHeuristicProviders(cfg.Heuristics)
+	if err := registerPlugins(cfg.Plugins); err != nil {
+		return err
+	}
+	covType := CoverageType(*covTypeStr)
+	if _, err := lookupCoverageProvider(covType); err != nil {
+		return err
+	}
+
+	catalog, err := loadFiles(ctx, *projectDir, *runArtifactsDir, catalogRequired(covType), settings)
+	if err != nil {
+		return err
+	}
+	if err := evaluateCoverage(ctx, catalog, covType); err != nil {
+		return err
+	}
+	report := computeJSONReport(catalog, covType, GroupByNone)
+	return writeCoverageList(os.Stdout, report, ListOptions{Selector: selector, Uncovered: *uncovered, Covered: *covered})
+}
+
+// writeCoverageList prints one model.column entry per line. Table-level
+// coverage types (description, contract, unit_test, model_test) list the models alone.
+func writeCoverageList(w io.Writer, report JSONReport, opts ListOptions) error {
+	keep := func(covered bool) bool {
+		return !(opts.Uncovered && covered) && !(opts.Covered && !covered)
+	}
+	for _, t := range report.Tables {
+		if !opts.Selector.Matches(t) {
+			continue
+		}
+		if len(t.Columns) == 0 {
+			if t.Total > 0 && keep(t.Covered == t.Total) {
+				if _, err := fmt.Fprintln(w, t.Name); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for _, c := range t.Columns {
+			if !keep(c.Covered == c.Total) {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "%s.%s\n", t.Name, c.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
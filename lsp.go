@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// The subset of the Language Server Protocol served by the lsp subcommand:
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/
+const (
+	lspMethodNotFound = -32601
+
+	lspSeverityError       = 1
+	lspSeverityWarning     = 2
+	lspSeverityInformation = 3
+)
+
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+type lspNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspDiagnostic struct {
+	Range struct {
+		Start lspPosition `json:"start"`
+		End   lspPosition `json:"end"`
+	} `json:"range"`
+	Severity int    `json:"severity"`
+	Code     string `json:"code"`
+	Source   string `json:"source"`
+	Message  string `json:"message"`
+}
+
+type lspPublishDiagnostics struct {
+	URI         string          `json:"uri"`
+	Diagnostics []lspDiagnostic `json:"diagnostics"`
+}
+
+type lspTextDocumentParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// readLSPMessage reads one message framed by a Content-Length header.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return body, err
+}
+
+func writeLSPMessage(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	return err
+}
+
+func lspSeverity(severity Severity) int {
+	switch severity {
+	case SeverityError:
+		return lspSeverityError
+	case SeverityInfo:
+		return lspSeverityInformation
+	}
+	return lspSeverityWarning
+}
+
+// lspServer publishes the coverage gaps of the open .yml files. The coverage
+// comes from the artifacts, recomputed when they change (dbt parse, dbt docs
+// generate), while the lines come from the edited buffer, so the diagnostics
+// stay on their column while the file is being edited.
+type lspServer struct {
+	projectDir string
+	target     string
+	settings   ParseSettings
+	covTypes   []CoverageType
+	severities *SeverityConfig
+	exemptions []Exemption
+	out        io.Writer
+
+	docs     map[string]string
+	reports  []JSONReport
+	computed time.Time
+}
+
+func (s *lspServer) refresh(ctx context.Context) {
+	manifestPath := artifactPath(s.projectDir, s.target, "manifest.json")
+	catalogPath := artifactPath(s.projectDir, s.target, "catalog.json")
+	changed := latestModTime(manifestPath, catalogPath)
+	if !changed.After(s.computed) {
+		return
+	}
+	s.computed = changed
+	catalog, err := loadFiles(ctx, s.projectDir, s.target, catalogRequired(s.covTypes...), s.settings)
+	if err != nil {
+		s.logMessage(fmt.Sprintf("dbt-goverage: %v", err))
+		return
+	}
+	catalog, _ = catalog.ApplyExemptions(s.exemptions, time.Now())
+	s.reports = s.reports[:0]
+	for _, covType := range s.covTypes {
+		if err := evaluateCoverage(ctx, catalog, covType); err != nil {
+			s.logMessage(fmt.Sprintf("dbt-goverage: %v", err))
+			return
+		}
+		report := computeJSONReport(catalog, covType, GroupByNone)
+		if s.severities != nil {
+			applySeverities(&report, *s.severities)
+		}
+		s.reports = append(s.reports, report)
+	}
+	log.Printf("Coverage of %d tables loaded from %s", len(catalog.Tables), manifestPath)
+}
+
+func (s *lspServer) logMessage(message string) {
+	log.Print(message)
+	writeLSPMessage(s.out, lspNotification{JSONRPC: "2.0", Method: "window/logMessage", Params: map[string]any{"type": 2, "message": message}})
+}
+
+// documentPath returns the path of a file:// URI relative to the dbt project.
+func (s *lspServer) documentPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	path := u.Path
+	if len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:] // file:///C:/project/models/schema.yml
+	}
+	root, err := filepath.Abs(s.projectDir)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, filepath.FromSlash(path))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return slashPath(rel), true
+}
+
+// diagnostics lists the gaps of the tables documented in the file, at the
+// lines of the current text, which fails to parse while being typed.
+func (s *lspServer) diagnostics(uri string) ([]lspDiagnostic, error) {
+	diagnostics := []lspDiagnostic{}
+	path, ok := s.documentPath(uri)
+	if !ok {
+		return diagnostics, nil
+	}
+	text := s.docs[uri]
+	blocks, err := parseSchemaFile([]byte(text), s.settings.Naming)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(text, "\n")
+	for _, report := range s.reports {
+		var tables []TableReport
+		for _, t := range report.Tables {
+			if slashPath(t.PatchPath) != path {
+				continue
+			}
+			t.Columns = append([]ColumnReport(nil), t.Columns...)
+			t.applySchemaBlocks(blocks)
+			if t.Line > 0 {
+				tables = append(tables, t)
+			}
+		}
+		for _, gap := range coverageGaps(tables) {
+			var d lspDiagnostic
+			d.Range.Start.Line = gap.Line - 1
+			if gap.Line <= len(lines) {
+				line := strings.TrimRight(lines[gap.Line-1], "\r")
+				d.Range.Start.Character = utf16Len(line) - utf16Len(strings.TrimLeft(line, " -"))
+				d.Range.End = lspPosition{Line: gap.Line - 1, Character: utf16Len(line)}
+			}
+			d.Severity = lspSeverity(gap.Severity)
+			d.Code = coverageRuleID(report.CovType)
+			d.Source = "dbt-goverage"
+			d.Message = gap.Message(report.CovType)
+			diagnostics = append(diagnostics, d)
+		}
+	}
+	return diagnostics, nil
+}
+
+// utf16Len is the length of s in UTF-16 code units, the unit of the LSP
+// character offsets.
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// publish sends the diagnostics of a file, or nothing while its YAML is
+// invalid so that the editor keeps the previous ones.
+func (s *lspServer) publish(uri string) error {
+	diagnostics, err := s.diagnostics(uri)
+	if err != nil {
+		log.Printf("Diagnostics of %s not updated: %v", uri, err)
+		return nil
+	}
+	return writeLSPMessage(s.out, lspNotification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  lspPublishDiagnostics{URI: uri, Diagnostics: diagnostics},
+	})
+}
+
+// handle processes one message, done being true after the exit notification.
+func (s *lspServer) handle(ctx context.Context, msg lspMessage) (done bool, err error) {
+	var params lspTextDocumentParams
+	if len(msg.Params) > 0 {
+		json.Unmarshal(msg.Params, &params)
+	}
+	uri := params.TextDocument.URI
+	switch msg.Method {
+	case "initialize":
+		return false, writeLSPMessage(s.out, lspResponse{JSONRPC: "2.0", ID: msg.ID, Result: map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": map[string]any{"openClose": true, "change": 1, "save": map[string]any{}},
+			},
+			"serverInfo": map[string]string{"name": "dbt-goverage", "version": currentVersion()},
+		}})
+	case "shutdown":
+		return false, writeLSPMessage(s.out, lspResponse{JSONRPC: "2.0", ID: msg.ID})
+	case "exit":
+		return true, nil
+	case "textDocument/didOpen":
+		s.docs[uri] = params.TextDocument.Text
+		s.refresh(ctx)
+		return false, s.publish(uri)
+	case "textDocument/didChange":
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[uri] = params.ContentChanges[n-1].Text
+		}
+		return false, s.publish(uri)
+	case "textDocument/didSave":
+		// dbt parse usually runs on save: recompute and refresh every file.
+		s.refresh(ctx)
+		for open := range s.docs {
+			if err := s.publish(open); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	case "textDocument/didClose":
+		delete(s.docs, uri)
+		return false, writeLSPMessage(s.out, lspNotification{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics",
+			Params: lspPublishDiagnostics{URI: uri, Diagnostics: []lspDiagnostic{}}})
+	}
+	if len(msg.ID) > 0 {
+		return false, writeLSPMessage(s.out, lspResponse{JSONRPC: "2.0", ID: msg.ID,
+			Error: &lspError{Code: lspMethodNotFound, Message: "method not supported: " + msg.Method}})
+	}
+	return false, nil // other notifications ($/cancelRequest, initialized...)
+}
+
+func (s *lspServer) serve(ctx context.Context, in io.Reader) error {
+	r := bufio.NewReader(in)
+	for {
+		body, err := readLSPMessage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var msg lspMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			log.Printf("Invalid LSP message: %v", err)
+			continue
+		}
+		done, err := s.handle(ctx, msg)
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+func runLSP(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("lsp")
+	var (
+		projectDir      = fs.String("dbt_dir", ".", "dbt project path")
+		runArtifactsDir = fs.String("target_dir", "target", "dbt target path")
+		covTypesStr     = fs.String("type", "doc,test", "Coverage types reported as diagnostics (split using ',')")
+		configPath      = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+
+	cfg, err := loadConfig(*configPath, *projectDir)
+	if err != nil {
+		return err
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		return err
+	}
+	defer closeCoverageProviders()
+	registerHeuristicProviders(cfg.Heuristics)
+	if err := registerPlugins(cfg.Plugins); err != nil {
+		return err
+	}
+	server := &lspServer{
+		projectDir: *projectDir,
+		target:     *runArtifactsDir,
+		settings:   settings,
+		exemptions: cfg.Exemptions,
+		out:        os.Stdout,
+		docs:       make(map[string]string),
+	}
+	for _, name := range splitList(*covTypesStr) {
+		covType := CoverageType(name)
+		if _, err := lookupCoverageProvider(covType); err != nil {
+			return err
+		}
+		server.covTypes = append(server.covTypes, covType)
+	}
+	if cfg.Severities.enabled() {
+		server.severities = &cfg.Severities
+	}
+	return server.serve(ctx, os.Stdin)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -8,11 +9,14 @@ import (
 	"io"
 	"log"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
-
-	"github.com/olekukonko/tablewriter"
 )
 
 var SupportedManifestSchemaVersions = []string{
@@ -32,6 +36,11 @@ type CoverageType string
 const (
 	CoverageTypeDoc  CoverageType = "doc"
 	CoverageTypeTest CoverageType = "test"
+
+	CoverageTypeDescription CoverageType = "description"
+	CoverageTypeContract    CoverageType = "contract"
+	CoverageTypeUnitTest    CoverageType = "unit_test"
+	CoverageTypeModelTest   CoverageType = "model_test"
 )
 
 type CoverageFormat string
@@ -39,59 +48,190 @@ type CoverageFormat string
 const (
 	FormatStringTable   CoverageFormat = "string"
 	FormatMarkdownTable CoverageFormat = "markdown"
+	FormatHTMLReport    CoverageFormat = "html"
 )
 
 type Column struct {
-	Name string
-	Doc  bool
-	Test bool
+	Name        string
+	Index       int
+	Description string
+	Meta        map[string]interface{}
+	Tags        []string
+	Doc         bool
+	Test        bool
+	TestNames   []string
+	Coverage    map[CoverageType]bool
 }
 
 type Table struct {
 	UniqueID         string
 	Name             string
+	NodeName         string
+	ResourceType     string
+	PackageName      string
+	Database         string
+	Schema           string
 	OriginalFilePath string
+	PatchPath        string
+	Owners           []string
+	Description      string
+	Meta             map[string]interface{}
+	Tags             []string
+	ContractEnforced bool
+	PersistDocs      PersistDocs
+	Freshness        *SourceFreshness
+	CreatedAt        time.Time
+	Disabled         bool
+	External         bool
+	UnitTests        []string
+	ModelTests       []string
+	TestTypes        map[string]int
+	TestPackages     map[string]int
+	DeclaredTests    map[string]int
+	YAMLColumns      []string
+	QualityScore     *float64
+	Coverage         map[CoverageType]bool
 	Columns          map[string]Column
 }
 
 type Catalog struct {
-	Tables map[string]Table
+	Tables              map[string]Table
+	Disabled            []DisabledNode
+	Unattributed        []UnattributedTest
+	GeneratedAt         time.Time
+	ManifestGeneratedAt time.Time
+	ManifestVersion     string
+	Warnings            *runWarnings
+	Settings            ParseSettings
+}
+
+func (c Catalog) Stale() bool {
+	return !c.GeneratedAt.IsZero() && c.GeneratedAt.Before(c.ManifestGeneratedAt)
 }
 
 type Manifest struct {
-	Sources   map[string]map[string]interface{}
-	Models    map[string]map[string]interface{}
-	Seeds     map[string]map[string]interface{}
-	Snapshots map[string]map[string]interface{}
-	Tests     map[string]map[string][]interface{}
+	GeneratedAt time.Time
+	// SchemaVersion is the version of the manifest schema: v12.
+	SchemaVersion string
+	Sources       map[string]map[string]interface{}
+	Models        map[string]map[string]interface{}
+	Seeds         map[string]map[string]interface{}
+	Snapshots     map[string]map[string]interface{}
+	Tests         map[string]map[string][]interface{}
+	UnitTests     map[string][]string
+	ModelTests    map[string][]string
+	TestTypes     map[string]map[string]int
+	TestPackages  map[string]map[string]int
+	// DeclaredTests counts the generic tests of each table per macro,
+	// including the ones ignored by test_packages.
+	DeclaredTests map[string]map[string]int
+	Disabled      map[string]map[string]interface{}
+	Unattributed  []UnattributedTest
+	Warnings      *runWarnings
+	Settings      ParseSettings
 }
 
 type ColumnReport struct {
-	Name     string  `json:"name"`
-	Covered  int     `json:"covered"`
-	Total    int     `json:"total"`
-	Coverage float64 `json:"coverage"`
+	Name       string          `json:"name"`
+	Index      int             `json:"index,omitempty"`
+	Covered    int             `json:"covered"`
+	Total      int             `json:"total"`
+	Coverage   float64         `json:"coverage"`
+	Dimensions map[string]bool `json:"dimensions,omitempty"`
+	Severity   Severity        `json:"severity,omitempty"`
+	Line       int             `json:"line,omitempty"`
 }
 
 type TableReport struct {
-	Name     string         `json:"name"`
-	Covered  int            `json:"covered"`
-	Total    int            `json:"total"`
-	Coverage float64        `json:"coverage"`
-	Columns  []ColumnReport `json:"columns"`
+	Name             string         `json:"name"`
+	NodeName         string         `json:"node_name,omitempty"`
+	UniqueID         string         `json:"unique_id,omitempty"`
+	ResourceType     string         `json:"resource_type,omitempty"`
+	PackageName      string         `json:"package_name,omitempty"`
+	Database         string         `json:"database,omitempty"`
+	Schema           string         `json:"schema,omitempty"`
+	Group            string         `json:"group,omitempty"`
+	OriginalFilePath string         `json:"original_file_path,omitempty"`
+	PatchPath        string         `json:"patch_path,omitempty"`
+	Line             int            `json:"line,omitempty"`
+	Owners           []string       `json:"owners,omitempty"`
+	Tags             []string       `json:"tags,omitempty"`
+	TestTypes        map[string]int `json:"test_types,omitempty"`
+	TestTypeCount    int            `json:"distinct_test_types"`
+	Covered          int            `json:"covered"`
+	Total            int            `json:"total"`
+	Coverage         float64        `json:"coverage"`
+	QualityScore     *float64       `json:"quality_score,omitempty"`
+	Disabled         bool           `json:"disabled,omitempty"`
+	External         bool           `json:"external,omitempty"`
+	ContractEnforced bool           `json:"contract_enforced,omitempty"`
+	PersistDocs      *PersistDocs   `json:"persist_docs,omitempty"`
+	Severity         Severity       `json:"severity,omitempty"`
+	Columns          []ColumnReport `json:"columns"`
+}
+
+func (t TableReport) SchemaFilePath() string {
+	if t.PatchPath != "" {
+		return t.PatchPath
+	}
+	return t.OriginalFilePath
+}
+
+// SchemaLine is the line of the model in SchemaFilePath, 1 when unknown.
+func (t TableReport) SchemaLine() int {
+	if t.Line > 0 {
+		return t.Line
+	}
+	return 1
 }
 
 type JSONReport struct {
-	CovType  string        `json:"cov_type"`
-	Covered  int           `json:"covered"`
-	Total    int           `json:"total"`
-	Coverage float64       `json:"coverage"`
-	Tables   []TableReport `json:"tables"`
+	CovType         string             `json:"cov_type"`
+	Covered         int                `json:"covered"`
+	Total           int                `json:"total"`
+	Coverage        float64            `json:"coverage"`
+	CoverageScale   float64            `json:"coverage_scale,omitempty"`
+	GeneratedAt     string             `json:"generated_at,omitempty"`
+	GitSHA          string             `json:"git_sha,omitempty"`
+	Labels          map[string]string  `json:"labels,omitempty"`
+	ManifestVersion string             `json:"manifest_version,omitempty"`
+	QualityScore    *float64           `json:"quality_score,omitempty"`
+	GroupBy         string             `json:"group_by,omitempty"`
+	Groups          []GroupReport      `json:"groups,omitempty"`
+	Tables          []TableReport      `json:"tables"`
+	External        []TableReport      `json:"external_sources,omitempty"`
+	Disabled        []DisabledNode     `json:"disabled_nodes,omitempty"`
+	Unattributed    []UnattributedTest `json:"unattributed_tests,omitempty"`
+	TestPackages    []TestPackageUsage `json:"test_packages,omitempty"`
+	Seeds           []SeedAudit        `json:"seeds,omitempty"`
+	Warnings        []Warning          `json:"warnings,omitempty"`
+	Budgets         []BudgetProgress   `json:"budgets,omitempty"`
+	Exemptions      []ExemptionStatus  `json:"exemptions,omitempty"`
+	Debt            *DebtReport        `json:"coverage_debt,omitempty"`
+	Freshness       *FreshnessReport   `json:"source_freshness,omitempty"`
+	Severities      map[Severity]int   `json:"severities,omitempty"`
 }
 
-func NewColumnFromNode(node map[string]interface{}) Column {
-	name := strings.ToLower(node["name"].(string))
-	return Column{Name: name}
+func NewColumnFromNode(node map[string]interface{}, naming ColumnNaming) Column {
+	name := naming.Key(node["name"].(string))
+	index, _ := node["index"].(float64)
+	return Column{Name: name, Index: int(index)}
+}
+
+// SortedColumns lists the columns in their warehouse order (catalog index),
+// falling back to the name for columns without index.
+func (t Table) SortedColumns() []Column {
+	columns := make([]Column, 0, len(t.Columns))
+	for _, col := range t.Columns {
+		columns = append(columns, col)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if columns[i].Index != columns[j].Index {
+			return columns[i].Index < columns[j].Index
+		}
+		return columns[i].Name < columns[j].Name
+	})
+	return columns
 }
 
 func IsValidDoc(doc interface{}) bool {
@@ -121,7 +261,13 @@ func NewTableFromNode(node map[string]interface{}, manifest *Manifest) (Table, e
 	if columnsRaw, ok := node["columns"].(map[string]interface{}); ok {
 		for _, v := range columnsRaw {
 			if colNode, ok := v.(map[string]interface{}); ok {
-				col := NewColumnFromNode(colNode)
+				col := NewColumnFromNode(colNode, manifest.Settings.Naming)
+				if !manifest.Settings.IncludeSnapshotMetaColumns && isSnapshotMetaColumn(manifestTable, col.Name) {
+					continue
+				}
+				if _, excluded := excludedColumn(manifest.Settings.Exclusions, col.Name); excluded {
+					continue
+				}
 				cols[col.Name] = col
 			}
 		}
@@ -130,13 +276,26 @@ func NewTableFromNode(node map[string]interface{}, manifest *Manifest) (Table, e
 	if v, ok := manifestTable["original_file_path"].(string); ok {
 		origPath = v
 	} else {
-		log.Printf("warning: original_file_path not found in %s", uniqueID)
+		manifest.Warnings.add(WarningMissingOriginalPath, uniqueID, "original_file_path not found in %s", uniqueID)
 	}
+	patchPath, _ := manifestTable["patch_path"].(string)
+	resourceType, _ := manifestTable["resource_type"].(string)
+	packageName, _ := manifestTable["package_name"].(string)
 	name := strings.ToLower(manifestTable["name"].(string))
+	nodeName, _ := manifestTable["node_name"].(string)
+	database, _ := manifestTable["database"].(string)
+	schema, _ := manifestTable["schema"].(string)
 	return Table{
 		UniqueID:         uniqueID,
 		Name:             name,
+		NodeName:         nodeName,
+		ResourceType:     resourceType,
+		PackageName:      packageName,
+		Database:         strings.ToLower(database),
+		Schema:           strings.ToLower(schema),
 		OriginalFilePath: origPath,
+		PatchPath:        patchPath,
+		Owners:           manifest.Settings.Ownership.Owners(origPath),
 		Columns:          cols,
 	}, nil
 }
@@ -145,32 +304,92 @@ func (c Catalog) FilterTables(modelPathFilter []string) Catalog {
 	filtered := make(map[string]Table)
 	for id, table := range c.Tables {
 
-		originalPath := filepath.ToSlash(table.OriginalFilePath)
 		for _, filt := range modelPathFilter {
-
-			normalizedFilt := filepath.ToSlash(filt)
-			if strings.HasPrefix(originalPath, normalizedFilt) {
+			if hasPathPrefix(table.OriginalFilePath, filt) {
 				filtered[id] = table
 				break
 			}
 		}
 	}
 	log.Printf("Tables after filtering: %d", len(filtered))
-	return Catalog{Tables: filtered}
+	c.Tables = filtered
+	return c
+}
+
+var ResourceTypes = []string{"model", "source", "seed", "snapshot"}
+
+func ParseResourceTypes(values []string) ([]string, error) {
+	var types []string
+	for _, v := range values {
+		t := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(v)), "s")
+		found := false
+		for _, known := range ResourceTypes {
+			if t == known {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown resource type %q, expected one of: %s", v, strings.Join(ResourceTypes, ", "))
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+func (c Catalog) FilterResourceTypes(resourceTypes []string) Catalog {
+	filtered := make(map[string]Table)
+	for id, table := range c.Tables {
+		for _, t := range resourceTypes {
+			if table.ResourceType == t {
+				filtered[id] = table
+				break
+			}
+		}
+	}
+	log.Printf("Tables after filtering on resource types %v: %d", resourceTypes, len(filtered))
+	c.Tables = filtered
+	return c
 }
 
 func CatalogFromNodes(nodes []interface{}, manifest *Manifest) (Catalog, error) {
 	tables := make(map[string]Table)
 	for _, n := range nodes {
-		if node, ok := n.(map[string]interface{}); ok {
-			table, err := NewTableFromNode(node, manifest)
-			if err != nil {
-				return Catalog{}, err
-			}
-			tables[table.UniqueID] = table
+		node, ok := n.(map[string]interface{})
+		if !ok {
+			manifest.Warnings.add(WarningUnparseableNode, "", "catalog node of type %T ignored", n)
+			continue
 		}
+		if id, _ := node["unique_id"].(string); manifest.excluded(id) {
+			continue
+		}
+		table, err := NewTableFromNode(node, manifest)
+		if err != nil {
+			return Catalog{}, err
+		}
+		tables[table.UniqueID] = table
 	}
-	return Catalog{Tables: tables}, nil
+	return Catalog{Tables: tables, Disabled: disabledNodes(manifest), Unattributed: manifest.Unattributed, Warnings: manifest.Warnings, Settings: manifest.Settings}, nil
+}
+
+func CatalogFromManifest(manifest *Manifest) (Catalog, error) {
+	var nodes []interface{}
+	groups := []map[string]map[string]interface{}{manifest.Sources, manifest.Models, manifest.Seeds, manifest.Snapshots}
+	if manifest.Settings.IncludeDisabled {
+		groups = append(groups, manifest.Disabled)
+	}
+	for _, group := range groups {
+		for id, node := range group {
+			nodes = append(nodes, map[string]interface{}{"unique_id": id, "columns": node["columns"]})
+		}
+	}
+	catalog, err := CatalogFromNodes(nodes, manifest)
+	if err != nil {
+		return Catalog{}, err
+	}
+	catalog.ManifestGeneratedAt = manifest.GeneratedAt
+	catalog.ManifestVersion = manifest.SchemaVersion
+	return catalog, nil
 }
 
 func (m *Manifest) GetTable(tableID string) (map[string]interface{}, error) {
@@ -187,6 +406,9 @@ func (m *Manifest) GetTable(tableID string) (map[string]interface{}, error) {
 	if v, ok := m.Snapshots[tableID]; ok {
 		candidates = append(candidates, v)
 	}
+	if v, ok := m.Disabled[tableID]; ok && m.Settings.IncludeDisabled && len(candidates) == 0 {
+		candidates = append(candidates, v)
+	}
 	if len(candidates) == 0 {
 		return nil, fmt.Errorf("table %s not found", tableID)
 	}
@@ -196,32 +418,56 @@ func (m *Manifest) GetTable(tableID string) (map[string]interface{}, error) {
 	return candidates[0], nil
 }
 
-func ManifestFromNodes(manifestNodes map[string]interface{}) (*Manifest, error) {
+func ManifestFromNodes(manifestNodes map[string]interface{}, settings ParseSettings) (*Manifest, error) {
 	sources := make(map[string]map[string]interface{})
 	models := make(map[string]map[string]interface{})
 	seeds := make(map[string]map[string]interface{})
 	snapshots := make(map[string]map[string]interface{})
 	tests := make(map[string]map[string][]interface{})
+	modelTests := make(map[string][]string)
+	warnings := new(runWarnings)
+	var unattributed []UnattributedTest
+	testIDs := make(map[string]map[string]map[string]bool)
+	countTest := func(tableID, id string, testMeta map[string]interface{}) {
+		macro := testMacroName(testMeta)
+		if testIDs[tableID] == nil {
+			testIDs[tableID] = make(map[string]map[string]bool)
+		}
+		if testIDs[tableID][macro] == nil {
+			testIDs[tableID][macro] = make(map[string]bool)
+		}
+		testIDs[tableID][macro][id] = true
+	}
+	packageTests := make(map[string]map[string]int)
+	declaredTests := make(map[string]map[string]int)
+	addTest := func(tableID, column string, node map[string]interface{}) {
+		if tests[tableID] == nil {
+			tests[tableID] = make(map[string][]interface{})
+		}
+		key := settings.Naming.Key(column)
+		tests[tableID][key] = append(tests[tableID][key], node)
+	}
 
-	for _, v := range manifestNodes {
+	for id, v := range manifestNodes {
 		node, ok := v.(map[string]interface{})
 		if !ok {
+			warnings.add(WarningUnparseableNode, id, "manifest node %s of type %T ignored", id, v)
 			continue
 		}
 		resourceType, _ := node["resource_type"].(string)
 		switch resourceType {
 		case "source":
 			id, _ := node["unique_id"].(string)
-			sources[id] = normalizeTable(node)
+			sources[id] = normalizeTable(node, settings)
 		case "model":
 			id, _ := node["unique_id"].(string)
-			models[id] = normalizeTable(node)
+			models[id] = normalizeTable(node, settings)
 		case "seed":
 			id, _ := node["unique_id"].(string)
-			seeds[id] = normalizeTable(node)
+			seeds[id] = normalizeTable(node, settings)
 		case "snapshot":
 			id, _ := node["unique_id"].(string)
-			snapshots[id] = normalizeTable(node)
+			snapshots[id] = normalizeTable(node, settings)
 		case "test":
 			if _, exists := node["test_metadata"]; !exists {
 				continue
@@ -232,6 +478,7 @@ func ManifestFromNodes(manifestNodes map[string]interface{}) (*Manifest, error)
 			}
 			nodesDep, ok := dependsRaw["nodes"].([]interface{})
 			if !ok || len(nodesDep) == 0 {
+				warnings.add(WarningUnmappedTest, id, "test %s does not depend on any node", id)
 				continue
 			}
 			testMeta, ok := node["test_metadata"].(map[string]interface{})
@@ -249,6 +496,28 @@ func ManifestFromNodes(manifestNodes map[string]interface{}) (*Manifest, error)
 					tableID = first
 				}
 			}
+			pkg := testPackage(node, testMeta)
+			if packageTests[tableID] == nil {
+				packageTests[tableID] = make(map[string]int)
+			}
+			packageTests[tableID][pkg]++
+			if declaredTests[tableID] == nil {
+				declaredTests[tableID] = make(map[string]int)
+			}
+			declaredTests[tableID][testMacroName(testMeta)]++
+			if !settings.TestPackages.Includes(pkg) {
+				continue
+			}
+			countTest(tableID, id, testMeta)
+			if columns, ok := configuredTestColumns(testMeta, settings.TestColumns); ok && len(columns) > 0 {
+				if columnName, _ := node["column_name"].(string); columnName == "" {
+					modelTests[tableID] = append(modelTests[tableID], id)
+				}
+				for _, column := range columns {
+					addTest(tableID, column, node)
+				}
+				continue
+			}
 			var columnName string
 			if v, exists := node["column_name"]; exists {
 				if s, ok := v.(string); ok {
@@ -272,31 +541,77 @@ func ManifestFromNodes(manifestNodes map[string]interface{}) (*Manifest, error)
 				}
 			}
 			if columnName == "" {
+				modelTests[tableID] = append(modelTests[tableID], id)
+				kwargs, _ := testMeta["kwargs"].(map[string]interface{})
+				if columns := modelTestColumns(kwargs); len(columns) > 0 {
+					for _, column := range columns {
+						addTest(tableID, column, node)
+					}
+				} else if keys := unresolvedColumnKwargs(kwargs); len(keys) > 0 {
+					warnings.add(WarningUnknownKwargs, id, "test %s references columns through unsupported kwargs %v, they are not credited", id, keys)
+					unattributed = append(unattributed, newUnattributedTest(id, tableID, testMeta))
+				}
 				continue
 			}
-			columnName = strings.ToLower(columnName)
-			if tests[tableID] == nil {
-				tests[tableID] = make(map[string][]interface{})
+			addTest(tableID, columnName, node)
+			if testName == "relationships" && settings.CreditRelationshipParent {
+				if parentID, field := relationshipParent(node, testMeta, tableID); parentID != "" {
+					addTest(parentID, field, node)
+					countTest(parentID, id, testMeta)
+				}
 			}
-			tests[tableID][columnName] = append(tests[tableID][columnName], node)
+		}
+	}
+
+	for _, columns := range tests {
+		for _, columnTests := range columns {
+			sort.Slice(columnTests, func(i, j int) bool {
+				a, _ := columnTests[i].(map[string]interface{})["unique_id"].(string)
+				b, _ := columnTests[j].(map[string]interface{})["unique_id"].(string)
+				return a < b
+			})
+		}
+	}
+
+	sortUnattributedTests(unattributed)
+	for _, ids := range modelTests {
+		sort.Strings(ids)
+	}
+	testTypes := make(map[string]map[string]int, len(testIDs))
+	for tableID, macros := range testIDs {
+		testTypes[tableID] = make(map[string]int, len(macros))
+		for macro, ids := range macros {
+			testTypes[tableID][macro] = len(ids)
 		}
 	}
 
 	return &Manifest{
-		Sources:   sources,
-		Models:    models,
-		Seeds:     seeds,
-		Snapshots: snapshots,
-		Tests:     tests,
+		Sources:       sources,
+		Models:        models,
+		Seeds:         seeds,
+		Snapshots:     snapshots,
+		Tests:         tests,
+		UnitTests:     make(map[string][]string),
+		ModelTests:    modelTests,
+		TestTypes:     testTypes,
+		TestPackages:  packageTests,
+		DeclaredTests: declaredTests,
+		Unattributed:  unattributed,
+		Warnings:      warnings,
+		Settings:      settings,
 	}, nil
 }
 
-func normalizeTable(table map[string]interface{}) map[string]interface{} {
+func normalizeTable(table map[string]interface{}, settings ParseSettings) map[string]interface{} {
 	if cols, ok := table["columns"].(map[string]interface{}); ok {
 		normCols := make(map[string]interface{})
 		for _, v := range cols {
 			if col, ok := v.(map[string]interface{}); ok {
-				name := strings.ToLower(col["name"].(string))
+				rawName := col["name"].(string)
+				if _, quoted := unquoteIdentifier(rawName); quoted {
+					col["quote"] = true
+				}
+				name := settings.Naming.Key(rawName)
 				col["name"] = name
 				normCols[name] = col
 			}
@@ -304,18 +619,33 @@ func normalizeTable(table map[string]interface{}) map[string]interface{} {
 		table["columns"] = normCols
 	}
 	if pathStr, ok := table["original_file_path"].(string); ok {
-		table["original_file_path"] = filepath.ToSlash(pathStr)
+		table["original_file_path"] = slashPath(pathStr)
 	}
-	schema, _ := table["schema"].(string)
-	name, _ := table["name"].(string)
-	table["name"] = strings.ToLower(fmt.Sprintf("%s.%s", schema, name))
+	if pathStr, ok := table["patch_path"].(string); ok {
+		if _, p, found := strings.Cut(pathStr, "://"); found {
+			pathStr = p
+		}
+		table["patch_path"] = slashPath(pathStr)
+	}
+	if name, _ := table["name"].(string); !strings.EqualFold(name, relationIdentifier(table)) {
+		table["node_name"] = name
+	}
+	format := settings.NameFormat
+	if format == "" {
+		format = DefaultNameFormat
+	}
+	table["name"] = formatTableName(table, format)
 	return table
 }
 
 type TableCoverage struct {
 	ModelName string
+	NodeName  string
+	Group     string
+	Folder    string
 	Covered   int
 	Total     int
+	Score     *float64
 }
 
 type DetailedCoverageReport struct {
@@ -324,87 +654,161 @@ type DetailedCoverageReport struct {
 	TotalColumns int
 	TableCount   int
 	CovType      CoverageType
+	GroupBy      GroupBy
+	QualityScore *float64
+	Disabled     []DisabledNode
+	Unattributed []UnattributedTest
+	TestPackages []TestPackageUsage
+	Seeds        []SeedAudit
+	Warnings     []Warning
 }
 
-func computeJSONReport(catalog Catalog, covType CoverageType) JSONReport {
+func computeJSONReport(catalog Catalog, covType CoverageType, groupBy GroupBy) JSONReport {
 	var tables []TableReport
 	globalCovered := 0
 	globalTotal := 0
 
 	for _, table := range catalog.Tables {
 		var cols []ColumnReport
-		tableCovered := 0
-		tableTotal := 0
-		for _, col := range table.Columns {
+		tableCovered, tableTotal := tableLevelCoverage(table, covType)
+		for _, col := range table.SortedColumns() {
+			isCovered, applicable := col.Coverage[covType]
+			if !applicable {
+				continue
+			}
 			colTotal := 1
 			colCovered := 0
-			switch covType {
-			case CoverageTypeDoc:
-				if col.Doc {
-					colCovered = 1
-				}
-			case CoverageTypeTest:
-				if col.Test {
-					colCovered = 1
-				}
+			if isCovered {
+				colCovered = 1
 			}
 			cols = append(cols, ColumnReport{
-				Name:     col.Name,
-				Covered:  colCovered,
-				Total:    colTotal,
-				Coverage: float64(colCovered) / float64(colTotal),
+				Name:       col.Name,
+				Index:      col.Index,
+				Covered:    colCovered,
+				Total:      colTotal,
+				Coverage:   float64(colCovered) / float64(colTotal),
+				Dimensions: columnDimensions(col),
 			})
 			tableTotal += colTotal
 			tableCovered += colCovered
 		}
+		if tableTotal == 0 && len(table.Columns) > 0 {
+			continue
+		}
+		var persistDocs *PersistDocs
+		if covType == CoverageTypePersistDocs && tableTotal > 0 {
+			persistDocs = &table.PersistDocs
+		}
 		tables = append(tables, TableReport{
-			Name:     table.Name,
-			Covered:  tableCovered,
-			Total:    tableTotal,
-			Coverage: float64(tableCovered) / float64(tableTotal),
-			Columns:  cols,
+			Name:             table.Name,
+			NodeName:         table.NodeName,
+			UniqueID:         table.UniqueID,
+			ResourceType:     table.ResourceType,
+			PackageName:      table.PackageName,
+			Database:         table.Database,
+			Schema:           table.Schema,
+			Group:            groupBy.Key(table),
+			OriginalFilePath: table.OriginalFilePath,
+			PatchPath:        table.PatchPath,
+			Owners:           table.Owners,
+			Tags:             table.Tags,
+			TestTypes:        table.TestTypes,
+			TestTypeCount:    len(table.TestTypes),
+			Covered:          tableCovered,
+			Total:            tableTotal,
+			Coverage:         ratio(tableCovered, tableTotal),
+			QualityScore:     table.QualityScore,
+			Disabled:         table.Disabled,
+			External:         table.External,
+			ContractEnforced: table.ContractEnforced,
+			PersistDocs:      persistDocs,
+			Columns:          cols,
 		})
 		globalTotal += tableTotal
 		globalCovered += tableCovered
 	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].UniqueID < tables[j].UniqueID })
+
+	report := JSONReport{
+		CovType:         string(covType),
+		Covered:         globalCovered,
+		Total:           globalTotal,
+		Coverage:        ratio(globalCovered, globalTotal),
+		ManifestVersion: catalog.ManifestVersion,
+		QualityScore:    averageQualityScore(catalog),
+		Tables:          tables,
+		Disabled:        catalog.Disabled,
+		Unattributed:    unattributedFor(catalog.Unattributed, catalog.Tables),
+		TestPackages:    testPackageUsage(catalog.Tables, catalog.Settings.TestPackages),
+		Seeds:           auditSeeds(catalog),
+	}
+	if groupBy != GroupByNone {
+		report.GroupBy = string(groupBy)
+		report.Groups = computeGroupReports(tables)
+	}
+	return report
+}
 
-	globalCoverage := 0.0
-	if globalTotal > 0 {
-		globalCoverage = float64(globalCovered) / float64(globalTotal)
+// columnDimensions answers the coverage dimensions of a column at once,
+// whatever the coverage type of the run: doc and test are always known, meta
+// keys and plugins appear once evaluated. The contract is a property of the
+// model, reported on the table.
+func columnDimensions(col Column) map[string]bool {
+	dims := map[string]bool{
+		string(CoverageTypeDoc):  col.Doc,
+		string(CoverageTypeTest): col.Test,
 	}
-	return JSONReport{
-		CovType:  string(covType),
-		Covered:  globalCovered,
-		Total:    globalTotal,
-		Coverage: globalCoverage,
-		Tables:   tables,
+	for covType, covered := range col.Coverage {
+		dims[string(covType)] = covered
 	}
+	return dims
 }
 
-func computeDetailedCoverage(catalog Catalog, covType CoverageType) DetailedCoverageReport {
+func tableLevelCoverage(table Table, covType CoverageType) (int, int) {
+	covered, applicable := table.Coverage[covType]
+	switch {
+	case !applicable:
+		return 0, 0
+	case covered:
+		return 1, 1
+	}
+	return 0, 1
+}
+
+func ratio(covered, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total)
+}
+
+func computeDetailedCoverage(catalog Catalog, covType CoverageType, groupBy GroupBy) DetailedCoverageReport {
 	var reports []TableCoverage
 	totalCovered := 0
 	totalColumns := 0
 	for _, table := range catalog.Tables {
-		tCovered := 0
-		tTotal := 0
+		tCovered, tTotal := tableLevelCoverage(table, covType)
 		for _, col := range table.Columns {
+			isCovered, applicable := col.Coverage[covType]
+			if !applicable {
+				continue
+			}
 			tTotal++
-			switch covType {
-			case CoverageTypeDoc:
-				if col.Doc {
-					tCovered++
-				}
-			case CoverageTypeTest:
-				if col.Test {
-					tCovered++
-				}
+			if isCovered {
+				tCovered++
 			}
 		}
+		if tTotal == 0 && len(table.Columns) > 0 {
+			continue
+		}
 		reports = append(reports, TableCoverage{
 			ModelName: table.Name,
+			NodeName:  table.NodeName,
+			Group:     groupBy.Key(table),
+			Folder:    tableFolder(table),
 			Covered:   tCovered,
 			Total:     tTotal,
+			Score:     table.QualityScore,
 		})
 		totalCovered += tCovered
 		totalColumns += tTotal
@@ -413,51 +817,44 @@ func computeDetailedCoverage(catalog Catalog, covType CoverageType) DetailedCove
 		TableReports: reports,
 		TotalCovered: totalCovered,
 		TotalColumns: totalColumns,
-		TableCount:   len(catalog.Tables),
+		TableCount:   len(reports),
 		CovType:      covType,
+		GroupBy:      groupBy,
+		QualityScore: averageQualityScore(catalog),
+		Disabled:     catalog.Disabled,
+		Unattributed: unattributedFor(catalog.Unattributed, catalog.Tables),
+		TestPackages: testPackageUsage(catalog.Tables, catalog.Settings.TestPackages),
+		Seeds:        auditSeeds(catalog),
 	}
 }
 
-func printDetailedCoverageReport(report DetailedCoverageReport) {
-
-	fmt.Printf("%s ✅ Analysis done: %d tables, %d columns.\n\n",
-		currentLogPrefix(), report.TableCount, report.TotalColumns)
-	fmt.Printf("📊 Coverage Report (%s)\n", strings.ToUpper(string(report.CovType)))
-	fmt.Println()
-
-	// Création d'un nouvel objet tablewriter
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Model", "Columns Ratio", "Coverage"})
-	table.SetBorder(false)
-	table.SetCenterSeparator("│")
-	table.SetColumnAlignment([]int{
-		tablewriter.ALIGN_LEFT, tablewriter.ALIGN_CENTER, tablewriter.ALIGN_RIGHT,
-	})
-
-	for _, tr := range report.TableReports {
-		ratio := fmt.Sprintf("(%d/%d)", tr.Covered, tr.Total)
-		coverage := "0.0%"
-		if tr.Total > 0 {
-			coverage = fmt.Sprintf("%.1f%%", float64(tr.Covered)/float64(tr.Total)*100)
-		}
-		table.Append([]string{tr.ModelName, ratio, coverage})
+func metadataGeneratedAt(artifact map[string]interface{}) time.Time {
+	metadata, ok := artifact["metadata"].(map[string]interface{})
+	if !ok {
+		return time.Time{}
 	}
-
-	totalRatio := fmt.Sprintf("(%d/%d)", report.TotalCovered, report.TotalColumns)
-	totalCoverage := "0.0%"
-	if report.TotalColumns > 0 {
-		totalCoverage = fmt.Sprintf("%.1f%%", float64(report.TotalCovered)/float64(report.TotalColumns)*100)
+	generatedAt, _ := metadata["generated_at"].(string)
+	t, err := time.Parse(time.RFC3339Nano, generatedAt)
+	if err != nil {
+		return time.Time{}
 	}
-	table.SetFooter([]string{"TOTAL", totalRatio, totalCoverage})
+	return t
+}
 
-	table.Render()
+func printPartialSummary(w io.Writer, catalog Catalog) {
+	columns := 0
+	for _, table := range catalog.Tables {
+		columns += len(table.Columns)
+	}
+	fmt.Fprintf(w, "%s ⚠️ Run stopped early after analyzing %d tables (%d columns), no report written.\n",
+		currentLogPrefix(), len(catalog.Tables), columns)
 }
 
 func currentLogPrefix() string {
 	return time.Now().Format("02-01-2006 15:04:05")
 }
 
-func checkManifestVersion(manifestJSON map[string]interface{}) {
+func checkManifestVersion(manifestJSON map[string]interface{}, warnings *runWarnings) {
 	metadata, ok := manifestJSON["metadata"].(map[string]interface{})
 	if !ok {
 		return
@@ -471,17 +868,40 @@ func checkManifestVersion(manifestJSON map[string]interface{}) {
 		}
 	}
 	if !found {
-		log.Printf("warning: manifest version %s invalid. Valid versions: %v", version, SupportedManifestSchemaVersions)
+		warnings.add(WarningManifestVersion, "", "manifest version %s invalid. Valid versions: %v", version, SupportedManifestSchemaVersions)
 	}
 }
 
-func loadManifest(projectDir string, runArtifactsDir string) (*Manifest, error) {
-	var manifestPath string
+// manifestSchemaVersion reads the version of metadata.dbt_schema_version:
+// v12 for https://schemas.getdbt.com/dbt/manifest/v12.json.
+func manifestSchemaVersion(manifestJSON map[string]interface{}) string {
+	metadata, _ := manifestJSON["metadata"].(map[string]interface{})
+	schema, _ := metadata["dbt_schema_version"].(string)
+	version := strings.TrimSuffix(schema[strings.LastIndex(schema, "/")+1:], ".json")
+	if manifestVersionNumber(version) == 0 {
+		return ""
+	}
+	return version
+}
+
+// manifestVersionNumber is 12 for v12, 0 for an unknown version.
+func manifestVersionNumber(version string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil || !strings.HasPrefix(version, "v") {
+		return 0
+	}
+	return n
+}
+
+func artifactPath(projectDir string, runArtifactsDir string, name string) string {
 	if runArtifactsDir == "" {
-		manifestPath = filepath.Join(projectDir, "target", "manifest.json")
-	} else {
-		manifestPath = filepath.Join(runArtifactsDir, "manifest.json")
+		return filepath.Join(projectDir, "target", name)
 	}
+	return filepath.Join(runArtifactsDir, name)
+}
+
+func loadManifest(projectDir string, runArtifactsDir string, settings ParseSettings) (*Manifest, error) {
+	manifestPath := artifactPath(projectDir, runArtifactsDir, "manifest.json")
 	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
 		return nil, fmt.Errorf("manifest.json not found in %s", manifestPath)
 	}
@@ -489,11 +909,14 @@ func loadManifest(projectDir string, runArtifactsDir string) (*Manifest, error)
 	if err != nil {
 		return nil, err
 	}
+	return ParseManifest(data, settings)
+}
+
+func ParseManifest(data []byte, settings ParseSettings) (*Manifest, error) {
 	var manifestJSON map[string]interface{}
 	if err := json.Unmarshal(data, &manifestJSON); err != nil {
 		return nil, err
 	}
-	checkManifestVersion(manifestJSON)
 	nodes := make(map[string]interface{})
 	if sources, ok := manifestJSON["sources"].(map[string]interface{}); ok {
 		for k, v := range sources {
@@ -505,16 +928,33 @@ func loadManifest(projectDir string, runArtifactsDir string) (*Manifest, error)
 			nodes[k] = v
 		}
 	}
-	return ManifestFromNodes(nodes)
+	manifest, err := ManifestFromNodes(nodes, settings)
+	if err != nil {
+		return nil, err
+	}
+	checkManifestVersion(manifestJSON, manifest.Warnings)
+	manifest.GeneratedAt = metadataGeneratedAt(manifestJSON)
+	manifest.SchemaVersion = manifestSchemaVersion(manifestJSON)
+	manifest.Disabled = parseDisabledNodes(manifestJSON, settings)
+	if unitTests, ok := manifestJSON["unit_tests"].(map[string]interface{}); ok {
+		for id, v := range unitTests {
+			node, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			dependsOn, _ := node["depends_on"].(map[string]interface{})
+			for _, dep := range stringList(dependsOn["nodes"]) {
+				if strings.HasPrefix(dep, "model.") {
+					manifest.UnitTests[dep] = append(manifest.UnitTests[dep], id)
+				}
+			}
+		}
+	}
+	return manifest, nil
 }
 
 func loadCatalog(projectDir string, runArtifactsDir string, manifest *Manifest) (Catalog, error) {
-	var catalogPath string
-	if runArtifactsDir == "" {
-		catalogPath = filepath.Join(projectDir, "target", "catalog.json")
-	} else {
-		catalogPath = filepath.Join(runArtifactsDir, "catalog.json")
-	}
+	catalogPath := artifactPath(projectDir, runArtifactsDir, "catalog.json")
 	if _, err := os.Stat(catalogPath); os.IsNotExist(err) {
 		return Catalog{}, fmt.Errorf("catalog.json not found in %s", catalogPath)
 	}
@@ -522,138 +962,1018 @@ func loadCatalog(projectDir string, runArtifactsDir string, manifest *Manifest)
 	if err != nil {
 		return Catalog{}, err
 	}
+	return ParseCatalog(data, manifest)
+}
+
+func ParseCatalog(data []byte, manifest *Manifest) (Catalog, error) {
+	catalogNodes, generatedAt, err := parseCatalogNodes(data)
+	if err != nil {
+		return Catalog{}, err
+	}
+	nodes := make([]interface{}, 0, len(catalogNodes))
+	for _, node := range catalogNodes {
+		nodes = append(nodes, node)
+	}
+	if manifest.Settings.IncludeDisabled {
+		// dbt docs generate skips the disabled nodes, their columns are the
+		// ones declared in the manifest.
+		for id, node := range manifest.Disabled {
+			if _, ok := catalogNodes[id]; !ok {
+				nodes = append(nodes, map[string]interface{}{"unique_id": id, "columns": node["columns"]})
+			}
+		}
+	}
+	catalog, err := CatalogFromNodes(nodes, manifest)
+	if err != nil {
+		return Catalog{}, err
+	}
+	catalog.GeneratedAt = generatedAt
+	catalog.ManifestGeneratedAt = manifest.GeneratedAt
+	catalog.ManifestVersion = manifest.SchemaVersion
+	return catalog, nil
+}
+
+func parseCatalogNodes(data []byte) (map[string]interface{}, time.Time, error) {
 	var catalogJSON map[string]interface{}
 	if err := json.Unmarshal(data, &catalogJSON); err != nil {
-		return Catalog{}, err
+		return nil, time.Time{}, err
 	}
-	var catalogNodes []interface{}
+	nodes := make(map[string]interface{})
 	for _, key := range []string{"sources", "nodes"} {
 		if group, ok := catalogJSON[key].(map[string]interface{}); ok {
 			for id, node := range group {
 				if strings.HasPrefix(id, "test.") {
 					continue
 				}
-				catalogNodes = append(catalogNodes, node)
+				nodes[id] = node
 			}
 		}
 	}
-	return CatalogFromNodes(catalogNodes, manifest)
+	return nodes, metadataGeneratedAt(catalogJSON), nil
 }
 
-func loadFiles(projectDir string, runArtifactsDir string) (Catalog, error) {
+func loadFiles(ctx context.Context, projectDir string, runArtifactsDir string, withCatalog bool, settings ParseSettings) (Catalog, error) {
 	if runArtifactsDir == "" {
 		log.Printf("Loading files from: %s", projectDir)
 	} else {
 		log.Printf("Loading files from a specified artifacts folder: %s", runArtifactsDir)
 	}
-	manifest, err := loadManifest(projectDir, runArtifactsDir)
+	manifest, err := loadManifest(projectDir, runArtifactsDir, settings)
+	if err != nil {
+		return Catalog{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return Catalog{}, err
+	}
+	var catalog Catalog
+	if withCatalog {
+		catalog, err = loadCatalog(projectDir, runArtifactsDir, manifest)
+	} else {
+		log.Printf("Only manifest-derived metrics requested, catalog.json is not loaded")
+		catalog, err = CatalogFromManifest(manifest)
+	}
+	if err != nil {
+		return Catalog{}, err
+	}
+	return enrichLoadedCatalog(ctx, catalog, manifest)
+}
+
+// enrichLoadedCatalog enriches a freshly loaded catalog and warns when
+// catalog.json is older than manifest.json.
+func enrichLoadedCatalog(ctx context.Context, catalog Catalog, manifest *Manifest) (Catalog, error) {
+	catalog, err := EnrichCatalog(ctx, catalog, manifest)
+	if err != nil {
+		return catalog, err
+	}
+	if catalog.Stale() {
+		catalog.Warnings.add(WarningStaleCatalog, "", "catalog.json (%s) is older than manifest.json (%s), run `dbt docs generate` again",
+			catalog.GeneratedAt.Format(time.RFC3339), catalog.ManifestGeneratedAt.Format(time.RFC3339))
+	}
+	return catalog, nil
+}
+
+func BuildCatalog(ctx context.Context, manifestData, catalogData []byte, settings ParseSettings) (Catalog, error) {
+	manifest, err := ParseManifest(manifestData, settings)
 	if err != nil {
 		return Catalog{}, err
 	}
-	catalog, err := loadCatalog(projectDir, runArtifactsDir, manifest)
+	catalog, err := ParseCatalog(catalogData, manifest)
 	if err != nil {
 		return Catalog{}, err
 	}
+	return EnrichCatalog(ctx, catalog, manifest)
+}
 
+func EnrichCatalog(ctx context.Context, catalog Catalog, manifest *Manifest) (Catalog, error) {
+	processed := make(map[string]Table, len(catalog.Tables))
 	for tableID, table := range catalog.Tables {
-		var manifestTable map[string]interface{}
-		if v, ok := manifest.Sources[tableID]; ok {
-			manifestTable = v
-		} else if v, ok := manifest.Models[tableID]; ok {
-			manifestTable = v
-		} else if v, ok := manifest.Seeds[tableID]; ok {
-			manifestTable = v
-		} else if v, ok := manifest.Snapshots[tableID]; ok {
-			manifestTable = v
-		}
-		var manifestColumns map[string]interface{}
-		if manifestTable != nil {
-			if mc, ok := manifestTable["columns"].(map[string]interface{}); ok {
-				manifestColumns = mc
-			}
-		}
-		manifestTableTests := manifest.Tests[tableID]
-		for colName, col := range table.Columns {
-			var colInfo map[string]interface{}
-			if manifestColumns != nil {
-				if v, ok := manifestColumns[colName]; ok {
-					if ci, ok := v.(map[string]interface{}); ok {
-						colInfo = ci
-					}
-				}
-			}
-			var desc interface{}
-			if colInfo != nil {
-				desc = colInfo["description"]
-			}
-			col.Doc = IsValidDoc(desc)
-			var testsForCol []interface{}
-			if manifestTableTests != nil {
-				testsForCol = manifestTableTests[colName]
-			}
-			col.Test = IsValidTest(testsForCol)
-			table.Columns[colName] = col
+		if err := ctx.Err(); err != nil {
+			catalog.Tables = processed
+			return catalog, err
 		}
+		table = enrichTable(table, manifest)
 		catalog.Tables[tableID] = table
+		processed[tableID] = table
 	}
 	return catalog, nil
 }
 
+func enrichTable(table Table, manifest *Manifest) Table {
+	var manifestTable map[string]interface{}
+	if v, ok := manifest.Sources[table.UniqueID]; ok {
+		manifestTable = v
+	} else if v, ok := manifest.Models[table.UniqueID]; ok {
+		manifestTable = v
+	} else if v, ok := manifest.Seeds[table.UniqueID]; ok {
+		manifestTable = v
+	} else if v, ok := manifest.Snapshots[table.UniqueID]; ok {
+		manifestTable = v
+	} else if v, ok := manifest.Disabled[table.UniqueID]; ok {
+		manifestTable = v
+		table.Disabled = true
+	}
+	var manifestColumns map[string]interface{}
+	if manifestTable != nil {
+		if mc, ok := manifestTable["columns"].(map[string]interface{}); ok {
+			manifestColumns = mc
+			table.YAMLColumns = sortedKeys(mc)
+		}
+		table.Description, _ = manifestTable["description"].(string)
+		table.Meta = mergedMeta(manifestTable)
+		table.Tags = stringList(manifestTable["tags"])
+		table.External = isExternalSource(manifestTable)
+		if contract, ok := manifestTable["contract"].(map[string]interface{}); ok {
+			table.ContractEnforced, _ = contract["enforced"].(bool)
+		}
+		table.PersistDocs = parsePersistDocs(manifestTable)
+		if table.ResourceType == "source" {
+			table.Freshness = parseSourceFreshness(manifestTable)
+		}
+		if createdAt, ok := manifestTable["created_at"].(float64); ok {
+			table.CreatedAt = time.Unix(0, int64(createdAt*float64(time.Second))).UTC()
+		}
+	}
+	table.UnitTests = manifest.UnitTests[table.UniqueID]
+	table.ModelTests = manifest.ModelTests[table.UniqueID]
+	table.TestTypes = manifest.TestTypes[table.UniqueID]
+	table.TestPackages = manifest.TestPackages[table.UniqueID]
+	table.DeclaredTests = manifest.DeclaredTests[table.UniqueID]
+	manifestTableTests := manifest.Tests[table.UniqueID]
+	mapped := make(map[string]bool)
+	for colName, col := range table.Columns {
+		colInfo := lookupColumnInfo(manifestColumns, colName, manifest.Settings.Naming)
+		var desc interface{}
+		if colInfo != nil {
+			desc = colInfo["description"]
+			col.Description, _ = desc.(string)
+			col.Meta = mergedMeta(colInfo)
+			col.Tags = stringList(colInfo["tags"])
+		}
+		col.Doc = IsValidDoc(desc)
+		testsForCol := lookupColumnTests(manifestTableTests, colName, manifest.Settings.Naming)
+		col.Test = IsValidTest(testsForCol)
+		col.TestNames = testMacroNames(testsForCol)
+		table.Columns[colName] = col
+		for _, t := range testsForCol {
+			node, _ := t.(map[string]interface{})
+			testID, _ := node["unique_id"].(string)
+			mapped[testID] = true
+		}
+	}
+	for _, colName := range sortedKeys(manifestTableTests) {
+		for _, t := range manifestTableTests[colName] {
+			node, _ := t.(map[string]interface{})
+			if testID, _ := node["unique_id"].(string); !mapped[testID] {
+				manifest.Warnings.add(WarningUnmappedTest, testID, "test %s targets column %s, not found in %s", testID, colName, table.UniqueID)
+			}
+		}
+	}
+	return table
+}
+
+func testMacroNames(tests []interface{}) []string {
+	var names []string
+	for _, t := range tests {
+		node, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		testMeta, ok := node["test_metadata"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name := testMacroName(testMeta); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func testMacroName(testMeta map[string]interface{}) string {
+	name, _ := testMeta["name"].(string)
+	if namespace, _ := testMeta["namespace"].(string); namespace != "" {
+		name = namespace + "." + name
+	}
+	return name
+}
+
+func relationshipParent(node, testMeta map[string]interface{}, childID string) (string, string) {
+	kwargs, _ := testMeta["kwargs"].(map[string]interface{})
+	field, _ := kwargs["field"].(string)
+	if field == "" {
+		return "", ""
+	}
+	dependsOn, _ := node["depends_on"].(map[string]interface{})
+	for _, dep := range stringList(dependsOn["nodes"]) {
+		if dep != childID {
+			return dep, field
+		}
+	}
+	return "", ""
+}
+
+func mergedMeta(node map[string]interface{}) map[string]interface{} {
+	meta := make(map[string]interface{})
+	if config, ok := node["config"].(map[string]interface{}); ok {
+		if m, ok := config["meta"].(map[string]interface{}); ok {
+			for k, v := range m {
+				meta[k] = v
+			}
+		}
+	}
+	if m, ok := node["meta"].(map[string]interface{}); ok {
+		for k, v := range m {
+			meta[k] = v
+		}
+	}
+	return meta
+}
+
+func stringList(v interface{}) []string {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 func writeCoverageReport(report JSONReport, path string) error {
-	data, err := json.MarshalIndent(report, "", "  ")
+	return writeReport(report, ReportFormatJSON, path)
+}
+
+type Options struct {
+	ProjectDir        string
+	RunArtifactsDir   string
+	Archive           *artifactArchive
+	Parse             ParseSettings
+	Output            string
+	Format            string
+	ExtraOutputs      []ReportOutput
+	HTMLOutput        string
+	PageSize          int
+	Page              int
+	HistoryDir        string
+	Labels            map[string]string
+	CovType           CoverageType
+	ModelPathFilter   []string
+	ResourceTypes     []string
+	Since             time.Time
+	CreatedAt         string
+	GroupBy           GroupBy
+	ExternalSources   ExternalSources
+	Renderer          CoverageRenderer
+	Benchmark         bool
+	PhaseBudgets      map[string]time.Duration
+	Telemetry         *telemetry
+	Stdout            io.Writer
+	QualityWeights    map[string]float64
+	FailUnder         float64
+	FailUnderModel    float64
+	MaxUncovered      *int // nil when disabled
+	MaxUncoveredModel *int
+	ModelSelector     Selector
+	Budgets           []Budget
+	Exemptions        []Exemption
+	Debt              *DebtConfig
+	FreshnessSLA      *FreshnessSLA
+	ChangedFiles      ChangedFiles
+	Severities        *SeverityConfig
+	Baseline          string
+	FailOnWarning     bool
+}
+
+func (opts Options) stdout() io.Writer {
+	if opts.Stdout == nil {
+		return os.Stdout
+	}
+	return opts.Stdout
+}
+
+// loadFiles loads the artifacts of the run, from --artifacts_archive when set.
+func (opts Options) loadFiles(ctx context.Context, withCatalog bool) (Catalog, error) {
+	if opts.Archive != nil {
+		return opts.Archive.load(ctx, withCatalog, opts.Parse)
+	}
+	return loadFiles(ctx, opts.ProjectDir, opts.RunArtifactsDir, withCatalog, opts.Parse)
+}
+
+func currentGitSHA(ctx context.Context, projectDir string) string {
+	for _, key := range []string{"GITHUB_SHA", "CI_COMMIT_SHA", "BITBUCKET_COMMIT", "BUILD_SOURCEVERSION", "BUILDKITE_COMMIT", "GIT_COMMIT"} {
+		if sha := os.Getenv(key); sha != "" {
+			return sha
+		}
+	}
+	out, err := exec.CommandContext(ctx, "git", "-C", projectDir, "rev-parse", "HEAD").Output()
 	if err != nil {
-		return err
+		return ""
 	}
-	log.Printf("Writing report into %s", path)
-	return os.WriteFile(path, data, 0644)
+	return strings.TrimSpace(string(out))
 }
 
-func doCompute(projectDir, runArtifactsDir, output string, covType CoverageType, modelPathFilter []string) error {
-	catalog, err := loadFiles(projectDir, runArtifactsDir)
+func writeHTMLReport(report JSONReport, history []HistoryEntry, path string) error {
+	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
-	if len(modelPathFilter) > 0 {
-		catalog = catalog.FilterTables(modelPathFilter)
+	log.Printf("Writing HTML report into %s", path)
+	if err := renderHTMLReport(f, report, history); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func doCompute(ctx context.Context, opts Options) (JSONReport, []RunFailure, error) {
+	timings := newPhaseTimings()
+	report, failures, err := computeReport(ctx, opts, timings)
+	// Failed and timed out runs are traced too, with the phases they completed.
+	opts.Telemetry.exportRun(ctx, timings, opts.CovType, report, err)
+	return report, failures, err
+}
+
+func computeReport(ctx context.Context, opts Options, timings *phaseTimings) (JSONReport, []RunFailure, error) {
+	covTypes := []CoverageType{opts.CovType}
+	for component := range opts.QualityWeights {
+		covTypes = append(covTypes, CoverageType(component))
+	}
+	catalog, err := opts.loadFiles(ctx, catalogRequired(covTypes...))
+	if err != nil {
+		if ctx.Err() != nil && len(catalog.Tables) > 0 {
+			printPartialSummary(opts.stdout(), catalog)
+		}
+		return JSONReport{}, nil, err
+	}
+	if len(opts.ModelPathFilter) > 0 {
+		catalog = catalog.FilterTables(opts.ModelPathFilter)
 		if len(catalog.Tables) == 0 {
-			return errors.New("no table after applying the filter, please check the `path_filter` value")
+			return JSONReport{}, nil, errors.New("no table after applying the filter, please check the `path_filter` value")
 		}
 	}
+	if len(opts.ResourceTypes) > 0 {
+		catalog = catalog.FilterResourceTypes(opts.ResourceTypes)
+		if len(catalog.Tables) == 0 {
+			return JSONReport{}, nil, errors.New("no table after applying the filter, please check the `resource_types` value")
+		}
+	}
+	if !opts.Since.IsZero() {
+		dates, err := creationDates(ctx, opts.CreatedAt, opts.ProjectDir, catalog)
+		if err != nil {
+			return JSONReport{}, nil, err
+		}
+		catalog = catalog.FilterCreatedSince(dates, opts.Since)
+		if len(catalog.Tables) == 0 {
+			return JSONReport{}, nil, fmt.Errorf("no table created since %s according to %s", opts.Since.Format(time.DateOnly), opts.CreatedAt)
+		}
+	}
+	catalog, exemptions := catalog.ApplyExemptions(opts.Exemptions, time.Now())
+	var external Catalog
+	switch opts.ExternalSources {
+	case ExternalExclude:
+		catalog, _ = catalog.SplitExternal()
+	case ExternalSeparate:
+		catalog, external = catalog.SplitExternal()
+	}
+	timings.done("load")
 
-	detailedReport := computeDetailedCoverage(catalog, covType)
-	printDetailedCoverageReport(detailedReport)
+	if err := evaluateCoverage(ctx, external, opts.CovType); err != nil {
+		return JSONReport{}, nil, err
+	}
+	if err := evaluateCoverage(ctx, catalog, opts.CovType); err != nil {
+		if ctx.Err() != nil {
+			printPartialSummary(opts.stdout(), catalog)
+		}
+		return JSONReport{}, nil, err
+	}
 
-	jsonReport := computeJSONReport(catalog, covType)
-	if err := writeCoverageReport(jsonReport, output); err != nil {
-		return err
+	if len(opts.QualityWeights) > 0 {
+		if err := computeQualityScores(ctx, catalog, opts.QualityWeights); err != nil {
+			return JSONReport{}, nil, err
+		}
+	}
+	timings.done("evaluate")
+
+	if opts.streamsJSONL() {
+		report, err := streamJSONLReport(ctx, opts, catalog, exemptions)
+		if err != nil {
+			return JSONReport{}, nil, err
+		}
+		timings.done("report")
+		failures, err := checkRunPhases(opts, report, catalog, timings)
+		return report, failures, err
+	}
+
+	warnings := catalog.Warnings.sorted()
+	detailedReport := computeDetailedCoverage(catalog, opts.CovType, opts.GroupBy)
+	detailedReport.Warnings = warnings
+	var page Page
+	if opts.PageSize > 0 {
+		if page, err = paginateDetailedReport(&detailedReport, opts.PageSize, max(opts.Page, 1)); err != nil {
+			return JSONReport{}, nil, err
+		}
+	}
+	if err := printDetailedCoverageReport(opts.stdout(), detailedReport, opts.Renderer); err != nil {
+		return JSONReport{}, nil, err
+	}
+	if opts.PageSize > 0 {
+		printPage(opts.stdout(), page)
+	}
+	printExternalSources(opts.stdout(), computeDetailedCoverage(external, opts.CovType, GroupByNone), modelNameWidth(false, false))
+
+	jsonReport := computeJSONReport(catalog, opts.CovType, opts.GroupBy)
+	jsonReport.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+	jsonReport.GitSHA = currentGitSHA(ctx, opts.ProjectDir)
+	if len(opts.Labels) > 0 {
+		jsonReport.Labels = opts.Labels
+	}
+	jsonReport.Warnings = warnings
+	jsonReport.External = computeJSONReport(external, opts.CovType, GroupByNone).Tables
+	resolveSchemaLines(&jsonReport, opts.ProjectDir, opts.Parse.Naming)
+	if opts.Severities != nil {
+		applySeverities(&jsonReport, *opts.Severities)
+	}
+	jsonReport.Budgets = budgetProgress(opts.Budgets, jsonReport.Tables, time.Now())
+	jsonReport.Exemptions = exemptions
+	printBudgets(opts.stdout(), jsonReport.Budgets)
+	printExemptions(opts.stdout(), jsonReport.Exemptions)
+	if opts.Debt != nil {
+		jsonReport.Debt = computeDebt(jsonReport, *opts.Debt)
+		printDebt(opts.stdout(), jsonReport.Debt)
+	}
+	printSeverities(opts.stdout(), jsonReport)
+	printPersistDocs(opts.stdout(), jsonReport.Tables)
+	if opts.FreshnessSLA != nil {
+		jsonReport.Freshness = computeFreshness(*opts.FreshnessSLA, catalog, external)
+		printFreshness(opts.stdout(), jsonReport.Freshness)
+	}
+	if err := ctx.Err(); err != nil {
+		return JSONReport{}, nil, err
+	}
+	var jobs []renderJob
+	for _, output := range append([]ReportOutput{{Format: opts.Format, Path: opts.Output}}, opts.ExtraOutputs...) {
+		jobs = append(jobs, outputJob(ctx, output, jsonReport, catalog, opts.CovType, opts.PageSize))
+	}
+	if opts.HTMLOutput != "" {
+		var history []HistoryEntry
+		if opts.HistoryDir != "" {
+			if history, err = loadHistory(opts.HistoryDir, jsonReport.CovType); err != nil {
+				return JSONReport{}, nil, err
+			}
+			history = filterHistoryByLabels(history, opts.Labels)
+			// The report is stored once rendered, the trend already ends
+			// with it.
+			generatedAt, _ := time.Parse(time.RFC3339, jsonReport.GeneratedAt)
+			history = append(history, HistoryEntry{GeneratedAt: generatedAt, Report: jsonReport})
+		}
+		jobs = append(jobs, renderJob{name: "HTML report " + opts.HTMLOutput, run: func() error {
+			if opts.PageSize > 0 {
+				return writeHTMLPages(jsonReport, history, opts.HTMLOutput, opts.PageSize)
+			}
+			return writeHTMLReport(jsonReport, history, opts.HTMLOutput)
+		}})
+	}
+	if err := runRenderJobs(ctx, jobs); err != nil {
+		return JSONReport{}, nil, err
+	}
+	if opts.HistoryDir != "" {
+		path, err := saveToHistory(opts.HistoryDir, jsonReport)
+		if err != nil {
+			return JSONReport{}, nil, err
+		}
+		log.Printf("Report stored in the history: %s", path)
+	}
+	timings.done("report")
+	if opts.Benchmark {
+		timings.print(opts.stdout(), detailedReport.TableCount, detailedReport.TotalColumns)
+	}
+	if opts.ChangedFiles != nil {
+		fmt.Fprintf(opts.stdout(), "\n🔀 Thresholds applied to the %d changed models out of %d, the others are informational\n",
+			len(gatedReport(jsonReport, opts.ChangedFiles).Tables), len(jsonReport.Tables))
+	}
+	failures, err := checkRunPhases(opts, jsonReport, catalog, timings)
+	return jsonReport, failures, err
+}
+
+// checkRunPhases adds the phases over their --benchmark_budget to the checks
+// of the report.
+func checkRunPhases(opts Options, report JSONReport, catalog Catalog, timings *phaseTimings) ([]RunFailure, error) {
+	failures, err := checkRun(opts, report, catalog)
+	if over := timings.overBudget(opts.PhaseBudgets); len(over) > 0 {
+		failures = append(failures, RunFailure{
+			Class:   FailureBenchmarkBudget,
+			Message: "over the benchmark budget: " + strings.Join(over, ", "),
+		})
+	}
+	return failures, err
+}
+
+func checkRun(opts Options, report JSONReport, catalog Catalog) ([]RunFailure, error) {
+	var failures []RunFailure
+	// With --changed_files, the thresholds only look at the changed models;
+	// the other checks still cover the whole project.
+	gated := gatedReport(report, opts.ChangedFiles)
+	if opts.ChangedFiles != nil && len(gated.Tables) == 0 {
+		// No model changed, --fail_under has nothing to gate.
+		gated.Coverage = 1
+	}
+	if opts.FailUnder > 0 && gated.Coverage*100 < opts.FailUnder {
+		failures = append(failures, RunFailure{
+			Class:   FailureBelowThreshold,
+			Message: fmt.Sprintf("coverage %s is below the threshold %.1f%%", formatCoverage(gated.Covered, gated.Total), opts.FailUnder),
+		})
+	}
+	if opts.FailUnderModel > 0 {
+		for _, t := range gated.Tables {
+			if t.Coverage*100 < opts.FailUnderModel && opts.ModelSelector.Matches(t) {
+				failures = append(failures, RunFailure{
+					Class: FailureBelowThreshold,
+					Message: fmt.Sprintf("%s coverage %s (%d/%d) is below the per-model threshold %.1f%%",
+						t.Name, formatCoverage(t.Covered, t.Total), t.Covered, t.Total, opts.FailUnderModel),
+				})
+			}
+		}
+	}
+	if uncovered := gated.Total - gated.Covered; opts.MaxUncovered != nil && uncovered > *opts.MaxUncovered {
+		failures = append(failures, RunFailure{
+			Class:   FailureBelowThreshold,
+			Message: fmt.Sprintf("%d uncovered columns, more than the %d allowed", uncovered, *opts.MaxUncovered),
+		})
+	}
+	if opts.MaxUncoveredModel != nil {
+		for _, t := range gated.Tables {
+			if uncovered := t.Total - t.Covered; uncovered > *opts.MaxUncoveredModel && opts.ModelSelector.Matches(t) {
+				failures = append(failures, RunFailure{
+					Class:   FailureBelowThreshold,
+					Message: fmt.Sprintf("%s has %d uncovered columns, more than the %d allowed per model", t.Name, uncovered, *opts.MaxUncoveredModel),
+				})
+			}
+		}
+	}
+	if gaps := severityGaps(gated.Tables, SeverityError); len(gaps) > 0 {
+		failures = append(failures, RunFailure{
+			Class:   FailureSeverityError,
+			Message: fmt.Sprintf("%d gaps of severity error: %s", len(gaps), strings.Join(gaps, ", ")),
+		})
+	}
+	for _, b := range report.Budgets {
+		if b.Overdue {
+			failures = append(failures, RunFailure{
+				Class: FailureBelowThreshold,
+				Message: fmt.Sprintf("%s coverage %s is below its %.1f%% budget, due %s",
+					b.Path, formatCoverage(b.Covered, b.Total), b.Target, b.Deadline),
+			})
+		}
+	}
+	for _, e := range report.Exemptions {
+		if e.Expired {
+			failures = append(failures, RunFailure{
+				Class:   FailureExpiredExemption,
+				Message: fmt.Sprintf("exemption of %s expired on %s (%s)", strings.TrimSuffix(e.Model+"."+e.Column, "."), e.Until, e.Reason),
+			})
+		}
+	}
+	if opts.Baseline != "" {
+		baseline, err := readJSONReport(opts.Baseline)
+		if err != nil {
+			return nil, err
+		}
+		if report.Coverage < baseline.Coverage {
+			failures = append(failures, RunFailure{
+				Class: FailureRegression,
+				Message: fmt.Sprintf("coverage regressed from %s to %s compared to %s",
+					formatCoverage(baseline.Covered, baseline.Total), formatCoverage(report.Covered, report.Total), opts.Baseline),
+			})
+		}
+	}
+	if sources := criticalFreshnessFailures(report.Freshness); len(sources) > 0 {
+		failures = append(failures, RunFailure{
+			Class:   FailureFreshnessSLA,
+			Message: fmt.Sprintf("%d critical sources exceed the freshness SLA: %s", len(sources), strings.Join(sources, ", ")),
+		})
+	}
+	if catalog.Stale() {
+		failures = append(failures, RunFailure{
+			Class:   FailureStaleCatalog,
+			Message: "catalog.json is older than manifest.json",
+		})
+	}
+	if warnings := catalog.Warnings.sorted(); len(warnings) > 0 {
+		failures = append(failures, RunFailure{
+			Class:   FailureParseWarnings,
+			Message: fmt.Sprintf("%d warnings raised while parsing the artifacts", len(warnings)),
+		})
+	}
+	if opts.FailOnWarning && len(report.Unattributed) > 0 {
+		failures = append(failures, RunFailure{
+			Class:   FailureParseWarnings,
+			Message: fmt.Sprintf("%d tests not attributed to any column", len(report.Unattributed)),
+		})
+	}
+	return failures, nil
+}
+func setupLogging(verbose bool) {
+	if verbose {
+		log.SetFlags(log.LstdFlags)
+	} else {
+		log.SetOutput(io.Discard)
+	}
+}
+
+type commonFlags struct {
+	verbose *bool
+	timeout *time.Duration
+}
+
+func newFlagSet(name string) (*flag.FlagSet, commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return fs, commonFlags{
+		verbose: fs.Bool("verbose", false, "Enable verbose logging"),
+		timeout: fs.Duration("timeout", 0, "Abort the run after this duration (e.g. 5m, disabled when 0)"),
 	}
-	return nil
+}
+
+func (c commonFlags) setup(ctx context.Context) (context.Context, context.CancelFunc) {
+	setupLogging(*c.verbose)
+	return withTimeout(ctx, *c.timeout)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func runError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("run timed out: %w", err)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("run interrupted: %w", err)
+	}
+	return err
+}
+
+// limitFlag reads a limit flag, disabled (nil) when negative.
+func limitFlag(v *int) *int {
+	if *v < 0 {
+		return nil
+	}
+	return v
+}
+
+var subcommands = map[string]func(ctx context.Context, args []string) error{
+	"annotate-docs": runAnnotateDocs,
+	"explain":       runExplain,
+	"gen-fixture":   runGenFixture,
+	"history":       runHistory,
+	"list":          runList,
+	"lsp":           runLSP,
+	"meta-matrix":   runMetaMatrix,
+	"open":          runOpen,
+	"publish":       runPublish,
+	"self-update":   runSelfUpdate,
+	"serve":         runServe,
+	"site":          runSite,
+	"tui":           runTUI,
+	"version":       runVersion,
+	"watch":         runWatch,
 }
 
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string) int {
+	if len(args) > 0 {
+		if subcommand, ok := subcommands[args[0]]; ok {
+			if err := subcommand(ctx, args[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", runError(ctx, err))
+				return 1
+			}
+			return 0
+		}
+	}
+
 	var (
 		projectDir      = flag.String("dbt_dir", ".", "dbt project path")
-		runArtifactsDir = flag.String("target_dir", "target", "dbt target path")
-		output          = flag.String("output", "coverage.json", "Output filename (JSON)")
-		covTypeStr      = flag.String("type", "test", "Coverage type (doc ou test)")
+		runArtifactsDir = flag.String("target_dir", "target", "dbt target path, or the http(s) URL of a directory serving the artifacts")
+		cacheDir        = flag.String("cache_dir", defaultArtifactCacheDir(), "Cache of the artifacts downloaded from a --target_dir URL, revalidated with conditional requests")
+		archivePath     = flag.String("artifacts_archive", "", "Read manifest.json and catalog.json from this .zip, .tar, .tar.gz or .tgz archive instead of --target_dir")
+		output          = flag.String("output", "coverage.json", "Output filename (coverage.pdf, .csv, .md or .html for the pdf, dbt-project-evaluator, markdown and html formats), - for stdout")
+		format          = flag.String("format", ReportFormatJSON, "Output format: json (whole report), jsonl (one record per column, encoded table by table once evaluated, without building the report), pdf (printable executive summary), markdown, html, dbt-score (JSON of dbt-score), dbt-project-evaluator (CSV row of fct_documentation_coverage or fct_test_coverage), sarif (one result per gap, for code scanning), rdjson (reviewdog diagnostics) or a compiled-in renderer; several formats split using ',' are rendered concurrently, the first one into --output and the others into coverage.<format> unless given as format=path")
+		htmlOutput      = flag.String("html_output", "", "Also write an HTML report to this file")
+		historyDir      = flag.String("history_dir", "", "Directory of past JSON reports; the current report is stored there and trends are added to the HTML report")
+		covTypeStr      = flag.String("type", "test", "Coverage type (doc, test, accepted_values, relationships, description, contract, unit_test, model_test, monitoring, freshness, meta:<key> or a plugin name)")
 		modelFilter     = flag.String("path_filter", "", "Path filter to select the models (split using ',')")
+		externalStr     = flag.String("external_sources", string(ExternalInclude), "External sources (dbt-external-tables): include, exclude, or separate to report them apart from the totals")
+		groupByStr      = flag.String("group_by", "", "Report subtotals per group: package, folder, owner or database (database.schema of the relations)")
+		fullNames       = flag.Bool("full_names", false, "Never truncate long model names to fit the terminal width")
+		pageSize        = flag.Int("page_size", 0, "Print the models of the console report by pages of this size and split the HTML report into linked files of this size (disabled when 0)")
+		pageNumber      = flag.Int("page", 1, "Page of the console report printed with --page_size")
+		heatmap         = flag.Bool("heatmap", false, "Print a compact heatmap (one colored cell per model, grouped by folder) instead of the table")
+		relParent       = flag.Bool("relationships_credit_parent", false, "Also credit relationships tests to the referenced key column (field) of the parent table")
+		includeDisabled = flag.Bool("include_disabled", false, "Analyze the disabled nodes of the manifest too (they are excluded and listed by default)")
+		presets         = flag.String("preset", "", "Exclude the system columns of loaders and warehouses: "+strings.Join(presetNames(), ", ")+" (split using ',')")
+		includeSnapMeta = flag.Bool("include_snapshot_meta_columns", false, "Count the snapshot metadata columns (dbt_scd_id, dbt_valid_from...), excluded by default")
+		resourceTypes   = flag.String("resource_types", "", "Resource types to analyze: model, source, seed, snapshot (split using ',')")
+		sinceStr        = flag.String("since", "", "Only analyze the models created on or after this date (YYYY-MM-DD), legacy models are grandfathered")
+		createdAtSource = flag.String("created_at", CreatedAtManifest, "Creation date of the models for --since: manifest (created_at of the nodes), git (commit adding the file) or a YAML file mapping unique_ids, paths or names to dates")
+		ownersPath      = flag.String("owners", "", "CODEOWNERS-style file assigning path patterns to teams, for --group_by owner and owner: selectors (overrides owners_file)")
+		configPath      = flag.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		nameFormat      = flag.String("name_format", DefaultNameFormat, "Model display name template: {{database}}, {{schema}}, {{identifier}} (alias or identifier when set), {{name}}, {{alias}}, {{package}}, {{source}}")
+		caseSensitive   = flag.Bool("case_sensitive", false, "Match column names case-sensitively (quoted identifiers such as \"CamelCase\")")
+		qualityScore    = flag.Bool("quality_score", false, "Report a weighted quality score per model (weights from quality_score in the configuration)")
+		failUnder       = flag.Float64("fail_under", 0, "Fail when the global coverage (%) is below this value")
+		failUnderModel  = flag.Float64("fail_under_per_model", 0, "Fail when the coverage (%) of any model is below this value")
+		maxUncovered    = flag.Int("max_uncovered", -1, "Fail when more columns than this are uncovered (disabled when negative)")
+		maxUncoveredPer = flag.Int("max_uncovered_per_model", -1, "Fail when a model has more uncovered columns than this (disabled when negative)")
+		perModelSelect  = flag.String("per_model_select", "", "Models checked by the per-model thresholds: name globs or path:, package:, resource_type:, tag: selectors (split using ',')")
+		budgetsPath     = flag.String("budgets", "", "Budgets file (YAML) with coverage targets per directory and optional deadlines")
+		baseline        = flag.String("baseline", "", "Previous coverage report (JSON) used to detect regressions")
+		locale          = flag.String("locale", "", "Locale of the percentages: "+strings.Join(sortedKeys(percentLocales), ", ")+" (overrides number_format.locale)")
+		precision       = flag.Int("precision", -1, "Decimals of the percentages (overrides number_format.precision, 1 by default)")
+		jsonScale       = flag.Float64("json_scale", 0, "Scale of the coverage values of the JSON report: 1 (0–1, default) or 100 (0–100)")
+		changedFiles    = flag.String("changed_files", "", "File listing the changed files, one per line (git diff --name-only, - for stdin): the thresholds only apply to the models whose .sql or .yml changed")
+		preCommit       = flag.Bool("pre_commit", false, "pre-commit hook mode: analyze the models of the files given as arguments from manifest.json alone, print their gaps as file:line: messages and fail unless fully covered (or the given thresholds are met)")
+		debt            = flag.Bool("debt", false, "Estimate the remediation effort of the uncovered columns (coverage_debt minutes per column), per folder or group")
+		freshness       = flag.Bool("freshness", false, "Report the freshness warn_after/error_after of each source against the freshness_sla of the configuration (implied when freshness_sla is configured)")
+		failOnWarning   = flag.Bool("fail_on_warning", false, "Fail on any parse warning or test not attributed to any column (exit code of parse_warnings, 1 unless configured)")
+		dryRunFlag      = flag.Bool("dry_run", false, "Print the resolved configuration, artifacts, filters and thresholds, and how many models would be analyzed, without computing")
+		benchmark       = flag.Bool("benchmark", false, "Print the duration and throughput (nodes/s) of each phase")
+		benchmarkBudget = flag.String("benchmark_budget", "", "Fail when a phase takes longer than its budget, phase=duration among load, evaluate, report and total (split using ',', e.g. evaluate=30s,total=1m; implies --benchmark)")
+		otelEndpoint    = flag.String("otel_endpoint", "", "OTLP/HTTP collector receiving the traces and coverage gauges (defaults to $OTEL_EXPORTER_OTLP_ENDPOINT)")
+		timeout         = flag.Duration("timeout", 0, "Abort the run after this duration (e.g. 5m, disabled when 0)")
 		verbose         = flag.Bool("verbose", false, "Enable verbose logging")
+		about           = flag.Bool("about", false, "Print the build information and capabilities of the binary as JSON (no file read, no network access) and exit")
+		labels          = make(labelsFlag)
 	)
-	flag.Parse()
+	flag.Var(labels, "label", "Label of the report, key=value, repeatable (e.g. --label env=prod --label release=2024.06); stored with the report and selecting the history of the HTML trends")
+	flag.CommandLine.Parse(args)
+	if *about {
+		if err := writeAbout(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return defaultExitCodes[FailureError]
+		}
+		return 0
+	}
+	setupLogging(*verbose)
+	ctx, cancel := withTimeout(ctx, *timeout)
+	defer cancel()
 
-	if *verbose {
-		log.SetFlags(log.LstdFlags)
-	} else {
-		log.SetOutput(io.Discard)
+	var archive *artifactArchive
+	if *archivePath != "" {
+		var err error
+		archive, err = readArtifactArchive(*archivePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading the artifacts archive: %v\n", err)
+			return defaultExitCodes[FailureError]
+		}
+	} else if isRemoteTarget(*runArtifactsDir) {
+		cache := newArtifactCache(*cacheDir, *runArtifactsDir)
+		if _, err := cache.FetchAll(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "error downloading the artifacts: %v\n", runError(ctx, err))
+			return defaultExitCodes[FailureError]
+		}
+		*runArtifactsDir = cache.dir
+	}
+	cfg, err := loadConfig(*configPath, *projectDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error loading the configuration: %v\n", err)
+		return defaultExitCodes[FailureError]
+	}
+	cfg.Presets = append(cfg.Presets, splitList(*presets)...)
+	if *failOnWarning {
+		cfg.failOnWarnings()
+	}
+	if *ownersPath != "" {
+		cfg.OwnersFile = *ownersPath
+	}
+	if *locale != "" {
+		cfg.NumberFormat.Locale = *locale
+	}
+	if *precision >= 0 {
+		cfg.NumberFormat.Precision = precision
 	}
+	if *jsonScale != 0 {
+		cfg.NumberFormat.JSONScale = *jsonScale
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+	if *caseSensitive {
+		settings.Naming.CaseSensitive = true
+	}
+	if err := ValidateNameFormat(*nameFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+	settings.NameFormat = *nameFormat
+	settings.IncludeDisabled = *includeDisabled
+	settings.IncludeSnapshotMetaColumns = *includeSnapMeta
+	settings.CreditRelationshipParent = *relParent
 
-	covType := CoverageType(*covTypeStr)
 	var filters []string
 	if *modelFilter != "" {
 		filters = strings.Split(*modelFilter, ",")
 	}
 
-	if err := doCompute(*projectDir, *runArtifactsDir, *output, covType, filters); err != nil {
-		log.Fatalf("error computing the coverage value: %v", err)
+	types, err := ParseResourceTypes(splitList(*resourceTypes))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+	phaseBudgets, err := parsePhaseBudgets(splitList(*benchmarkBudget))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+	var since time.Time
+	if *sinceStr != "" {
+		if since, err = time.Parse(time.DateOnly, *sinceStr); err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid --since %q, expected YYYY-MM-DD\n", *sinceStr)
+			return cfg.ExitCode(FailureError)
+		}
+	}
+	if *pageSize < 0 || *pageNumber < 1 {
+		fmt.Fprintf(os.Stderr, "error: --page_size must be positive and --page start at 1\n")
+		return cfg.ExitCode(FailureError)
+	}
+
+	defer closeCoverageProviders()
+	registerHeuristicProviders(cfg.Heuristics)
+	if err := registerPlugins(cfg.Plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+	covType := CoverageType(*covTypeStr)
+	if _, err := lookupCoverageProvider(covType); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+
+	outputs, err := parseReportOutputs(*format, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+	var stdout io.Writer
+	for _, o := range outputs {
+		if o.Path == "-" {
+			stdout = os.Stderr
+		}
+	}
+
+	modelSelector, err := ParseSelector(splitList(*perModelSelect))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+
+	groupBy, err := ParseGroupBy(*groupByStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+	externalSources, err := ParseExternalSources(*externalStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return cfg.ExitCode(FailureError)
+	}
+
+	var budgets []Budget
+	if *budgetsPath != "" {
+		if budgets, err = loadBudgets(*budgetsPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return cfg.ExitCode(FailureError)
+		}
+	}
+
+	qualityWeights := cfg.QualityScore.Weights
+	if *qualityScore && len(qualityWeights) == 0 {
+		qualityWeights = defaultQualityWeights
+	}
+	for component := range qualityWeights {
+		if _, err := lookupCoverageProvider(CoverageType(component)); err != nil {
+			fmt.Fprintf(os.Stderr, "error: quality_score: %v\n", err)
+			return cfg.ExitCode(FailureError)
+		}
+	}
+
+	opts := Options{
+		ProjectDir:        *projectDir,
+		RunArtifactsDir:   *runArtifactsDir,
+		Archive:           archive,
+		Parse:             settings,
+		Output:            outputs[0].Path,
+		Format:            outputs[0].Format,
+		ExtraOutputs:      outputs[1:],
+		HTMLOutput:        *htmlOutput,
+		PageSize:          *pageSize,
+		Page:              *pageNumber,
+		HistoryDir:        *historyDir,
+		Labels:            labels,
+		CovType:           covType,
+		ModelPathFilter:   filters,
+		ResourceTypes:     types,
+		Since:             since,
+		CreatedAt:         *createdAtSource,
+		GroupBy:           groupBy,
+		ExternalSources:   externalSources,
+		Renderer:          newConsoleRenderer(*heatmap, *fullNames),
+		Benchmark:         *benchmark || len(phaseBudgets) > 0,
+		PhaseBudgets:      phaseBudgets,
+		Telemetry:         newTelemetry(*otelEndpoint),
+		Stdout:            stdout,
+		QualityWeights:    qualityWeights,
+		FailUnder:         *failUnder,
+		FailUnderModel:    *failUnderModel,
+		MaxUncovered:      limitFlag(maxUncovered),
+		MaxUncoveredModel: limitFlag(maxUncoveredPer),
+		ModelSelector:     modelSelector,
+		Budgets:           budgets,
+		Exemptions:        cfg.Exemptions,
+		Baseline:          *baseline,
+		FailOnWarning:     *failOnWarning,
+	}
+	if *debt {
+		opts.Debt = &cfg.Debt
+	}
+	if cfg.Severities.enabled() {
+		opts.Severities = &cfg.Severities
+	}
+	if *freshness || cfg.FreshnessSLA.enabled() {
+		opts.FreshnessSLA = &cfg.FreshnessSLA
+	}
+	if *changedFiles != "" {
+		if opts.ChangedFiles, err = loadChangedFiles(*changedFiles); err != nil {
+			fmt.Fprintf(os.Stderr, "error loading the changed files: %v\n", err)
+			return cfg.ExitCode(FailureError)
+		}
+	}
+	if *preCommit {
+		if *failUnder == 0 && *failUnderModel == 0 && *maxUncovered < 0 && *maxUncoveredPer < 0 {
+			opts.FailUnderModel = 100
+		}
+		failures, err := preCommitCheck(ctx, os.Stdout, opts, flag.Args())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", runError(ctx, err))
+			return cfg.ExitCode(FailureError)
+		}
+		code, failures := exitCodeFor(cfg, failures)
+		for _, f := range failures {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", f.Class, f.Message)
+		}
+		return code
+	}
+	if *dryRunFlag {
+		if err := dryRun(ctx, os.Stdout, opts, *configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", runError(ctx, err))
+			return cfg.ExitCode(FailureError)
+		}
+		return 0
+	}
+	report, failures, err := doCompute(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error computing the coverage value: %v\n", runError(ctx, err))
+		// The scrapers still find the line of a failed run.
+		printSummaryLine(opts.stdout(), JSONReport{CovType: string(opts.CovType)}, opts.FailUnder, false)
+		return cfg.ExitCode(FailureError)
+	}
+	code, failures := exitCodeFor(cfg, failures)
+	for _, f := range failures {
+		fmt.Fprintf(os.Stderr, "%s: %s (exit code %d)\n", f.Class, f.Message, cfg.ExitCode(f.Class))
 	}
+	printSummaryLine(opts.stdout(), report, opts.FailUnder, code == 0)
+	return code
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// MetaMatrixConfig is the meta_matrix section of the configuration: the meta
+// keys every column must carry, e.g. owner, pii and source_system.
+type MetaMatrixConfig struct {
+	RequiredKeys []string `yaml:"required_keys"`
+}
+
+func (c MetaMatrixConfig) validate() error {
+	for _, key := range c.RequiredKeys {
+		if strings.TrimSpace(key) == "" {
+			return errors.New("meta_matrix required_keys cannot contain an empty key")
+		}
+	}
+	return nil
+}
+
+type MetaMatrixRow struct {
+	Model    string          `json:"model"`
+	UniqueID string          `json:"unique_id"`
+	Column   string          `json:"column"`
+	Keys     map[string]bool `json:"keys"`
+	Complete bool            `json:"complete"`
+}
+
+type MetaKeyTotal struct {
+	Key      string  `json:"key"`
+	Covered  int     `json:"covered"`
+	Total    int     `json:"total"`
+	Coverage float64 `json:"coverage"`
+}
+
+// MetaMatrix tells, for each column, which of the required meta keys it
+// carries, with the totals per key and the columns carrying all of them.
+type MetaMatrix struct {
+	Keys     []string        `json:"keys"`
+	Columns  []MetaMatrixRow `json:"columns"`
+	Totals   []MetaKeyTotal  `json:"totals"`
+	Complete int             `json:"complete"`
+	Total    int             `json:"total"`
+}
+
+func computeMetaMatrix(catalog Catalog, keys []string, selector Selector, incompleteOnly bool) MetaMatrix {
+	matrix := MetaMatrix{Keys: keys, Totals: make([]MetaKeyTotal, len(keys))}
+	for i, key := range keys {
+		matrix.Totals[i].Key = key
+	}
+	for _, id := range sortedKeys(catalog.Tables) {
+		table := catalog.Tables[id]
+		if !selector.Matches(selectorReport(table)) {
+			continue
+		}
+		for _, col := range table.SortedColumns() {
+			row := MetaMatrixRow{Model: table.Name, UniqueID: id, Column: col.Name, Keys: make(map[string]bool), Complete: true}
+			for i, key := range keys {
+				covered, _ := metaCoverageProvider{key: key}.Evaluate(context.Background(), table, col)
+				row.Keys[key] = covered
+				row.Complete = row.Complete && covered
+				matrix.Totals[i].Total++
+				if covered {
+					matrix.Totals[i].Covered++
+				}
+			}
+			matrix.Total++
+			if row.Complete {
+				matrix.Complete++
+			}
+			if !incompleteOnly || !row.Complete {
+				matrix.Columns = append(matrix.Columns, row)
+			}
+		}
+	}
+	for i := range matrix.Totals {
+		matrix.Totals[i].Coverage = ratio(matrix.Totals[i].Covered, matrix.Totals[i].Total)
+	}
+	return matrix
+}
+
+func writeMetaMatrixTable(w io.Writer, matrix MetaMatrix) {
+	table := tablewriter.NewWriter(w)
+	header := append([]string{"Model", "Column"}, matrix.Keys...)
+	table.SetHeader(append(header, "Complete"))
+	table.SetAutoFormatHeaders(false)
+	table.SetBorder(false)
+	table.SetCenterSeparator("│")
+	check := func(covered bool) string {
+		if covered {
+			return "✅"
+		}
+		return "❌"
+	}
+	for _, r := range matrix.Columns {
+		row := []string{r.Model, r.Column}
+		for _, key := range matrix.Keys {
+			row = append(row, check(r.Keys[key]))
+		}
+		table.Append(append(row, check(r.Complete)))
+	}
+	footer := []string{"TOTAL", fmt.Sprintf("%d columns", matrix.Total)}
+	for _, t := range matrix.Totals {
+		footer = append(footer, formatCoverage(t.Covered, t.Total))
+	}
+	table.SetFooter(append(footer, formatCoverage(matrix.Complete, matrix.Total)))
+	table.Render()
+}
+
+func writeMetaMatrixCSV(w io.Writer, matrix MetaMatrix) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append(append([]string{"model", "unique_id", "column"}, matrix.Keys...), "complete"))
+	for _, r := range matrix.Columns {
+		row := []string{r.Model, r.UniqueID, r.Column}
+		for _, key := range matrix.Keys {
+			row = append(row, fmt.Sprint(r.Keys[key]))
+		}
+		cw.Write(append(row, fmt.Sprint(r.Complete)))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func runMetaMatrix(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("meta-matrix")
+	var (
+		projectDir      = fs.String("dbt_dir", ".", "dbt project path")
+		runArtifactsDir = fs.String("target_dir", "target", "dbt target path")
+		configPath      = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		keysStr         = fs.String("keys", "", "Required meta keys, nested keys with dots (split using ','; defaults to meta_matrix.required_keys)")
+		selectStr       = fs.String("select", "", "Models to check: name globs or path:, package:, resource_type:, tag: selectors (split using ',')")
+		nameFormat      = fs.String("name_format", DefaultNameFormat, "Model display name template (see the main command)")
+		format          = fs.String("format", "table", "Output format: table, csv or json")
+		incomplete      = fs.Bool("incomplete", false, "Only list the columns missing a required key (the totals count every column)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	if !containsString([]string{"table", "csv", "json"}, *format) {
+		return fmt.Errorf("unknown format %q, expected one of: table, csv, json", *format)
+	}
+	selector, err := ParseSelector(splitList(*selectStr))
+	if err != nil {
+		return err
+	}
+	if err := ValidateNameFormat(*nameFormat); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath, *projectDir)
+	if err != nil {
+		return err
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		return err
+	}
+	settings.NameFormat = *nameFormat
+	keys := splitList(*keysStr)
+	if len(keys) == 0 {
+		keys = cfg.MetaMatrix.RequiredKeys
+	}
+	if len(keys) == 0 {
+		return errors.New("no required meta key, set --keys or meta_matrix.required_keys in the configuration")
+	}
+
+	catalog, err := loadFiles(ctx, *projectDir, *runArtifactsDir, true, settings)
+	if err != nil {
+		return err
+	}
+	matrix := computeMetaMatrix(catalog, keys, selector, *incomplete)
+	switch *format {
+	case "csv":
+		return writeMetaMatrixCSV(os.Stdout, matrix)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(matrix)
+	}
+	writeMetaMatrixTable(os.Stdout, matrix)
+	return nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ColumnNaming turns catalog and manifest column names into the keys used to
+// match them together.
+type ColumnNaming struct {
+	CaseSensitive  bool
+	KeepQuotes     bool
+	KeepWhitespace bool
+	Unicode        string
+	Replacements   []nameReplacement
+}
+
+type nameReplacement struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+var unicodeForms = map[string]norm.Form{
+	"nfc":  norm.NFC,
+	"nfd":  norm.NFD,
+	"nfkc": norm.NFKC,
+	"nfkd": norm.NFKD,
+}
+
+// adapterCaseSensitivity tells whether quoted identifiers keep their case on
+// each warehouse.
+var adapterCaseSensitivity = map[string]bool{
+	"bigquery":   false,
+	"databricks": false,
+	"duckdb":     false,
+	"postgres":   true,
+	"redshift":   false,
+	"snowflake":  true,
+}
+
+func (n ColumnNaming) Key(name string) string {
+	if !n.KeepWhitespace {
+		name = strings.TrimSpace(name)
+	}
+	if !n.KeepQuotes {
+		name, _ = unquoteIdentifier(name)
+	}
+	if form, ok := unicodeForms[n.Unicode]; ok {
+		name = form.String(name)
+	}
+	for _, r := range n.Replacements {
+		name = r.pattern.ReplaceAllString(name, r.replacement)
+	}
+	if !n.CaseSensitive {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+type ColumnNamingConfig struct {
+	Adapter        string                `yaml:"adapter"`
+	CaseSensitive  *bool                 `yaml:"case_sensitive"`
+	StripQuotes    *bool                 `yaml:"strip_quotes"`
+	TrimWhitespace *bool                 `yaml:"trim_whitespace"`
+	Unicode        string                `yaml:"unicode"`
+	Replace        []NameReplacementRule `yaml:"replace"`
+}
+
+type NameReplacementRule struct {
+	Pattern     string `yaml:"pattern"`
+	Replacement string `yaml:"replacement"`
+}
+
+func (c ColumnNamingConfig) validate() error {
+	_, err := c.naming()
+	return err
+}
+
+func (c ColumnNamingConfig) naming() (ColumnNaming, error) {
+	var n ColumnNaming
+	if c.Adapter != "" {
+		caseSensitive, ok := adapterCaseSensitivity[c.Adapter]
+		if !ok {
+			return ColumnNaming{}, fmt.Errorf("unknown column_naming adapter %q, expected one of: %s", c.Adapter, strings.Join(sortedKeys(adapterCaseSensitivity), ", "))
+		}
+		n.CaseSensitive = caseSensitive
+	}
+	if c.CaseSensitive != nil {
+		n.CaseSensitive = *c.CaseSensitive
+	}
+	n.KeepQuotes = c.StripQuotes != nil && !*c.StripQuotes
+	n.KeepWhitespace = c.TrimWhitespace != nil && !*c.TrimWhitespace
+	if c.Unicode != "" {
+		if _, ok := unicodeForms[strings.ToLower(c.Unicode)]; !ok {
+			return ColumnNaming{}, fmt.Errorf("unknown column_naming unicode form %q, expected one of: %s", c.Unicode, strings.Join(sortedKeys(unicodeForms), ", "))
+		}
+		n.Unicode = strings.ToLower(c.Unicode)
+	}
+	for _, rule := range c.Replace {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return ColumnNaming{}, fmt.Errorf("invalid column_naming replace pattern %q: %w", rule.Pattern, err)
+		}
+		n.Replacements = append(n.Replacements, nameReplacement{pattern: pattern, replacement: rule.Replacement})
+	}
+	return n, nil
+}
+
+const DefaultNameFormat = "{{schema}}.{{identifier}}"
+
+var (
+	namePlaceholderRegexp = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+	namePlaceholders      = []string{"alias", "database", "identifier", "name", "package", "schema", "source"}
+)
+
+func ValidateNameFormat(format string) error {
+	for _, match := range namePlaceholderRegexp.FindAllStringSubmatch(format, -1) {
+		if !containsString(namePlaceholders, match[1]) {
+			return fmt.Errorf("unknown name_format placeholder %q, expected one of: %s", match[0], strings.Join(namePlaceholders, ", "))
+		}
+	}
+	return nil
+}
+
+// formatTableName renders the display name of a manifest node. identifier
+// is the relation actually created in the warehouse: the source identifier
+// or the model alias, falling back to the node name.
+func formatTableName(node map[string]interface{}, format string) string {
+	values := make(map[string]string)
+	for _, key := range []string{"alias", "database", "identifier", "name", "schema"} {
+		values[key], _ = node[key].(string)
+	}
+	values["package"], _ = node["package_name"].(string)
+	values["source"], _ = node["source_name"].(string)
+	values["identifier"] = relationIdentifier(node)
+	name := namePlaceholderRegexp.ReplaceAllStringFunc(format, func(placeholder string) string {
+		return values[namePlaceholderRegexp.FindStringSubmatch(placeholder)[1]]
+	})
+	return strings.ToLower(name)
+}
+
+func relationIdentifier(node map[string]interface{}) string {
+	for _, key := range []string{"identifier", "alias", "name"} {
+		if v, _ := node[key].(string); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func unquoteIdentifier(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if len(name) >= 2 {
+		first, last := name[0], name[len(name)-1]
+		if (first == '"' && last == '"') || (first == '`' && last == '`') || (first == '[' && last == ']') {
+			return name[1 : len(name)-1], true
+		}
+	}
+	return name, false
+}
+
+// lookupColumnInfo finds the manifest declaration of a catalog column. In
+// case-sensitive mode, unquoted declarations still match whatever case the
+// warehouse folded them to.
+func lookupColumnInfo(columns map[string]interface{}, key string, naming ColumnNaming) map[string]interface{} {
+	if colInfo, ok := columns[key].(map[string]interface{}); ok {
+		return colInfo
+	}
+	if !naming.CaseSensitive {
+		return nil
+	}
+	for name, v := range columns {
+		colInfo, ok := v.(map[string]interface{})
+		if !ok || !strings.EqualFold(name, key) {
+			continue
+		}
+		if quoted, _ := colInfo["quote"].(bool); !quoted {
+			return colInfo
+		}
+	}
+	return nil
+}
+
+func lookupColumnTests(tests map[string][]interface{}, key string, naming ColumnNaming) []interface{} {
+	if columnTests, ok := tests[key]; ok || !naming.CaseSensitive {
+		return columnTests
+	}
+	for name, columnTests := range tests {
+		if strings.EqualFold(name, key) {
+			return columnTests
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NumberFormatConfig is the number_format section of the configuration. It
+// sets how percentages are rendered for humans (console, Markdown, HTML, PDF,
+// notifications) and the scale of the coverage values of the JSON report.
+type NumberFormatConfig struct {
+	Precision *int    `yaml:"precision"`
+	Locale    string  `yaml:"locale"`
+	JSONScale float64 `yaml:"json_scale"`
+}
+
+type PercentFormat struct {
+	Precision int
+	Locale    string
+	JSONScale float64
+}
+
+type percentLocale struct {
+	decimal string
+	suffix  string
+}
+
+var percentLocales = map[string]percentLocale{
+	"en": {".", "%"},
+	"de": {",", " %"},
+	"es": {",", " %"},
+	"fr": {",", " %"},
+	"it": {",", "%"},
+	"nl": {",", "%"},
+	"pt": {",", "%"},
+}
+
+const maxPercentPrecision = 4
+
+var defaultPercentFormat = PercentFormat{Precision: 1, Locale: "en", JSONScale: 1}
+
+var percentFormat = defaultPercentFormat
+
+func (c NumberFormatConfig) validate() error {
+	_, err := c.percentFormat()
+	return err
+}
+
+func (c NumberFormatConfig) percentFormat() (PercentFormat, error) {
+	f := defaultPercentFormat
+	if c.Precision != nil {
+		if *c.Precision < 0 || *c.Precision > maxPercentPrecision {
+			return PercentFormat{}, fmt.Errorf("number_format precision %d must be between 0 and %d", *c.Precision, maxPercentPrecision)
+		}
+		f.Precision = *c.Precision
+	}
+	if c.Locale != "" {
+		// en_US, fr-FR... only the language matters.
+		locale, _, _ := strings.Cut(strings.ReplaceAll(strings.ToLower(c.Locale), "_", "-"), "-")
+		if _, ok := percentLocales[locale]; !ok {
+			return PercentFormat{}, fmt.Errorf("unknown number_format locale %q, expected one of: %s", c.Locale, strings.Join(sortedKeys(percentLocales), ", "))
+		}
+		f.Locale = locale
+	}
+	switch c.JSONScale {
+	case 0:
+	case 1, 100:
+		f.JSONScale = c.JSONScale
+	default:
+		return PercentFormat{}, fmt.Errorf("number_format json_scale %g must be 1 (0–1) or 100 (0–100)", c.JSONScale)
+	}
+	return f, nil
+}
+
+// Format renders a percentage (0–100), e.g. 81.2% or 81,2 %.
+func (f PercentFormat) Format(percent float64) string {
+	l := percentLocales[f.Locale]
+	return strings.Replace(strconv.FormatFloat(percent, 'f', f.Precision, 64), ".", l.decimal, 1) + l.suffix
+}
+
+// scaleReport returns a copy of the report whose coverage values go from 0 to
+// scale. The scale is recorded in coverage_scale, so readers of the report
+// can bring it back to 0–1.
+func scaleReport(report JSONReport, scale float64) JSONReport {
+	from := report.CoverageScale
+	if from == 0 {
+		from = 1
+	}
+	if scale == from {
+		return report
+	}
+	factor := scale / from
+	report.Coverage *= factor
+	report.CoverageScale = scale
+	if scale == 1 {
+		report.CoverageScale = 0
+	}
+	report.Groups = append([]GroupReport(nil), report.Groups...)
+	for i := range report.Groups {
+		report.Groups[i].Coverage *= factor
+	}
+	scaleTables := func(tables []TableReport) []TableReport {
+		tables = append([]TableReport(nil), tables...)
+		for i, t := range tables {
+			tables[i].Coverage *= factor
+			tables[i].Columns = append([]ColumnReport(nil), t.Columns...)
+			for j := range tables[i].Columns {
+				tables[i].Columns[j].Coverage *= factor
+			}
+		}
+		return tables
+	}
+	report.Tables = scaleTables(report.Tables)
+	report.External = scaleTables(report.External)
+	report.Budgets = append([]BudgetProgress(nil), report.Budgets...)
+	for i := range report.Budgets {
+		report.Budgets[i].Coverage *= factor
+	}
+	return report
+}
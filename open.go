@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+func runOpen(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("open")
+	var (
+		projectDir      = fs.String("dbt_dir", ".", "dbt project path")
+		runArtifactsDir = fs.String("target_dir", "target", "dbt target path")
+		covTypeStr      = fs.String("type", "test", "Coverage type (doc, test, accepted_values, relationships, description, contract, unit_test, model_test, monitoring, freshness, meta:<key> or a plugin name)")
+		configPath      = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		reportPath      = fs.String("report", "", "Open this coverage report (JSON) instead of computing one from the dbt artifacts")
+		nameFormat      = fs.String("name_format", DefaultNameFormat, "Model display name template (see the main command)")
+		historyDir      = fs.String("history_dir", "", "Directory of past JSON reports, adds the trends to the page")
+		browser         = fs.String("browser", "", "Command opening the page (defaults to $BROWSER, then the system default browser)")
+		labels          = make(labelsFlag)
+	)
+	fs.Var(labels, "label", "Only draw the trends of the reports of --history_dir carrying this label, key=value, repeatable (e.g. --label env=prod)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+
+	report, closeProviders, err := loadOrComputeReport(ctx, *reportPath, *projectDir, *runArtifactsDir, *configPath, *covTypeStr, *nameFormat)
+	if err != nil {
+		return err
+	}
+	defer closeProviders()
+	var history []HistoryEntry
+	if *historyDir != "" {
+		if history, err = loadHistory(*historyDir, report.CovType); err != nil {
+			return err
+		}
+		history = filterHistoryByLabels(history, labels)
+	}
+	// The page is left behind: the browser reads it after this command exits.
+	f, err := os.CreateTemp("", "dbt-goverage-*.html")
+	if err != nil {
+		return err
+	}
+	if err := renderHTMLReport(f, report, history); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	command := browserCommand(runtime.GOOS, *browser, os.Getenv("BROWSER"), f.Name())
+	log.Printf("Opening %s with %s", f.Name(), command[0])
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// browserCommand returns the command line opening path: the --browser flag,
+// then $BROWSER, then the opener of the operating system.
+func browserCommand(goos, browser, envBrowser, path string) []string {
+	for _, custom := range []string{browser, envBrowser} {
+		if fields := strings.Fields(custom); len(fields) > 0 {
+			return append(fields, path)
+		}
+	}
+	switch goos {
+	case "darwin":
+		return []string{"open", path}
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", path}
+	}
+	return []string{"xdg-open", path}
+}
+
+// loadOrComputeReport reads the JSON report at reportPath or, when it is
+// empty, computes the report of the dbt project like the list subcommand. The
+// returned function closes the coverage providers once the caller is done.
+func loadOrComputeReport(ctx context.Context, reportPath, projectDir, runArtifactsDir, configPath, covTypeStr, nameFormat string) (JSONReport, func(), error) {
+	noop := func() {}
+	if reportPath != "" {
+		report, err := readJSONReport(reportPath)
+		return report, noop, err
+	}
+	if err := ValidateNameFormat(nameFormat); err != nil {
+		return JSONReport{}, noop, err
+	}
+	cfg, err := loadConfig(configPath, projectDir)
+	if err != nil {
+		return JSONReport{}, noop, err
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		return JSONReport{}, noop, err
+	}
+	settings.NameFormat = nameFormat
+	registerHeuristicProviders(cfg.Heuristics)
+	if err := registerPlugins(cfg.Plugins); err != nil {
+		return JSONReport{}, closeCoverageProviders, err
+	}
+	covType := CoverageType(covTypeStr)
+	if _, err := lookupCoverageProvider(covType); err != nil {
+		return JSONReport{}, closeCoverageProviders, err
+	}
+	catalog, err := loadFiles(ctx, projectDir, runArtifactsDir, catalogRequired(covType), settings)
+	if err != nil {
+		return JSONReport{}, closeCoverageProviders, err
+	}
+	if err := evaluateCoverage(ctx, catalog, covType); err != nil {
+		return JSONReport{}, closeCoverageProviders, err
+	}
+	return computeJSONReport(catalog, covType, GroupByNone), closeCoverageProviders, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ReportOutput is a report file of the run: a format and where to write it,
+// - for stdout.
+type ReportOutput struct {
+	Format string
+	Path   string
+}
+
+// defaultOutputPath is the file of a format listed without a path after the
+// first one of --format: coverage.sarif, coverage.md, coverage.jsonl...
+func defaultOutputPath(format string) string {
+	if path, ok := defaultOutputs[format]; ok {
+		return path
+	}
+	return "coverage." + format
+}
+
+// parseReportOutputs reads --format, one format or a list of format or
+// format=path items: json,sarif,jsonl=columns.jsonl. The first format is
+// written to output unless given a path, the others to their default file.
+func parseReportOutputs(spec, output string) ([]ReportOutput, error) {
+	var outputs []ReportOutput
+	written := make(map[string]string)
+	for i, item := range splitList(spec) {
+		format, path, explicit := strings.Cut(item, "=")
+		if err := validateReportFormat(format); err != nil {
+			return nil, err
+		}
+		switch {
+		case explicit && path == "":
+			return nil, fmt.Errorf("empty output path for the %s format", format)
+		case explicit:
+		case i == 0:
+			path = output
+			if name, ok := defaultOutputs[format]; ok && path == "coverage.json" {
+				path = name
+			}
+		default:
+			path = defaultOutputPath(format)
+		}
+		if other, ok := written[path]; ok {
+			return nil, fmt.Errorf("the %s and %s formats are both written to %s", other, format, path)
+		}
+		written[path] = format
+		outputs = append(outputs, ReportOutput{Format: format, Path: path})
+	}
+	if len(outputs) == 0 {
+		return nil, errors.New("no output format")
+	}
+	return outputs, nil
+}
+
+// renderJob writes one output of the run.
+type renderJob struct {
+	name string
+	run  func() error
+}
+
+// runRenderJobs writes the outputs concurrently: they only read the shared
+// report (jsonl streams from the catalog), so a slow PDF or a large JSON
+// Lines file no longer delays the others. All the jobs run to completion and
+// their errors are joined.
+func runRenderJobs(ctx context.Context, jobs []renderJob) error {
+	if len(jobs) == 1 {
+		return jobs[0].run()
+	}
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			if err := job.run(); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", job.name, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// outputJob renders one --format output of the report.
+func outputJob(ctx context.Context, output ReportOutput, report JSONReport, catalog Catalog, covType CoverageType, pageSize int) renderJob {
+	job := renderJob{name: output.Format + " report " + output.Path}
+	switch {
+	case output.Format == ReportFormatJSONL:
+		job.run = func() error { return writeJSONLReport(ctx, catalog, covType, output.Path) }
+	case output.Format == string(FormatHTMLReport) && pageSize > 0 && output.Path != "-":
+		job.run = func() error { return writeHTMLPages(report, nil, output.Path, pageSize) }
+	default:
+		job.run = func() error { return writeReport(report, output.Format, output.Path) }
+	}
+	return job
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// OwnershipRule assigns the files matching a CODEOWNERS-style pattern to
+// owners (teams or people).
+type OwnershipRule struct {
+	Pattern string
+	Owners  []string
+	re      *regexp.Regexp
+}
+
+// Ownership maps the files of the dbt project to their owners, independently
+// of the dbt meta that not all teams maintain. Like CODEOWNERS, the last
+// matching rule wins.
+type Ownership []OwnershipRule
+
+// UnownedGroup is the group of the tables no ownership rule matches.
+const UnownedGroup = "unowned"
+
+func loadOwnership(path string) (Ownership, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ownership file: %w", err)
+	}
+	var rules Ownership
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		re, err := ownershipPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern %q: %w", path, line, fields[0], err)
+		}
+		var owners []string
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			owners = append(owners, owner)
+		}
+		rules = append(rules, OwnershipRule{Pattern: fields[0], Owners: owners, re: re})
+	}
+	return rules, scanner.Err()
+}
+
+// ownershipPattern compiles a CODEOWNERS pattern: a pattern starting with or
+// containing a slash is anchored to the dbt project, others match at any
+// depth; * stays within a directory, ** crosses them, and a pattern matching
+// a directory owns everything below it.
+func ownershipPattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	p := strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+	var b strings.Builder
+	if anchored {
+		b.WriteString("^")
+	} else {
+		b.WriteString("(^|/)")
+	}
+	for i := 0; i < len(p); i++ {
+		switch {
+		case strings.HasPrefix(p[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(p[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case p[i] == '*':
+			b.WriteString("[^/]*")
+		case p[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(p[i : i+1]))
+		}
+	}
+	b.WriteString("(/.*)?$")
+	return regexp.Compile(b.String())
+}
+
+// Owners returns the owners of a file of the dbt project, nil when no rule
+// matches or the last matching rule has no owner.
+func (o Ownership) Owners(filePath string) []string {
+	filePath = slashPath(filePath)
+	for i := len(o) - 1; i >= 0; i-- {
+		if o[i].re.MatchString(filePath) {
+			return o[i].Owners
+		}
+	}
+	return nil
+}
+
+func tableOwner(table Table) string {
+	if len(table.Owners) == 0 {
+		return UnownedGroup
+	}
+	return table.Owners[0]
+}
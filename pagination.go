@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Page is a window of the models of a report, for the projects whose
+// thousands of rows a terminal or a browser cannot render at once.
+type Page struct {
+	Number int
+	Count  int
+	From   int // index of the first model, inclusive
+	To     int // index of the last model, exclusive
+	Total  int
+}
+
+// paginate returns page number (1-based) of total models split in pages of
+// size models.
+func paginate(total, size, number int) (Page, error) {
+	count := max((total+size-1)/size, 1)
+	if number < 1 || number > count {
+		return Page{}, fmt.Errorf("page %d out of range, the report has %d pages of %d models", number, count, size)
+	}
+	from := (number - 1) * size
+	return Page{Number: number, Count: count, From: from, To: min(from+size, total), Total: total}, nil
+}
+
+// paginateDetailedReport keeps the models of one page of the console report,
+// ordered by group then name so the pages do not depend on the load order.
+// The totals remain those of the whole report.
+func paginateDetailedReport(report *DetailedCoverageReport, size, number int) (Page, error) {
+	page, err := paginate(len(report.TableReports), size, number)
+	if err != nil {
+		return Page{}, err
+	}
+	rows := append([]TableCoverage(nil), report.TableReports...)
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Group != rows[j].Group {
+			return rows[i].Group < rows[j].Group
+		}
+		if rows[i].ModelName != rows[j].ModelName {
+			return rows[i].ModelName < rows[j].ModelName
+		}
+		return rows[i].NodeName < rows[j].NodeName
+	})
+	report.TableReports = rows[page.From:page.To]
+	return page, nil
+}
+
+func printPage(w io.Writer, page Page) {
+	fmt.Fprintf(w, "\n📄 Page %d/%d: models %d–%d of %d", page.Number, page.Count, page.From+1, page.To, page.Total)
+	if page.Number < page.Count {
+		fmt.Fprintf(w, " (--page %d for the next ones)", page.Number+1)
+	}
+	fmt.Fprintln(w)
+}
+
+// htmlPagePath names the file of an HTML page: coverage.html for the first
+// one, then coverage-2.html, coverage-3.html...
+func htmlPagePath(path string, number int) string {
+	if number == 1 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path, ext), number, ext)
+}
+
+// writeHTMLPages splits the HTML report in files of size models linked to
+// each other, the first one keeping the path and the trend chart.
+func writeHTMLPages(report JSONReport, history []HistoryEntry, path string, size int) error {
+	first, err := paginate(len(report.Tables), size, 1)
+	if err != nil {
+		return err
+	}
+	links := make([]htmlPageLink, first.Count)
+	for i := range links {
+		links[i] = htmlPageLink{Number: i + 1, Href: filepath.Base(htmlPagePath(path, i+1))}
+	}
+	for number := 1; number <= first.Count; number++ {
+		page, _ := paginate(len(report.Tables), size, number)
+		chunk := report
+		chunk.Tables = report.Tables[page.From:page.To]
+		data := newHTMLReportData(chunk, history)
+		data.Page = &page
+		if number > 1 {
+			data.Trend = ""
+		}
+		data.Pages = links
+		pagePath := htmlPagePath(path, number)
+		f, err := os.Create(pagePath)
+		if err != nil {
+			return err
+		}
+		log.Printf("Writing HTML report page %d/%d into %s", number, page.Count, pagePath)
+		if err := htmlReportTemplate.Execute(f, data); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
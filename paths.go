@@ -0,0 +1,39 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+)
+
+// pathsFoldCase makes the path filters case-insensitive like the Windows file
+// systems, so models\Marts and models/marts select the same models there.
+var pathsFoldCase = runtime.GOOS == "windows"
+
+// slashPath converts a path written by dbt or typed by a user to the forward
+// slash form used to match the models. Unlike filepath.ToSlash it also
+// converts the backslashes of a manifest generated on Windows when the report
+// runs on Linux, and it turns the extended-length (\\?\C:\...) and UNC
+// (\\?\UNC\server\share) prefixes into C:/... and //server/share.
+func slashPath(p string) string {
+	switch {
+	case strings.HasPrefix(p, `\\?\UNC\`):
+		p = `\\` + p[len(`\\?\UNC\`):]
+	case strings.HasPrefix(p, `\\?\`):
+		p = p[len(`\\?\`):]
+	}
+	p = strings.ReplaceAll(p, `\`, "/")
+	for strings.HasPrefix(p, "./") {
+		p = p[2:]
+	}
+	return p
+}
+
+// hasPathPrefix tells whether the path p starts with prefix once both are
+// converted by slashPath, ignoring the case when pathsFoldCase is set.
+func hasPathPrefix(p, prefix string) bool {
+	p, prefix = slashPath(p), slashPath(prefix)
+	if pathsFoldCase {
+		p, prefix = strings.ToLower(p), strings.ToLower(prefix)
+	}
+	return strings.HasPrefix(p, prefix)
+}
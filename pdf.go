@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	ReportFormatPDF = "pdf"
+
+	pdfPageWidth   = 595 // A4, in points
+	pdfPageHeight  = 842
+	pdfMargin      = 50
+	pdfWorstTables = 10
+)
+
+// pdfDocument lays out lines of text on A4 pages with the standard Helvetica
+// fonts, so no font has to be embedded.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+type pdfCell struct {
+	X    float64
+	Text string
+}
+
+func newPDFDocument() *pdfDocument {
+	d := &pdfDocument{}
+	d.newPage()
+	return d
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// row writes cells on a new line, the widest cell being cut to fit before the
+// next one.
+func (d *pdfDocument) row(bold bool, size float64, cells ...pdfCell) {
+	if d.y-size < pdfMargin {
+		d.newPage()
+	}
+	d.y -= size * 1.4
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	page := d.pages[len(d.pages)-1]
+	for i, c := range cells {
+		limit := float64(pdfPageWidth - pdfMargin)
+		if i+1 < len(cells) {
+			limit = cells[i+1].X - 8
+		}
+		text := fitPDFText(c.Text, size, limit-c.X)
+		fmt.Fprintf(page, "BT /%s %.1f Tf %.1f %.1f Td (%s) Tj ET\n", font, size, c.X, d.y, escapePDFText(text))
+	}
+}
+
+func (d *pdfDocument) space(height float64) {
+	d.y -= height
+}
+
+// fitPDFText cuts the text to the width available, Helvetica glyphs being
+// about half as wide as the font size.
+func fitPDFText(text string, size, width float64) string {
+	maxChars := int(width / (size * 0.52))
+	runes := []rune(text)
+	if maxChars < 2 || len(runes) <= maxChars {
+		return text
+	}
+	return string(runes[:maxChars-1]) + "…"
+}
+
+// escapePDFText encodes the text in WinAnsi, replacing the characters it
+// cannot represent, and escapes the string delimiters.
+func escapePDFText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '…':
+			b.WriteString("\\205")
+		case r < 32 || r > 255:
+			b.WriteByte('?')
+		case r > 126:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (d *pdfDocument) writeTo(w io.Writer) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	out.WriteString("%PDF-1.4\n")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// renderPDFSummary writes the executive summary of a report: global numbers,
+// coverage per group (per folder without --group_by) and worst offenders.
+func renderPDFSummary(w io.Writer, report JSONReport) error {
+	d := newPDFDocument()
+	d.row(true, 18, pdfCell{pdfMargin, fmt.Sprintf("Coverage summary (%s)", strings.ToUpper(report.CovType))})
+	var generated []string
+	if report.GeneratedAt != "" {
+		generated = append(generated, "Generated "+report.GeneratedAt)
+	}
+	if report.GitSHA != "" {
+		generated = append(generated, "commit "+report.GitSHA)
+	}
+	if len(generated) > 0 {
+		d.row(false, 9, pdfCell{pdfMargin, strings.Join(generated, ", ")})
+	}
+	d.space(10)
+	d.row(true, 28, pdfCell{pdfMargin, formatCoverage(report.Covered, report.Total)})
+	d.row(false, 11, pdfCell{pdfMargin, fmt.Sprintf("%d of %d columns covered, %d tables.", report.Covered, report.Total, len(report.Tables))})
+	if report.QualityScore != nil {
+		d.row(false, 11, pdfCell{pdfMargin, fmt.Sprintf("Quality score: %.1f", *report.QualityScore)})
+	}
+
+	groupBy, groups := report.GroupBy, report.Groups
+	if len(groups) == 0 {
+		groupBy = string(GroupByFolder)
+		tables := make([]TableReport, len(report.Tables))
+		for i, t := range report.Tables {
+			t.Group = path.Dir(strings.ReplaceAll(t.OriginalFilePath, "\\", "/"))
+			tables[i] = t
+		}
+		groups = computeGroupReports(tables)
+	}
+	d.space(14)
+	d.row(true, 13, pdfCell{pdfMargin, "Coverage per " + groupBy})
+	d.row(true, 10, pdfCell{pdfMargin, strings.ToUpper(groupBy[:1]) + groupBy[1:]}, pdfCell{330, "Tables"}, pdfCell{390, "Columns"}, pdfCell{470, "Coverage"})
+	for _, g := range groups {
+		d.row(false, 10, pdfCell{pdfMargin, g.Name}, pdfCell{330, fmt.Sprint(g.Tables)},
+			pdfCell{390, fmt.Sprintf("%d/%d", g.Covered, g.Total)}, pdfCell{470, formatCoverage(g.Covered, g.Total)})
+	}
+
+	d.space(14)
+	d.row(true, 13, pdfCell{pdfMargin, "Worst offenders"})
+	d.row(true, 10, pdfCell{pdfMargin, "Model"}, pdfCell{230, "Columns"}, pdfCell{290, "Coverage"}, pdfCell{350, "File to edit"})
+	for _, t := range lowestCoverageTables(report, pdfWorstTables) {
+		d.row(false, 10, pdfCell{pdfMargin, t.Name}, pdfCell{230, fmt.Sprintf("%d/%d", t.Covered, t.Total)},
+			pdfCell{290, formatCoverage(t.Covered, t.Total)}, pdfCell{350, t.SchemaFilePath()})
+	}
+
+	if len(report.Budgets) > 0 {
+		d.space(14)
+		d.row(true, 13, pdfCell{pdfMargin, "Budgets"})
+		d.row(true, 10, pdfCell{pdfMargin, "Path"}, pdfCell{290, "Coverage"}, pdfCell{360, "Target"}, pdfCell{420, "Deadline"})
+		for _, b := range report.Budgets {
+			deadline := b.Deadline
+			if b.Overdue {
+				deadline += " (overdue)"
+			}
+			d.row(false, 10, pdfCell{pdfMargin, b.Path}, pdfCell{290, formatCoverage(b.Covered, b.Total)},
+				pdfCell{360, fmt.Sprintf("%.1f%%", b.Target)}, pdfCell{420, deadline})
+		}
+	}
+	return d.writeTo(w)
+}
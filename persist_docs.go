@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const CoverageTypePersistDocs CoverageType = "persist_docs"
+
+// PersistDocs is the persist_docs config of a node. Documentation that is not
+// persisted as comments in the warehouse never reaches the BI users.
+type PersistDocs struct {
+	Relation bool `json:"relation"`
+	Columns  bool `json:"columns"`
+}
+
+func parsePersistDocs(node map[string]interface{}) PersistDocs {
+	config, _ := node["config"].(map[string]interface{})
+	persist, _ := config["persist_docs"].(map[string]interface{})
+	var p PersistDocs
+	p.Relation, _ = persist["relation"].(bool)
+	p.Columns, _ = persist["columns"].(bool)
+	return p
+}
+
+// persistDocsProvider rates the models, seeds and snapshots persisting both
+// their description and their column descriptions. Sources are not built by
+// dbt and never apply.
+type persistDocsProvider struct{}
+
+func (persistDocsProvider) Name() string { return string(CoverageTypePersistDocs) }
+
+func (p persistDocsProvider) Evaluate(ctx context.Context, table Table, _ Column) (bool, error) {
+	return p.EvaluateTable(ctx, table)
+}
+
+func (persistDocsProvider) EvaluateTable(_ context.Context, table Table) (bool, error) {
+	return table.PersistDocs.Relation && table.PersistDocs.Columns, nil
+}
+
+func (persistDocsProvider) Applies(table Table, _ Column) bool {
+	return table.ResourceType != "source"
+}
+
+func init() {
+	RegisterCoverageProvider(persistDocsProvider{})
+}
+
+// printPersistDocs lists the models missing a part of persist_docs, after the
+// report of a persist_docs run.
+func printPersistDocs(w io.Writer, tables []TableReport) {
+	var missing []TableReport
+	for _, t := range tables {
+		if t.PersistDocs != nil && t.Covered < t.Total {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i].Name < missing[j].Name })
+	fmt.Fprintf(w, "\n📝 %d models without persist_docs (relation and columns):\n", len(missing))
+	for _, t := range missing {
+		fmt.Fprintf(w, "  - %s (relation: %t, columns: %t)\n", t.Name, t.PersistDocs.Relation, t.PersistDocs.Columns)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// preCommitCheck is the --pre_commit mode, run by the pre-commit framework
+// with the staged files as arguments. It only reads manifest.json (the
+// columns declared in the .yml files, catalog.json being usually stale or
+// absent on a workstation), analyzes the models of the files, and prints
+// their gaps as file:line: messages. Nothing is written.
+func preCommitCheck(ctx context.Context, w io.Writer, opts Options, files []string) ([]RunFailure, error) {
+	changed := make(ChangedFiles, len(files))
+	for _, f := range files {
+		changed[slashPath(f)] = true
+	}
+	opts.ChangedFiles = changed
+	catalog, err := opts.loadFiles(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	catalog = catalog.FilterChanged(changed)
+	if len(catalog.Tables) == 0 {
+		return nil, nil
+	}
+	catalog, exemptions := catalog.ApplyExemptions(opts.Exemptions, time.Now())
+	if err := evaluateCoverage(ctx, catalog, opts.CovType); err != nil {
+		return nil, err
+	}
+	report := computeJSONReport(catalog, opts.CovType, GroupByNone)
+	resolveSchemaLines(&report, opts.ProjectDir, opts.Parse.Naming)
+	if opts.Severities != nil {
+		applySeverities(&report, *opts.Severities)
+	}
+	report.Exemptions = exemptions
+	printPreCommitGaps(w, report)
+	return checkRun(opts, report, catalog)
+}
+
+// printPreCommitGaps prints one gap per line, in the file:line: format of the
+// linters that editors and pre-commit make clickable.
+func printPreCommitGaps(w io.Writer, report JSONReport) {
+	for _, gap := range coverageGaps(report.Tables) {
+		path := gap.Table.SchemaFilePath()
+		if path == "" {
+			path = gap.Table.Name
+		}
+		severity := gap.Severity
+		if severity == "" {
+			severity = SeverityWarning
+		}
+		fmt.Fprintf(w, "%s:%d: %s: %s\n", slashPath(path), gap.Line, severity, gap.Message(report.CovType))
+	}
+}
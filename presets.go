@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ColumnPresets lists the loader and warehouse system columns nobody
+// documents, as lowercase globs.
+var ColumnPresets = map[string][]string{
+	"airbyte":  {"_airbyte_*", "_ab_cdc_*"},
+	"bigquery": {"_partitiontime", "_partitiondate", "_table_suffix", "_file_name"},
+	"fivetran": {"_fivetran_*"},
+	"stitch":   {"_sdc_*"},
+}
+
+type ColumnExclusion struct {
+	Pattern string
+	Source  string
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(ColumnPresets))
+	for name := range ColumnPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func columnExclusions(presets, patterns []string) ([]ColumnExclusion, error) {
+	var exclusions []ColumnExclusion
+	for _, preset := range presets {
+		name := strings.ToLower(strings.TrimSpace(preset))
+		globs, ok := ColumnPresets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q, expected one of: %s", preset, strings.Join(presetNames(), ", "))
+		}
+		for _, glob := range globs {
+			exclusions = append(exclusions, ColumnExclusion{Pattern: glob, Source: "preset " + name})
+		}
+	}
+	for _, pattern := range patterns {
+		glob := strings.ToLower(pattern)
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("exclude_columns: invalid pattern %q: %w", pattern, err)
+		}
+		exclusions = append(exclusions, ColumnExclusion{Pattern: glob, Source: "exclude_columns"})
+	}
+	return exclusions, nil
+}
+
+// excludedColumn returns the first exclusion matching a column name, case
+// insensitively.
+func excludedColumn(exclusions []ColumnExclusion, name string) (ColumnExclusion, bool) {
+	name = strings.ToLower(name)
+	for _, e := range exclusions {
+		if ok, _ := path.Match(e.Pattern, name); ok {
+			return e, true
+		}
+	}
+	return ColumnExclusion{}, false
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type CoverageProvider interface {
+	Name() string
+	Evaluate(ctx context.Context, table Table, column Column) (bool, error)
+}
+
+var (
+	coverageProvidersMu sync.RWMutex
+	coverageProviders   = make(map[CoverageType]CoverageProvider)
+)
+
+func RegisterCoverageProvider(p CoverageProvider) {
+	coverageProvidersMu.Lock()
+	defer coverageProvidersMu.Unlock()
+	name := CoverageType(p.Name())
+	if _, exists := coverageProviders[name]; exists {
+		panic(fmt.Sprintf("coverage provider %s registered twice", name))
+	}
+	coverageProviders[name] = p
+}
+
+// replaceCoverageProvider registers p in place of the provider of the same
+// name, when serve reloads its configuration.
+func replaceCoverageProvider(p CoverageProvider) {
+	coverageProvidersMu.Lock()
+	defer coverageProvidersMu.Unlock()
+	coverageProviders[CoverageType(p.Name())] = p
+}
+
+func lookupCoverageProvider(covType CoverageType) (CoverageProvider, error) {
+	if key, ok := strings.CutPrefix(string(covType), metaCoveragePrefix); ok {
+		if key == "" {
+			return nil, fmt.Errorf("missing meta key in coverage type %q (e.g. meta:pii)", covType)
+		}
+		return metaCoverageProvider{key: key}, nil
+	}
+	coverageProvidersMu.RLock()
+	defer coverageProvidersMu.RUnlock()
+	if p, ok := coverageProviders[covType]; ok {
+		return p, nil
+	}
+	names := make([]string, 0, len(coverageProviders))
+	for name := range coverageProviders {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown coverage type %q, expected one of: %s, meta:<key>", covType, strings.Join(names, ", "))
+}
+
+// TableCoverageProvider rates a table as a whole from the manifest alone:
+// its tables count as one unit each and catalog.json is not needed.
+type TableCoverageProvider interface {
+	CoverageProvider
+	EvaluateTable(ctx context.Context, table Table) (bool, error)
+}
+
+func catalogRequired(covTypes ...CoverageType) bool {
+	for _, covType := range covTypes {
+		provider, err := lookupCoverageProvider(covType)
+		if err != nil {
+			return true
+		}
+		if _, ok := provider.(TableCoverageProvider); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+func evaluateCoverage(ctx context.Context, catalog Catalog, covType CoverageType) error {
+	provider, err := lookupCoverageProvider(covType)
+	if err != nil {
+		return err
+	}
+	scoped, isScoped := provider.(ScopedCoverageProvider)
+	tableProvider, isTableLevel := provider.(TableCoverageProvider)
+	for id, table := range catalog.Tables {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if isTableLevel {
+			if isScoped && !scoped.Applies(table, Column{}) {
+				delete(table.Coverage, covType)
+				catalog.Tables[id] = table
+				continue
+			}
+			covered, err := tableProvider.EvaluateTable(ctx, table)
+			if err != nil {
+				return fmt.Errorf("%s coverage of %s: %w", covType, table.Name, err)
+			}
+			if table.Coverage == nil {
+				table.Coverage = make(map[CoverageType]bool)
+			}
+			table.Coverage[covType] = covered
+			catalog.Tables[id] = table
+			continue
+		}
+		for name, col := range table.Columns {
+			if col.Coverage == nil {
+				col.Coverage = make(map[CoverageType]bool)
+			}
+			if isScoped && !scoped.Applies(table, col) {
+				delete(col.Coverage, covType)
+				table.Columns[name] = col
+				continue
+			}
+			covered, err := provider.Evaluate(ctx, table, col)
+			if err != nil {
+				return fmt.Errorf("%s coverage of %s.%s: %w", covType, table.Name, name, err)
+			}
+			col.Coverage[covType] = covered
+			table.Columns[name] = col
+		}
+		catalog.Tables[id] = table
+	}
+	return nil
+}
+
+type docCoverageProvider struct{}
+
+func (docCoverageProvider) Name() string { return string(CoverageTypeDoc) }
+
+func (docCoverageProvider) Evaluate(_ context.Context, _ Table, column Column) (bool, error) {
+	return column.Doc, nil
+}
+
+type testCoverageProvider struct{}
+
+func (testCoverageProvider) Name() string { return string(CoverageTypeTest) }
+
+func (testCoverageProvider) Evaluate(_ context.Context, _ Table, column Column) (bool, error) {
+	return column.Test, nil
+}
+
+const metaCoveragePrefix = "meta:"
+
+type metaCoverageProvider struct {
+	key string
+}
+
+func (p metaCoverageProvider) Name() string { return metaCoveragePrefix + p.key }
+
+func (p metaCoverageProvider) Evaluate(_ context.Context, _ Table, column Column) (bool, error) {
+	var value interface{} = column.Meta
+	for _, part := range strings.Split(p.key, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return false, nil
+		}
+		if value, ok = m[part]; !ok {
+			return false, nil
+		}
+	}
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case string:
+		return strings.TrimSpace(v) != "", nil
+	}
+	return true, nil
+}
+
+type tableCoverageFunc struct {
+	name     CoverageType
+	evaluate func(Table) bool
+}
+
+func (p tableCoverageFunc) Name() string { return string(p.name) }
+
+func (p tableCoverageFunc) Evaluate(ctx context.Context, table Table, _ Column) (bool, error) {
+	return p.EvaluateTable(ctx, table)
+}
+
+func (p tableCoverageFunc) EvaluateTable(_ context.Context, table Table) (bool, error) {
+	return p.evaluate(table), nil
+}
+
+func init() {
+	RegisterCoverageProvider(docCoverageProvider{})
+	RegisterCoverageProvider(testCoverageProvider{})
+	RegisterCoverageProvider(tableCoverageFunc{CoverageTypeDescription, func(t Table) bool { return IsValidDoc(t.Description) }})
+	RegisterCoverageProvider(tableCoverageFunc{CoverageTypeContract, func(t Table) bool { return t.ContractEnforced }})
+	RegisterCoverageProvider(tableCoverageFunc{CoverageTypeUnitTest, func(t Table) bool { return len(t.UnitTests) > 0 }})
+	RegisterCoverageProvider(tableCoverageFunc{CoverageTypeModelTest, func(t Table) bool { return len(t.ModelTests) > 0 }})
+}
+
+type PluginConfig struct {
+	Name    string   `yaml:"name"`
+	Command []string `yaml:"command"`
+}
+
+type rpcRequest struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int         `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type pluginTable struct {
+	UniqueID         string                 `json:"unique_id"`
+	Name             string                 `json:"name"`
+	ResourceType     string                 `json:"resource_type"`
+	PackageName      string                 `json:"package_name"`
+	OriginalFilePath string                 `json:"original_file_path"`
+	Description      string                 `json:"description"`
+	Meta             map[string]interface{} `json:"meta"`
+	Tags             []string               `json:"tags"`
+}
+
+type pluginColumn struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Meta        map[string]interface{} `json:"meta"`
+	Tags        []string               `json:"tags"`
+	Documented  bool                   `json:"documented"`
+	Tested      bool                   `json:"tested"`
+}
+
+type externalCoverageProvider struct {
+	config PluginConfig
+
+	mu  sync.Mutex
+	cmd *exec.Cmd
+	// cmdCtx is the context the process was started with: it is killed once
+	// the context is done, and started again by the next evaluation.
+	cmdCtx context.Context
+	stdin  io.WriteCloser
+	stdout *json.Decoder
+	nextID int
+}
+
+func newExternalCoverageProvider(config PluginConfig) (*externalCoverageProvider, error) {
+	if config.Name == "" || len(config.Command) == 0 {
+		return nil, errors.New("plugins require a name and a command")
+	}
+	return &externalCoverageProvider{config: config}, nil
+}
+
+func (p *externalCoverageProvider) Name() string { return p.config.Name }
+
+func (p *externalCoverageProvider) start(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, p.config.Command[0], p.config.Command[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	log.Printf("Starting coverage plugin %s: %s", p.config.Name, strings.Join(p.config.Command, " "))
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting plugin %s: %w", p.config.Name, err)
+	}
+	p.cmd = cmd
+	p.cmdCtx = ctx
+	p.stdin = stdin
+	p.stdout = json.NewDecoder(bufio.NewReader(stdout))
+	return nil
+}
+
+func (p *externalCoverageProvider) Evaluate(ctx context.Context, table Table, column Column) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if p.cmd != nil && p.cmdCtx.Err() != nil {
+		p.stop()
+	}
+	if p.cmd == nil {
+		if err := p.start(ctx); err != nil {
+			return false, err
+		}
+	}
+	p.nextID++
+	req := rpcRequest{
+		JSONRPC: "2.0",
+		ID:      p.nextID,
+		Method:  "evaluate",
+		Params: map[string]interface{}{
+			"table": pluginTable{
+				UniqueID:         table.UniqueID,
+				Name:             table.Name,
+				ResourceType:     table.ResourceType,
+				PackageName:      table.PackageName,
+				OriginalFilePath: table.OriginalFilePath,
+				Description:      table.Description,
+				Meta:             table.Meta,
+				Tags:             table.Tags,
+			},
+			"column": pluginColumn{
+				Name:        column.Name,
+				Description: column.Description,
+				Meta:        column.Meta,
+				Tags:        column.Tags,
+				Documented:  column.Doc,
+				Tested:      column.Test,
+			},
+		},
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return false, err
+	}
+	if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+		return false, fmt.Errorf("plugin %s: %w", p.config.Name, err)
+	}
+	var resp rpcResponse
+	if err := p.stdout.Decode(&resp); err != nil {
+		if ctx.Err() != nil {
+			return false, fmt.Errorf("plugin %s: %w", p.config.Name, ctx.Err())
+		}
+		return false, fmt.Errorf("plugin %s: invalid response: %w", p.config.Name, err)
+	}
+	if resp.Error != nil {
+		return false, fmt.Errorf("plugin %s: %s (code %d)", p.config.Name, resp.Error.Message, resp.Error.Code)
+	}
+	if resp.ID != req.ID {
+		return false, fmt.Errorf("plugin %s: response id %d does not match request id %d", p.config.Name, resp.ID, req.ID)
+	}
+	var result struct {
+		Covered bool `json:"covered"`
+	}
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return false, fmt.Errorf("plugin %s: invalid result: %w", p.config.Name, err)
+	}
+	return result.Covered, nil
+}
+
+func (p *externalCoverageProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil || p.cmdCtx.Err() != nil {
+		p.stop()
+		return nil
+	}
+	return p.stop()
+}
+
+// stop closes the standard input of the process and waits for it to exit.
+// The caller holds mu.
+func (p *externalCoverageProvider) stop() error {
+	if p.cmd == nil {
+		return nil
+	}
+	p.stdin.Close()
+	err := p.cmd.Wait()
+	p.cmd = nil
+	return err
+}
+
+func registerPlugins(plugins []PluginConfig) error {
+	for _, config := range plugins {
+		p, err := newExternalCoverageProvider(config)
+		if err != nil {
+			return err
+		}
+		if _, err := lookupCoverageProvider(CoverageType(p.Name())); err == nil {
+			return fmt.Errorf("plugin %s conflicts with an existing coverage type", p.Name())
+		}
+		RegisterCoverageProvider(p)
+	}
+	return nil
+}
+
+func closeCoverageProviders() {
+	coverageProvidersMu.RLock()
+	defer coverageProvidersMu.RUnlock()
+	for name, p := range coverageProviders {
+		if c, ok := p.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.Printf("warning: closing coverage provider %s: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type publisher func(ctx context.Context, args []string) error
+
+var publishers = map[string]publisher{
+	"azure-devops":  publishAzureDevOps,
+	"bitbucket":     publishBitbucket,
+	"buildkite":     publishBuildkite,
+	"confluence":    publishConfluence,
+	"datahub":       publishDataHub,
+	"discord":       publishDiscord,
+	"email":         publishEmail,
+	"github-checks": publishGitHubChecks,
+	"openmetadata":  publishOpenMetadata,
+	"slack":         publishSlack,
+	"teamcity":      publishTeamCity,
+}
+
+func runPublish(ctx context.Context, args []string) error {
+	// The flags before the target are those of publish itself, the others
+	// belong to the target.
+	fs := flag.NewFlagSet("publish", flag.ContinueOnError)
+	otelEndpoint := fs.String("otel_endpoint", "", "OTLP/HTTP collector receiving a span of the publication (defaults to $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		return fmt.Errorf("missing publish target, expected one of: %s, routes, s3://bucket/prefix/ or gs://bucket/prefix/", strings.Join(publisherNames(), ", "))
+	}
+	publish, ok := publishers[args[0]]
+	switch target := args[0]; {
+	case isObjectStorageTarget(target):
+		publish, ok = func(ctx context.Context, args []string) error {
+			return publishObjectStorage(ctx, target, args)
+		}, true
+	case target == "routes":
+		// Not in publishers, the routes publish to them.
+		publish, ok = publishRoutes, true
+	}
+	if !ok {
+		return fmt.Errorf("unknown publish target %q, expected one of: %s, routes", args[0], strings.Join(publisherNames(), ", "))
+	}
+	start := time.Now()
+	err := publish(ctx, args[1:])
+	newTelemetry(*otelEndpoint).exportSpan(ctx, "publish "+args[0], start, err, stringAttr("dbt_coverage.publish.target", args[0]))
+	return err
+}
+
+func isObjectStorageTarget(target string) bool {
+	return strings.HasPrefix(target, "s3://") || strings.HasPrefix(target, "gs://")
+}
+
+func publisherNames() []string {
+	names := make([]string, 0, len(publishers))
+	for name := range publishers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readJSONReport(path string) (JSONReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return JSONReport{}, fmt.Errorf("report %s not found, run the coverage computation first", path)
+		}
+		return JSONReport{}, err
+	}
+	var report JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return JSONReport{}, fmt.Errorf("invalid report %s: %w", path, err)
+	}
+	return scaleReport(report, 1), nil
+}
+
+func envOrDefault(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+var publishHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+func postJSON(ctx context.Context, url string, payload interface{}, headers map[string]string) error {
+	_, err := sendJSON(ctx, http.MethodPost, url, payload, headers)
+	return err
+}
+
+func sendJSON(ctx context.Context, method, url string, payload interface{}, headers map[string]string) ([]byte, error) {
+	return sendJSONWith(ctx, publishHTTPClient, method, url, payload, headers)
+}
+
+// sendJSONWith is sendJSON through client, for the targets needing their own
+// transport.
+func sendJSONWith(ctx context.Context, client *http.Client, method, url string, payload interface{}, headers map[string]string) ([]byte, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s returned %s: %s", method, url, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+func lowestCoverageTables(report JSONReport, n int) []TableReport {
+	tables := make([]TableReport, len(report.Tables))
+	copy(tables, report.Tables)
+	sort.SliceStable(tables, func(i, j int) bool {
+		if tables[i].Coverage != tables[j].Coverage {
+			return tables[i].Coverage < tables[j].Coverage
+		}
+		return tables[i].Name < tables[j].Name
+	})
+	if len(tables) > n {
+		tables = tables[:n]
+	}
+	return tables
+}
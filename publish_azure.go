@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type azureComment struct {
+	ParentCommentID int    `json:"parentCommentId"`
+	Content         string `json:"content"`
+	CommentType     int    `json:"commentType"`
+}
+
+type azureThread struct {
+	Comments []azureComment `json:"comments"`
+	Status   int            `json:"status"`
+}
+
+func publishAzureDevOps(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish azure-devops")
+	var (
+		reportPath    = fs.String("report", "coverage.json", "Coverage report to publish (JSON)")
+		collectionURI = fs.String("collection_uri", os.Getenv("SYSTEM_COLLECTIONURI"), "Organization URL")
+		project       = fs.String("project", os.Getenv("SYSTEM_TEAMPROJECT"), "Team project")
+		repositoryID  = fs.String("repository_id", os.Getenv("BUILD_REPOSITORY_ID"), "Repository ID")
+		pullRequestID = fs.String("pull_request_id", os.Getenv("SYSTEM_PULLREQUEST_PULLREQUESTID"), "Pull request to comment (skipped when empty)")
+		buildID       = fs.String("build_id", os.Getenv("BUILD_BUILDID"), "Build to tag and attach the summary to (skipped when empty)")
+		token         = fs.String("token", os.Getenv("SYSTEM_ACCESSTOKEN"), "Access token (map $(System.AccessToken) to SYSTEM_ACCESSTOKEN)")
+		tagPrefix     = fs.String("tag_prefix", "dbt-coverage", "Prefix of the build tag")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	switch {
+	case *token == "":
+		return errors.New("missing access token, map $(System.AccessToken) to SYSTEM_ACCESSTOKEN or use --token")
+	case *collectionURI == "" || *project == "":
+		return errors.New("missing --collection_uri or --project, are we running in Azure Pipelines?")
+	}
+
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+	var summary bytes.Buffer
+	if err := renderMarkdownReport(&summary, report); err != nil {
+		return err
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + *token}
+	baseURL := strings.TrimRight(*collectionURI, "/") + "/" + url.PathEscape(*project) + "/_apis"
+	published := false
+
+	if *pullRequestID != "" {
+		if *repositoryID == "" {
+			return errors.New("missing --repository_id to comment the pull request")
+		}
+		threadURL := fmt.Sprintf("%s/git/repositories/%s/pullRequests/%s/threads?api-version=7.1",
+			baseURL, url.PathEscape(*repositoryID), url.PathEscape(*pullRequestID))
+		thread := azureThread{
+			Comments: []azureComment{{ParentCommentID: 0, Content: summary.String(), CommentType: 1}},
+			Status:   1,
+		}
+		log.Printf("Commenting pull request %s", *pullRequestID)
+		if err := postJSON(ctx, threadURL, thread, headers); err != nil {
+			return err
+		}
+		published = true
+	}
+
+	if *buildID != "" {
+		tag := fmt.Sprintf("%s-%s-%.1f", *tagPrefix, report.CovType, report.Coverage*100)
+		tagURL := fmt.Sprintf("%s/build/builds/%s/tags/%s?api-version=7.1",
+			baseURL, url.PathEscape(*buildID), url.PathEscape(tag))
+		log.Printf("Tagging build %s with %s", *buildID, tag)
+		if _, err := sendJSON(ctx, http.MethodPut, tagURL, nil, headers); err != nil {
+			return err
+		}
+
+		summaryPath := filepath.Join(envOrDefault("AGENT_TEMPDIRECTORY", os.TempDir()), "dbt-coverage-"+report.CovType+".md")
+		if err := os.WriteFile(summaryPath, summary.Bytes(), 0644); err != nil {
+			return err
+		}
+		fmt.Printf("##vso[task.uploadsummary]%s\n", summaryPath)
+		published = true
+	}
+
+	if !published {
+		return errors.New("nothing to publish, no pull request nor build found")
+	}
+	return nil
+}
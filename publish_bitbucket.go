@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const bitbucketMaxAnnotationsPerRequest = 100
+
+type bitbucketReportData struct {
+	Title string      `json:"title"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type bitbucketReport struct {
+	Title      string                `json:"title"`
+	Details    string                `json:"details"`
+	ReportType string                `json:"report_type"`
+	Reporter   string                `json:"reporter"`
+	Result     string                `json:"result"`
+	Data       []bitbucketReportData `json:"data"`
+}
+
+type bitbucketAnnotation struct {
+	ExternalID     string `json:"external_id"`
+	AnnotationType string `json:"annotation_type"`
+	Summary        string `json:"summary"`
+	Severity       string `json:"severity"`
+	Path           string `json:"path"`
+	Line           int    `json:"line,omitempty"`
+}
+
+func publishBitbucket(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish bitbucket")
+	var (
+		reportPath = fs.String("report", "coverage.json", "Coverage report to publish (JSON)")
+		workspace  = fs.String("workspace", os.Getenv("BITBUCKET_WORKSPACE"), "Bitbucket workspace")
+		repoSlug   = fs.String("repo_slug", os.Getenv("BITBUCKET_REPO_SLUG"), "Bitbucket repository slug")
+		commit     = fs.String("commit", os.Getenv("BITBUCKET_COMMIT"), "Commit the report is attached to")
+		token      = fs.String("token", os.Getenv("BITBUCKET_ACCESS_TOKEN"), "Access token (the Pipelines proxy is used when empty)")
+		failUnder  = fs.Float64("fail_under", 0, "Global coverage (%) under which the report is marked as failed")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	if *workspace == "" || *repoSlug == "" || *commit == "" {
+		return errors.New("missing --workspace, --repo_slug or --commit, are we running in Bitbucket Pipelines?")
+	}
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+
+	apiURL := "https://api.bitbucket.org/2.0"
+	headers := map[string]string{}
+	client := publishHTTPClient
+	if *token != "" {
+		headers["Authorization"] = "Bearer " + *token
+	} else {
+		apiURL = "http://api.bitbucket.org/2.0"
+		client = &http.Client{
+			Timeout:   publishHTTPClient.Timeout,
+			Transport: &http.Transport{Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: "localhost:29418"})},
+		}
+	}
+	reportID := "dbt-goverage-" + report.CovType
+	reportURL := fmt.Sprintf("%s/repositories/%s/%s/commit/%s/reports/%s", apiURL, *workspace, *repoSlug, *commit, reportID)
+
+	result := "PASSED"
+	if report.Coverage*100 < *failUnder {
+		result = "FAILED"
+	}
+	insights := bitbucketReport{
+		Title:      fmt.Sprintf("dbt %s coverage", report.CovType),
+		Details:    fmt.Sprintf("%d/%d columns covered across %d tables.", report.Covered, report.Total, len(report.Tables)),
+		ReportType: "COVERAGE",
+		Reporter:   "dbt-goverage",
+		Result:     result,
+		Data: []bitbucketReportData{
+			{Title: "Coverage", Type: "PERCENTAGE", Value: report.Coverage * 100},
+			{Title: "Covered columns", Type: "NUMBER", Value: report.Covered},
+			{Title: "Total columns", Type: "NUMBER", Value: report.Total},
+		},
+	}
+	log.Printf("Creating Code Insights report %s on commit %s", reportID, *commit)
+	if _, err := sendJSONWith(ctx, client, http.MethodPut, reportURL, insights, headers); err != nil {
+		return err
+	}
+
+	annotations := buildBitbucketAnnotations(report)
+	for start := 0; start < len(annotations); start += bitbucketMaxAnnotationsPerRequest {
+		end := min(start+bitbucketMaxAnnotationsPerRequest, len(annotations))
+		log.Printf("Sending annotations %d to %d", start+1, end)
+		if _, err := sendJSONWith(ctx, client, http.MethodPost, reportURL+"/annotations", annotations[start:end], headers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bitbucketSeverity maps the severity of a table to the Bitbucket levels,
+// MEDIUM for the reports without severities.
+func bitbucketSeverity(severity Severity) string {
+	switch severity {
+	case SeverityError:
+		return "HIGH"
+	case SeverityInfo:
+		return "LOW"
+	}
+	return "MEDIUM"
+}
+
+func buildBitbucketAnnotations(report JSONReport) []bitbucketAnnotation {
+	var annotations []bitbucketAnnotation
+	for _, t := range report.Tables {
+		path := t.SchemaFilePath()
+		if t.Covered == t.Total || path == "" {
+			continue
+		}
+		var missing []string
+		for _, c := range t.Columns {
+			if c.Covered < c.Total {
+				missing = append(missing, c.Name)
+			}
+		}
+		summary := fmt.Sprintf("%s: %s %s coverage (%d/%d), missing: %s",
+			t.Name, formatCoverage(t.Covered, t.Total), report.CovType, t.Covered, t.Total, strings.Join(missing, ", "))
+		if runes := []rune(summary); len(runes) > 450 {
+			summary = string(runes[:447]) + "..."
+		}
+		annotations = append(annotations, bitbucketAnnotation{
+			ExternalID:     fmt.Sprintf("%s-%s", report.CovType, t.Name),
+			AnnotationType: "CODE_SMELL",
+			Summary:        summary,
+			Severity:       bitbucketSeverity(t.Severity),
+			Path:           path,
+			Line:           t.SchemaLine(),
+		})
+	}
+	return annotations
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CatalogEntity carries the coverage of a model as custom properties of its
+// data catalog entity, ready for an ingestion pipeline.
+type CatalogEntity struct {
+	FQN        string                 `json:"fqn"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// catalogEntities merges the reports (one per coverage type) by model. The
+// report names must match the warehouse relations, as with --name_format
+// "{{database}}.{{schema}}.{{identifier}}".
+func catalogEntities(reports []JSONReport, fqn func(name string) string, property func(covType, field string) string, stringValues bool) []CatalogEntity {
+	byFQN := make(map[string]*CatalogEntity)
+	for _, report := range reports {
+		for _, t := range report.Tables {
+			key := fqn(t.Name)
+			entity, ok := byFQN[key]
+			if !ok {
+				entity = &CatalogEntity{FQN: key, Properties: make(map[string]interface{})}
+				byFQN[key] = entity
+			}
+			var coverage interface{} = math.Round(t.Coverage*1000) / 10
+			if stringValues {
+				coverage = fmt.Sprintf("%.1f", t.Coverage*100)
+			}
+			entity.Properties[property(report.CovType, "coverage")] = coverage
+			entity.Properties[property(report.CovType, "columns")] = fmt.Sprintf("%d/%d", t.Covered, t.Total)
+		}
+	}
+	entities := make([]CatalogEntity, 0, len(byFQN))
+	for _, e := range byFQN {
+		entities = append(entities, *e)
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].FQN < entities[j].FQN })
+	return entities
+}
+
+// propertyWords splits a coverage type such as meta:pii into words usable in
+// a property name.
+func propertyWords(covType string) []string {
+	return strings.FieldsFunc(covType, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+}
+
+// openMetadataProperty names the custom properties in camelCase:
+// dbtCoverageDoc, dbtCoverageDocColumns.
+func openMetadataProperty(covType, field string) string {
+	name := "dbtCoverage"
+	for _, w := range propertyWords(covType) {
+		name += strings.ToUpper(w[:1]) + w[1:]
+	}
+	if field != "coverage" {
+		name += strings.ToUpper(field[:1]) + field[1:]
+	}
+	return name
+}
+
+// dataHubProperty names the custom properties in snake_case:
+// dbt_coverage_doc, dbt_coverage_doc_columns.
+func dataHubProperty(covType, field string) string {
+	name := "dbt_coverage_" + strings.ToLower(strings.Join(propertyWords(covType), "_"))
+	if field != "coverage" {
+		name += "_" + field
+	}
+	return name
+}
+
+func readJSONReports(paths string) ([]JSONReport, error) {
+	var reports []JSONReport
+	for _, path := range splitList(paths) {
+		report, err := readJSONReport(path)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	if len(reports) == 0 {
+		return nil, errors.New("missing --report")
+	}
+	return reports, nil
+}
+
+func writeCatalogEntities(path string, entities []CatalogEntity) error {
+	data, err := json.MarshalIndent(entities, "", "  ")
+	if err != nil {
+		return err
+	}
+	log.Printf("Writing %d catalog entities into %s", len(entities), path)
+	return os.WriteFile(path, data, 0644)
+}
+
+func publishOpenMetadata(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish openmetadata")
+	var (
+		reportPaths = fs.String("report", "coverage.json", "Coverage reports to publish, one per type (JSON, split using ',')")
+		baseURL     = fs.String("url", envOrDefault("DBT_GOVERAGE_OPENMETADATA_URL", ""), "OpenMetadata server URL (e.g. http://localhost:8585), nothing is pushed when empty")
+		token       = fs.String("token", envOrDefault("DBT_GOVERAGE_OPENMETADATA_TOKEN", ""), "OpenMetadata bot JWT token")
+		service     = fs.String("service", "", "Database service prefixed to the model names to build the table FQN")
+		output      = fs.String("output", "", "Also write the entities and their custom properties into this JSON file")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	if *baseURL == "" && *output == "" {
+		return errors.New("nothing to do, use --url to push the coverage or --output to export it")
+	}
+	reports, err := readJSONReports(*reportPaths)
+	if err != nil {
+		return err
+	}
+	fqn := func(name string) string {
+		if *service == "" {
+			return name
+		}
+		return *service + "." + name
+	}
+	entities := catalogEntities(reports, fqn, openMetadataProperty, false)
+	if *output != "" {
+		if err := writeCatalogEntities(*output, entities); err != nil {
+			return err
+		}
+	}
+	if *baseURL == "" {
+		return nil
+	}
+	if *token == "" {
+		return errors.New("missing OpenMetadata token, use --token or DBT_GOVERAGE_OPENMETADATA_TOKEN")
+	}
+	headers := map[string]string{"Authorization": "Bearer " + *token}
+	tablesURL := strings.TrimRight(*baseURL, "/") + "/api/v1/tables/name/"
+	updated := 0
+	for _, e := range entities {
+		tableURL := tablesURL + url.PathEscape(e.FQN)
+		data, err := sendJSON(ctx, http.MethodGet, tableURL+"?fields=extension", nil, headers)
+		if err != nil {
+			log.Printf("warning: table %s skipped: %v", e.FQN, err)
+			continue
+		}
+		var table struct {
+			Extension map[string]interface{} `json:"extension"`
+		}
+		if err := json.Unmarshal(data, &table); err != nil {
+			return fmt.Errorf("invalid OpenMetadata table %s: %w", e.FQN, err)
+		}
+		extension := table.Extension
+		if extension == nil {
+			extension = make(map[string]interface{})
+		}
+		for k, v := range e.Properties {
+			extension[k] = v
+		}
+		patch := []map[string]interface{}{{"op": "add", "path": "/extension", "value": extension}}
+		patchHeaders := map[string]string{"Authorization": headers["Authorization"], "Content-Type": "application/json-patch+json"}
+		if _, err := sendJSON(ctx, http.MethodPatch, tableURL, patch, patchHeaders); err != nil {
+			return err
+		}
+		updated++
+	}
+	fmt.Printf("Coverage pushed to %d OpenMetadata tables (%d skipped)\n", updated, len(entities)-updated)
+	return nil
+}
+
+func publishDataHub(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish datahub")
+	var (
+		reportPaths = fs.String("report", "coverage.json", "Coverage reports to publish, one per type (JSON, split using ',')")
+		gmsURL      = fs.String("url", envOrDefault("DATAHUB_GMS_URL", ""), "DataHub GMS URL (e.g. http://localhost:8080), nothing is pushed when empty")
+		token       = fs.String("token", envOrDefault("DATAHUB_GMS_TOKEN", ""), "DataHub personal access token")
+		platform    = fs.String("platform", "", "Data platform of the datasets (snowflake, bigquery, postgres...)")
+		env         = fs.String("env", "PROD", "Environment (fabric) of the datasets")
+		lowercase   = fs.Bool("lowercase", true, "Lowercase the dataset names, as the DataHub ingestion does by default")
+		output      = fs.String("output", "", "Also write the entities and their custom properties into this JSON file")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	switch {
+	case *gmsURL == "" && *output == "":
+		return errors.New("nothing to do, use --url to push the coverage or --output to export it")
+	case *platform == "":
+		return errors.New("missing --platform, needed to build the dataset URNs")
+	}
+	reports, err := readJSONReports(*reportPaths)
+	if err != nil {
+		return err
+	}
+	urn := func(name string) string {
+		if *lowercase {
+			name = strings.ToLower(name)
+		}
+		return fmt.Sprintf("urn:li:dataset:(urn:li:dataPlatform:%s,%s,%s)", *platform, name, *env)
+	}
+	entities := catalogEntities(reports, urn, dataHubProperty, true)
+	if *output != "" {
+		if err := writeCatalogEntities(*output, entities); err != nil {
+			return err
+		}
+	}
+	if *gmsURL == "" {
+		return nil
+	}
+	headers := map[string]string{}
+	if *token != "" {
+		headers["Authorization"] = "Bearer " + *token
+	}
+	ingestURL := strings.TrimRight(*gmsURL, "/") + "/aspects?action=ingestProposal"
+	for _, e := range entities {
+		// A PATCH proposal only touches our custom properties, keeping the
+		// description and the properties set by the other ingestions.
+		var ops []map[string]interface{}
+		for _, k := range sortedKeys(e.Properties) {
+			ops = append(ops, map[string]interface{}{"op": "add", "path": "/customProperties/" + k, "value": e.Properties[k]})
+		}
+		value, err := json.Marshal(ops)
+		if err != nil {
+			return err
+		}
+		proposal := map[string]interface{}{"proposal": map[string]interface{}{
+			"entityType": "dataset",
+			"entityUrn":  e.FQN,
+			"changeType": "PATCH",
+			"aspectName": "datasetProperties",
+			"aspect":     map[string]string{"contentType": "application/json-patch+json", "value": string(value)},
+		}}
+		if err := postJSON(ctx, ingestURL, proposal, headers); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Coverage pushed to %d DataHub datasets\n", len(entities))
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func publishTeamCity(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish teamcity")
+	var (
+		reportPath = fs.String("report", "coverage.json", "Coverage report to publish (JSON)")
+		prefix     = fs.String("key_prefix", "dbtCoverage", "Prefix of the statistic keys")
+		perModel   = fs.Bool("per_model", false, "Also emit one statistic per model")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+	writeTeamCityMessages(os.Stdout, report, *prefix, *perModel)
+	return nil
+}
+
+func writeTeamCityMessages(w io.Writer, report JSONReport, prefix string, perModel bool) {
+	key := prefix + "." + report.CovType
+	stat := func(k string, v string) {
+		fmt.Fprintf(w, "##teamcity[buildStatisticValue key='%s' value='%s']\n", escapeTeamCity(k), escapeTeamCity(v))
+	}
+	stat(key, fmt.Sprintf("%.2f", report.Coverage*100))
+	stat(key+".covered", fmt.Sprintf("%d", report.Covered))
+	stat(key+".total", fmt.Sprintf("%d", report.Total))
+	for _, g := range report.Groups {
+		stat(key+"."+report.GroupBy+"."+g.Name, fmt.Sprintf("%.2f", g.Coverage*100))
+	}
+	if perModel {
+		for _, t := range report.Tables {
+			stat(key+"."+t.Name, fmt.Sprintf("%.2f", t.Coverage*100))
+		}
+	}
+	fmt.Fprintf(w, "##teamcity[buildStatus text='{build.status.text}, %s coverage: %s']\n",
+		escapeTeamCity(report.CovType), escapeTeamCity(formatCoverage(report.Covered, report.Total)))
+}
+
+func escapeTeamCity(s string) string {
+	return strings.NewReplacer(
+		"|", "||",
+		"'", "|'",
+		"\n", "|n",
+		"\r", "|r",
+		"[", "|[",
+		"]", "|]",
+	).Replace(s)
+}
+
+func publishBuildkite(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish buildkite")
+	var (
+		reportPath  = fs.String("report", "coverage.json", "Coverage report to publish (JSON)")
+		contextName = fs.String("context", "", "Annotation context (defaults to dbt-coverage-<type>)")
+		agent       = fs.String("agent", "buildkite-agent", "Path to the buildkite-agent binary")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := renderMarkdownReport(&body, report); err != nil {
+		return err
+	}
+	annotationContext := *contextName
+	if annotationContext == "" {
+		annotationContext = "dbt-coverage-" + report.CovType
+	}
+	style := "success"
+	switch {
+	case report.Coverage < 0.5:
+		style = "error"
+	case report.Coverage < 0.8:
+		style = "warning"
+	}
+	log.Printf("Annotating the Buildkite build (context %s, style %s)", annotationContext, style)
+	cmd := exec.CommandContext(ctx, *agent, "annotate", "--context", annotationContext, "--style", style)
+	cmd.Stdin = &body
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type confluenceStorage struct {
+	Value          string `json:"value"`
+	Representation string `json:"representation"`
+}
+
+type confluenceVersion struct {
+	Number int `json:"number"`
+}
+
+type confluenceAncestor struct {
+	ID string `json:"id"`
+}
+
+type confluenceSpace struct {
+	Key string `json:"key"`
+}
+
+type confluencePage struct {
+	ID        string               `json:"id,omitempty"`
+	Type      string               `json:"type"`
+	Title     string               `json:"title"`
+	Space     *confluenceSpace     `json:"space,omitempty"`
+	Ancestors []confluenceAncestor `json:"ancestors,omitempty"`
+	Version   *confluenceVersion   `json:"version,omitempty"`
+	Body      struct {
+		Storage confluenceStorage `json:"storage"`
+	} `json:"body"`
+}
+
+func publishConfluence(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish confluence")
+	var (
+		reportPath = fs.String("report", "coverage.json", "Coverage report to publish (JSON)")
+		baseURL    = fs.String("base_url", envOrDefault("DBT_GOVERAGE_CONFLUENCE_URL", ""), "Confluence base URL (e.g. https://acme.atlassian.net/wiki)")
+		username   = fs.String("username", envOrDefault("DBT_GOVERAGE_CONFLUENCE_USERNAME", ""), "Confluence user (basic auth with an API token)")
+		token      = fs.String("token", envOrDefault("DBT_GOVERAGE_CONFLUENCE_TOKEN", ""), "Confluence API token or personal access token")
+		pageID     = fs.String("page_id", "", "Page to update (a new page is created when empty)")
+		spaceKey   = fs.String("space_key", "", "Space of the page to create")
+		parentID   = fs.String("parent_id", "", "Parent of the page to create")
+		title      = fs.String("title", "", "Page title (defaults to the current title when updating)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	switch {
+	case *baseURL == "":
+		return errors.New("missing Confluence URL, use --base_url or DBT_GOVERAGE_CONFLUENCE_URL")
+	case *token == "":
+		return errors.New("missing Confluence token, use --token or DBT_GOVERAGE_CONFLUENCE_TOKEN")
+	case *pageID == "" && *spaceKey == "":
+		return errors.New("either --page_id (update) or --space_key (create) is required")
+	}
+
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "<h2>📊 Coverage Report (%s)</h2>\n", strings.ToUpper(report.CovType))
+	if err := renderHTMLTables(&body, report); err != nil {
+		return err
+	}
+
+	headers := map[string]string{"Authorization": confluenceAuthorization(*username, *token)}
+	apiURL := strings.TrimRight(*baseURL, "/") + "/rest/api/content"
+
+	page := confluencePage{Type: "page", Title: *title}
+	page.Body.Storage = confluenceStorage{Value: body.String(), Representation: "storage"}
+
+	if *pageID == "" {
+		if page.Title == "" {
+			page.Title = fmt.Sprintf("dbt coverage report (%s)", report.CovType)
+		}
+		page.Space = &confluenceSpace{Key: *spaceKey}
+		if *parentID != "" {
+			page.Ancestors = []confluenceAncestor{{ID: *parentID}}
+		}
+		log.Printf("Creating Confluence page %q in space %s", page.Title, *spaceKey)
+		data, err := sendJSON(ctx, http.MethodPost, apiURL, page, headers)
+		if err != nil {
+			return err
+		}
+		var created confluencePage
+		if err := json.Unmarshal(data, &created); err != nil {
+			return fmt.Errorf("invalid Confluence response for the created page: %w", err)
+		}
+		fmt.Printf("Confluence page %s created\n", created.ID)
+		return nil
+	}
+
+	pageURL := apiURL + "/" + url.PathEscape(*pageID)
+	data, err := sendJSON(ctx, http.MethodGet, pageURL+"?expand=version", nil, headers)
+	if err != nil {
+		return err
+	}
+	var current confluencePage
+	if err := json.Unmarshal(data, &current); err != nil {
+		return fmt.Errorf("invalid Confluence response for page %s: %w", *pageID, err)
+	}
+	if current.Version == nil {
+		return fmt.Errorf("version of Confluence page %s not found", *pageID)
+	}
+	page.ID = *pageID
+	if page.Title == "" {
+		page.Title = current.Title
+	}
+	page.Version = &confluenceVersion{Number: current.Version.Number + 1}
+	log.Printf("Updating Confluence page %s (version %d)", *pageID, page.Version.Number)
+	_, err = sendJSON(ctx, http.MethodPut, pageURL, page, headers)
+	return err
+}
+
+func confluenceAuthorization(username, token string) string {
+	if username == "" {
+		return "Bearer " + token
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+token))
+}
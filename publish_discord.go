@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	discordColorGreen  = 0x2ecc71
+	discordColorOrange = 0xe67e22
+	discordColorRed    = 0xe74c3c
+)
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields,omitempty"`
+}
+
+type discordMessage struct {
+	Username string         `json:"username,omitempty"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+func publishDiscord(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish discord")
+	var (
+		reportPath = fs.String("report", "coverage.json", "Coverage report to send (JSON)")
+		webhookURL = fs.String("webhook_url", envOrDefault("DBT_GOVERAGE_DISCORD_WEBHOOK_URL", ""), "Discord webhook URL")
+		username   = fs.String("username", "dbt-goverage", "Name displayed as the message author")
+		worst      = fs.Int("worst", 5, "Number of least covered models listed in the summary")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	if *webhookURL == "" {
+		return errors.New("missing Discord webhook, use --webhook_url or DBT_GOVERAGE_DISCORD_WEBHOOK_URL")
+	}
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+	log.Printf("Sending coverage summary to Discord")
+	return postJSON(ctx, *webhookURL, buildDiscordMessage(report, *username, *worst), nil)
+}
+
+func buildDiscordMessage(report JSONReport, username string, worst int) discordMessage {
+	color := discordColorGreen
+	switch {
+	case report.Coverage < 0.5:
+		color = discordColorRed
+	case report.Coverage < 0.8:
+		color = discordColorOrange
+	}
+	embed := discordEmbed{
+		Title: fmt.Sprintf("📊 Coverage Report (%s)", strings.ToUpper(report.CovType)),
+		Color: color,
+		Fields: []discordField{
+			{Name: "Coverage", Value: formatCoverage(report.Covered, report.Total), Inline: true},
+			{Name: "Columns", Value: fmt.Sprintf("%d/%d", report.Covered, report.Total), Inline: true},
+			{Name: "Tables", Value: fmt.Sprintf("%d", len(report.Tables)), Inline: true},
+		},
+	}
+	if worst > 0 && len(report.Tables) > 0 {
+		var lines []string
+		for _, t := range lowestCoverageTables(report, worst) {
+			lines = append(lines, fmt.Sprintf("`%s` %s (%d/%d)", t.Name, formatCoverage(t.Covered, t.Total), t.Covered, t.Total))
+		}
+		embed.Fields = append(embed.Fields, discordField{Name: "Least covered models", Value: strings.Join(lines, "\n")})
+	}
+	return discordMessage{Username: username, Embeds: []discordEmbed{embed}}
+}
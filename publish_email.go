@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+func publishEmail(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish email")
+	var (
+		reportPath = fs.String("report", "coverage.json", "Coverage report to send (JSON)")
+		format     = fs.String("format", "html", "Email body format (html or markdown)")
+		host       = fs.String("smtp_host", envOrDefault("DBT_GOVERAGE_SMTP_HOST", ""), "SMTP server host")
+		port       = fs.String("smtp_port", envOrDefault("DBT_GOVERAGE_SMTP_PORT", "587"), "SMTP server port")
+		username   = fs.String("smtp_username", envOrDefault("DBT_GOVERAGE_SMTP_USERNAME", ""), "SMTP username")
+		password   = fs.String("smtp_password", envOrDefault("DBT_GOVERAGE_SMTP_PASSWORD", ""), "SMTP password")
+		from       = fs.String("from", envOrDefault("DBT_GOVERAGE_EMAIL_FROM", ""), "Sender address")
+		to         = fs.String("to", envOrDefault("DBT_GOVERAGE_EMAIL_TO", ""), "Recipients (split using ',')")
+		subject    = fs.String("subject", "dbt coverage report", "Email subject")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	recipients := splitList(*to)
+	switch {
+	case *host == "":
+		return errors.New("missing SMTP host, use --smtp_host or DBT_GOVERAGE_SMTP_HOST")
+	case *from == "":
+		return errors.New("missing sender, use --from or DBT_GOVERAGE_EMAIL_FROM")
+	case len(recipients) == 0:
+		return errors.New("missing recipients, use --to or DBT_GOVERAGE_EMAIL_TO")
+	}
+
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	contentType := "text/html"
+	switch CoverageFormat(*format) {
+	case FormatHTMLReport:
+		err = renderHTMLReport(&body, report, nil)
+	case FormatMarkdownTable:
+		contentType = "text/markdown"
+		err = renderMarkdownReport(&body, report)
+	default:
+		return fmt.Errorf("unsupported email format %q (html or markdown)", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	msg := buildEmailMessage(*from, recipients, fmt.Sprintf("%s (%s %s)", *subject, report.CovType, formatCoverage(report.Covered, report.Total)), contentType, body.Bytes())
+	var auth smtp.Auth
+	if *username != "" {
+		auth = smtp.PlainAuth("", *username, *password, *host)
+	}
+	log.Printf("Sending %s report to %s through %s:%s", *format, strings.Join(recipients, ", "), *host, *port)
+	return sendMail(ctx, net.JoinHostPort(*host, *port), *host, auth, *from, recipients, msg)
+}
+
+func sendMail(ctx context.Context, addr, host string, auth smtp.Auth, from string, to []string, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func buildEmailMessage(from string, to []string, subject, contentType string, body []byte) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	// RFC 2047: the headers are ASCII, the emoji and accents are Q-encoded.
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s; charset=UTF-8\r\n", contentType)
+	msg.WriteString("\r\n")
+	msg.Write(body)
+	return msg.Bytes()
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+const githubMaxAnnotationsPerRequest = 50
+
+type githubAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+}
+
+type githubCheckOutput struct {
+	Title       string             `json:"title"`
+	Summary     string             `json:"summary"`
+	Annotations []githubAnnotation `json:"annotations,omitempty"`
+}
+
+type githubCheckRun struct {
+	Name       string            `json:"name,omitempty"`
+	HeadSHA    string            `json:"head_sha,omitempty"`
+	Status     string            `json:"status,omitempty"`
+	Conclusion string            `json:"conclusion,omitempty"`
+	Output     githubCheckOutput `json:"output"`
+}
+
+func publishGitHubChecks(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish github-checks")
+	var (
+		reportPath = fs.String("report", "coverage.json", "Coverage report to publish (JSON)")
+		apiURL     = fs.String("api_url", envOrDefault("GITHUB_API_URL", "https://api.github.com"), "GitHub API URL")
+		repository = fs.String("repository", os.Getenv("GITHUB_REPOSITORY"), "Repository (owner/name)")
+		sha        = fs.String("sha", githubHeadSHA(), "Commit the check run is attached to")
+		token      = fs.String("token", os.Getenv("GITHUB_TOKEN"), "Token with the checks:write permission")
+		name       = fs.String("name", "", "Check run name (defaults to dbt <type> coverage)")
+		threshold  = fs.Float64("threshold", 100, "Model coverage (%) under which an annotation is created")
+		failUnder  = fs.Float64("fail_under", 0, "Global coverage (%) under which the check run fails")
+		projectDir = fs.String("dbt_dir", ".", "dbt project path, the annotations being located from the repository root")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	switch {
+	case *token == "":
+		return errors.New("missing GitHub token, use --token or GITHUB_TOKEN")
+	case *repository == "" || *sha == "":
+		return errors.New("missing --repository or --sha, are we running in GitHub Actions?")
+	}
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+
+	var summary bytes.Buffer
+	if err := renderMarkdownReport(&summary, report); err != nil {
+		return err
+	}
+	conclusion := "success"
+	if report.Coverage*100 < *failUnder {
+		conclusion = "failure"
+	}
+	checkName := *name
+	if checkName == "" {
+		checkName = fmt.Sprintf("dbt %s coverage", report.CovType)
+	}
+	output := githubCheckOutput{
+		Title:   fmt.Sprintf("%s %s coverage", formatCoverage(report.Covered, report.Total), report.CovType),
+		Summary: summary.String(),
+	}
+	annotations := buildGitHubAnnotations(report, *threshold, repositoryPrefix(ctx, *projectDir))
+	first := min(len(annotations), githubMaxAnnotationsPerRequest)
+	output.Annotations = annotations[:first]
+
+	headers := map[string]string{
+		"Authorization":        "Bearer " + *token,
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	checkRunsURL := fmt.Sprintf("%s/repos/%s/check-runs", strings.TrimRight(*apiURL, "/"), *repository)
+	log.Printf("Creating check run %q on %s with %d annotations", checkName, *sha, len(annotations))
+	data, err := sendJSON(ctx, http.MethodPost, checkRunsURL, githubCheckRun{
+		Name:       checkName,
+		HeadSHA:    *sha,
+		Status:     "completed",
+		Conclusion: conclusion,
+		Output:     output,
+	}, headers)
+	if err != nil {
+		return err
+	}
+	var created struct {
+		ID      int64  `json:"id"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(data, &created); err != nil {
+		return fmt.Errorf("invalid GitHub response: %w", err)
+	}
+	for start := first; start < len(annotations); start += githubMaxAnnotationsPerRequest {
+		end := min(start+githubMaxAnnotationsPerRequest, len(annotations))
+		output.Annotations = annotations[start:end]
+		if _, err := sendJSON(ctx, http.MethodPatch, fmt.Sprintf("%s/%d", checkRunsURL, created.ID), githubCheckRun{Output: output}, headers); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Check run created: %s\n", created.HTMLURL)
+	return nil
+}
+
+// buildGitHubAnnotations annotates the models under threshold. The paths of the
+// report are relative to the dbt project, prefix (its directory in the
+// repository) makes them relative to the repository root as GitHub expects.
+func buildGitHubAnnotations(report JSONReport, threshold float64, prefix string) []githubAnnotation {
+	var annotations []githubAnnotation
+	for _, t := range report.Tables {
+		file := t.SchemaFilePath()
+		if file == "" || t.Coverage*100 >= threshold {
+			continue
+		}
+		var missing []string
+		for _, c := range t.Columns {
+			if c.Covered < c.Total {
+				missing = append(missing, c.Name)
+			}
+		}
+		annotations = append(annotations, githubAnnotation{
+			Path:            path.Join(prefix, slashPath(file)),
+			StartLine:       t.SchemaLine(),
+			EndLine:         t.SchemaLine(),
+			AnnotationLevel: githubAnnotationLevel(t.Severity),
+			Title:           fmt.Sprintf("%s: %s %s coverage", t.Name, formatCoverage(t.Covered, t.Total), report.CovType),
+			Message:         fmt.Sprintf("Columns without %s: %s", report.CovType, strings.Join(missing, ", ")),
+		})
+	}
+	return annotations
+}
+
+// githubAnnotationLevel maps the severity of a table to the GitHub levels,
+// warning for the reports without severities.
+func githubAnnotationLevel(severity Severity) string {
+	switch severity {
+	case SeverityError:
+		return "failure"
+	case SeverityInfo:
+		return "notice"
+	}
+	return "warning"
+}
+
+// repositoryPrefix is the directory of the dbt project in its git repository,
+// empty at the root or outside of a repository.
+func repositoryPrefix(ctx context.Context, projectDir string) string {
+	out, err := exec.CommandContext(ctx, "git", "-C", projectDir, "rev-parse", "--show-prefix").Output()
+	if err != nil {
+		log.Printf("Cannot locate %s in a git repository, annotation paths are left relative to the project: %v", projectDir, err)
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func githubHeadSHA() string {
+	if eventPath := os.Getenv("GITHUB_EVENT_PATH"); eventPath != "" {
+		if data, err := os.ReadFile(eventPath); err == nil {
+			var event struct {
+				PullRequest struct {
+					Head struct {
+						SHA string `json:"sha"`
+					} `json:"head"`
+				} `json:"pull_request"`
+			}
+			if json.Unmarshal(data, &event) == nil && event.PullRequest.Head.SHA != "" {
+				return event.PullRequest.Head.SHA
+			}
+		}
+	}
+	return os.Getenv("GITHUB_SHA")
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+func publishSlack(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish slack")
+	var (
+		reportPath = fs.String("report", "coverage.json", "Coverage report to send (JSON)")
+		webhookURL = fs.String("webhook_url", envOrDefault("DBT_GOVERAGE_SLACK_WEBHOOK_URL", ""), "Slack incoming webhook URL (bound to a channel)")
+		title      = fs.String("title", "", "Title of the message (defaults to Coverage Report (<type>))")
+		worst      = fs.Int("worst", 5, "Number of least covered models listed in the summary")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	if *webhookURL == "" {
+		return errors.New("missing Slack webhook, use --webhook_url or DBT_GOVERAGE_SLACK_WEBHOOK_URL")
+	}
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+	log.Printf("Sending coverage summary to Slack")
+	return postJSON(ctx, *webhookURL, buildSlackMessage(report, *title, *worst), nil)
+}
+
+func buildSlackMessage(report JSONReport, title string, worst int) slackMessage {
+	if title == "" {
+		title = fmt.Sprintf("Coverage Report (%s)", strings.ToUpper(report.CovType))
+	}
+	summary := fmt.Sprintf("*%s* covered (%d/%d) across %d tables", formatCoverage(report.Covered, report.Total), report.Covered, report.Total, len(report.Tables))
+	msg := slackMessage{
+		Text: fmt.Sprintf("%s: %s", title, formatCoverage(report.Covered, report.Total)),
+		Blocks: []slackBlock{
+			{Type: "header", Text: &slackText{Type: "plain_text", Text: "📊 " + title}},
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: summary}},
+		},
+	}
+	if worst > 0 && len(report.Tables) > 0 {
+		lines := []string{"*Least covered models*"}
+		for _, t := range lowestCoverageTables(report, worst) {
+			lines = append(lines, fmt.Sprintf("• `%s` %s (%d/%d)", t.Name, formatCoverage(t.Covered, t.Total), t.Covered, t.Total))
+		}
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: strings.Join(lines, "\n")}})
+	}
+	return msg
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// objectStore uploads the rendered reports to a bucket.
+type objectStore interface {
+	put(ctx context.Context, key, contentType string, body []byte) error
+}
+
+type storageObject struct {
+	Name        string
+	ContentType string
+	Body        []byte
+}
+
+// publishObjectStorage uploads the JSON report, an HTML page and a coverage
+// badge under an s3:// or gs:// prefix, so a static site can be served from
+// the bucket.
+func publishObjectStorage(ctx context.Context, target string, args []string) error {
+	fs, common := newFlagSet("publish " + target)
+	var (
+		reportPath   = fs.String("report", "coverage.json", "Coverage report to publish (JSON)")
+		htmlPath     = fs.String("html", "", "HTML report to upload as index.html (rendered from the report when empty)")
+		badge        = fs.Bool("badge", true, "Also upload a badge.svg coverage badge")
+		cacheControl = fs.String("cache_control", "no-cache", "Cache-Control of the uploaded objects")
+		endpoint     = fs.String("endpoint", "", "Storage endpoint (S3-compatible storage or tests; defaults to AWS or Google Cloud Storage)")
+		region       = fs.String("region", envOrDefault("AWS_REGION", envOrDefault("AWS_DEFAULT_REGION", "us-east-1")), "AWS region of the S3 bucket")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid target %q, expected s3://bucket/prefix/ or gs://bucket/prefix/", target)
+	}
+	bucket, prefix := u.Host, strings.TrimPrefix(u.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	var store objectStore
+	switch u.Scheme {
+	case "s3":
+		store, err = newS3Store(bucket, *region, *endpoint, *cacheControl)
+	case "gs":
+		store, err = newGCSStore(ctx, bucket, *endpoint, *cacheControl)
+	default:
+		err = fmt.Errorf("unsupported storage %q, expected s3:// or gs://", u.Scheme)
+	}
+	if err != nil {
+		return err
+	}
+
+	objects, err := storageObjects(*reportPath, *htmlPath, *badge)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		log.Printf("Uploading %s://%s/%s%s (%s)", u.Scheme, bucket, prefix, obj.Name, obj.ContentType)
+		if err := store.put(ctx, prefix+obj.Name, obj.ContentType, obj.Body); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%d files published to %s://%s/%s\n", len(objects), u.Scheme, bucket, prefix)
+	return nil
+}
+
+func storageObjects(reportPath, htmlPath string, badge bool) ([]storageObject, error) {
+	report, err := readJSONReport(reportPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, err
+	}
+	objects := []storageObject{{Name: "coverage-" + report.CovType + ".json", ContentType: "application/json", Body: data}}
+
+	var page bytes.Buffer
+	if htmlPath != "" {
+		data, err := os.ReadFile(htmlPath)
+		if err != nil {
+			return nil, err
+		}
+		page.Write(data)
+	} else if err := renderHTMLReport(&page, report, nil); err != nil {
+		return nil, err
+	}
+	objects = append(objects, storageObject{Name: "index.html", ContentType: "text/html; charset=utf-8", Body: page.Bytes()})
+
+	if badge {
+		var svg bytes.Buffer
+		renderBadge(&svg, report)
+		objects = append(objects, storageObject{Name: "badge-" + report.CovType + ".svg", ContentType: "image/svg+xml", Body: svg.Bytes()})
+	}
+	return objects, nil
+}
+
+// renderBadge writes a shields.io-like badge, colored like the Buildkite
+// annotations.
+func renderBadge(w io.Writer, report JSONReport) {
+	label := report.CovType + " coverage"
+	value := formatCoverage(report.Covered, report.Total)
+	color := "#4c1"
+	switch {
+	case report.Coverage < 0.5:
+		color = "#e05d44"
+	case report.Coverage < 0.8:
+		color = "#dfb317"
+	}
+	labelWidth, valueWidth := 7*len(label)+10, 7*len(value)+10
+	width := labelWidth + valueWidth
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">
+<rect width="%d" height="20" fill="#555"/>
+<rect x="%d" width="%d" height="20" fill="%s"/>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,DejaVu Sans,sans-serif" font-size="11">
+<text x="%d" y="14">%s</text>
+<text x="%d" y="14">%s</text>
+</g>
+</svg>
+`, width, label, value, labelWidth, labelWidth, valueWidth, color, labelWidth/2, label, labelWidth+valueWidth/2, value)
+}
+
+// s3Store signs its requests with AWS Signature Version 4, using the
+// standard AWS_* environment credentials.
+type s3Store struct {
+	bucket, region, endpoint, cacheControl string
+	accessKey, secretKey, sessionToken     string
+	now                                    func() time.Time
+}
+
+func newS3Store(bucket, region, endpoint, cacheControl string) (*s3Store, error) {
+	s := &s3Store{
+		bucket:       bucket,
+		region:       region,
+		endpoint:     strings.TrimRight(endpoint, "/"),
+		cacheControl: cacheControl,
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		now:          time.Now,
+	}
+	if s.endpoint == "" {
+		s.endpoint = strings.TrimRight(os.Getenv("AWS_ENDPOINT_URL_S3"), "/")
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, errors.New("missing AWS credentials, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return s, nil
+}
+
+func (s *s3Store) objectURL(key string) string {
+	escaped := "/" + awsEscapePath(key)
+	if s.endpoint != "" {
+		return s.endpoint + "/" + s.bucket + escaped
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com%s", s.bucket, s.region, escaped)
+}
+
+func (s *s3Store) put(ctx context.Context, key, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.cacheControl != "" {
+		req.Header.Set("Cache-Control", s.cacheControl)
+	}
+	s.sign(req, body)
+	return doStorageRequest(req)
+}
+
+func (s *s3Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method, req.URL.EscapedPath(), req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+// gcsStore uploads through the XML API of Google Cloud Storage with an OAuth
+// access token.
+type gcsStore struct {
+	bucket, endpoint, cacheControl, token string
+}
+
+func newGCSStore(ctx context.Context, bucket, endpoint, cacheControl string) (*gcsStore, error) {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		out, err := exec.CommandContext(ctx, "gcloud", "auth", "print-access-token").Output()
+		if err != nil {
+			return nil, errors.New("missing Google Cloud credentials, set GOOGLE_OAUTH_ACCESS_TOKEN or log in with gcloud")
+		}
+		token = strings.TrimSpace(string(out))
+	}
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	return &gcsStore{bucket: bucket, endpoint: strings.TrimRight(endpoint, "/"), cacheControl: cacheControl, token: token}, nil
+}
+
+func (s *gcsStore) put(ctx context.Context, key, contentType string, body []byte) error {
+	objectURL := s.endpoint + "/" + s.bucket + (&url.URL{Path: path.Join("/", key)}).EscapedPath()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Content-Type", contentType)
+	if s.cacheControl != "" {
+		req.Header.Set("Cache-Control", s.cacheControl)
+	}
+	return doStorageRequest(req)
+}
+
+func doStorageRequest(req *http.Request) error {
+	resp, err := publishHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("PUT %s returned %s: %s", req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// awsEscapePath encodes everything but the unreserved characters and the
+// slashes, as Signature Version 4 expects.
+func awsEscapePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
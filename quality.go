@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+var defaultQualityWeights = map[string]float64{
+	string(CoverageTypeDoc):         1,
+	string(CoverageTypeTest):        1,
+	string(CoverageTypeDescription): 1,
+	string(CoverageTypeContract):    1,
+}
+
+type QualityScoreConfig struct {
+	Weights map[string]float64 `yaml:"weights"`
+}
+
+func (c QualityScoreConfig) validate() error {
+	for component, weight := range c.Weights {
+		if weight < 0 {
+			return fmt.Errorf("quality_score weight of %s must be positive", component)
+		}
+	}
+	return nil
+}
+
+func computeQualityScores(ctx context.Context, catalog Catalog, weights map[string]float64) error {
+	components := make([]string, 0, len(weights))
+	for component := range weights {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		if err := evaluateCoverage(ctx, catalog, CoverageType(component)); err != nil {
+			return fmt.Errorf("quality score: %w", err)
+		}
+	}
+
+	for id, table := range catalog.Tables {
+		sum, totalWeight := 0.0, 0.0
+		for _, component := range components {
+			weight := weights[component]
+			covered, total := tableLevelCoverage(table, CoverageType(component))
+			if total == 0 {
+				for _, col := range table.Columns {
+					isCovered, applicable := col.Coverage[CoverageType(component)]
+					if !applicable {
+						continue
+					}
+					total++
+					if isCovered {
+						covered++
+					}
+				}
+			}
+			if total == 0 {
+				continue
+			}
+			sum += weight * ratio(covered, total)
+			totalWeight += weight
+		}
+		if totalWeight == 0 {
+			continue
+		}
+		score := sum / totalWeight
+		table.QualityScore = &score
+		catalog.Tables[id] = table
+	}
+	return nil
+}
+
+func averageQualityScore(catalog Catalog) *float64 {
+	sum, count := 0.0, 0
+	for _, table := range catalog.Tables {
+		if table.QualityScore != nil {
+			sum += *table.QualityScore
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	avg := sum / float64(count)
+	return &avg
+}
+
+func averageScore(rows []TableCoverage) *float64 {
+	sum, count := 0.0, 0
+	for _, r := range rows {
+		if r.Score != nil {
+			sum += *r.Score
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	avg := sum / float64(count)
+	return &avg
+}
+
+func formatScore(score *float64) string {
+	if score == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", *score*100)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const ReportFormatRDJSON = "rdjson"
+
+// The Reviewdog Diagnostic Format, read by reviewdog -f=rdjson:
+// https://github.com/reviewdog/reviewdog/tree/master/proto/rdf
+type rdjsonPosition struct {
+	Line int `json:"line"`
+}
+
+type rdjsonDiagnostic struct {
+	Message  string `json:"message"`
+	Location struct {
+		Path  string `json:"path"`
+		Range struct {
+			Start rdjsonPosition `json:"start"`
+		} `json:"range"`
+	} `json:"location"`
+	Severity string `json:"severity"`
+	Code     struct {
+		Value string `json:"value"`
+		URL   string `json:"url,omitempty"`
+	} `json:"code"`
+}
+
+type rdjsonOutput struct {
+	Source struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"source"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+// rdjsonSeverity maps the severity of a gap to the reviewdog levels, warning
+// for the reports without severities.
+func rdjsonSeverity(severity Severity) string {
+	if severity == "" {
+		severity = SeverityWarning
+	}
+	return strings.ToUpper(string(severity))
+}
+
+// renderRDJSONReport writes one reviewdog diagnostic per gap, on the schema
+// file of the model, so reviewdog comments the pull requests like for any
+// other linter. Models without a file are left out.
+func renderRDJSONReport(w io.Writer, report JSONReport) error {
+	var out rdjsonOutput
+	out.Source.Name = "dbt-goverage"
+	out.Source.URL = "https://github.com/mickaelandrieu/dbt-goverage"
+	out.Diagnostics = []rdjsonDiagnostic{}
+	for _, gap := range coverageGaps(report.Tables) {
+		path := gap.Table.SchemaFilePath()
+		if path == "" {
+			continue
+		}
+		var d rdjsonDiagnostic
+		d.Message = gap.Message(report.CovType)
+		d.Location.Path = slashPath(path)
+		d.Location.Range.Start.Line = gap.Line
+		d.Severity = rdjsonSeverity(gap.Severity)
+		d.Code.Value = coverageRuleID(report.CovType)
+		d.Code.URL = "https://github.com/mickaelandrieu/dbt-goverage#readme"
+		out.Diagnostics = append(out.Diagnostics, d)
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
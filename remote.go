@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isRemoteTarget tells whether --target_dir is the URL of a directory serving
+// the artifacts (a bucket behind HTTPS, an artifact server...).
+func isRemoteTarget(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+func defaultArtifactCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "dbt-goverage", "artifacts")
+}
+
+// artifactCache downloads remote artifacts into a local directory, one
+// sub-directory per target URL, and revalidates them with conditional
+// requests (If-None-Match, If-Modified-Since): an unchanged 500MB manifest
+// costs a 304 instead of a download.
+type artifactCache struct {
+	dir     string
+	baseURL string
+	headers map[string]string
+	client  *http.Client
+}
+
+type cachedArtifact struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// newArtifactCache caches the artifacts of baseURL under cacheDir. The
+// DBT_GOVERAGE_ARTIFACTS_TOKEN variable, when set, is sent as a bearer token.
+func newArtifactCache(cacheDir, baseURL string) *artifactCache {
+	sum := sha256.Sum256([]byte(baseURL))
+	c := &artifactCache{
+		dir:     filepath.Join(cacheDir, hex.EncodeToString(sum[:8])),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		headers: make(map[string]string),
+		client:  &http.Client{},
+	}
+	if token := os.Getenv("DBT_GOVERAGE_ARTIFACTS_TOKEN"); token != "" {
+		c.headers["Authorization"] = "Bearer " + token
+	}
+	return c
+}
+
+// Fetch brings the cached copy of the artifact name up to date and returns
+// its path, changed being false when the server answered 304 Not Modified.
+// A 404 is not an error: the artifact is then absent from the cache, as
+// catalog.json is absent from target when dbt docs generate did not run.
+func (c *artifactCache) Fetch(ctx context.Context, name string) (path string, changed bool, err error) {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return "", false, err
+	}
+	path = filepath.Join(c.dir, name)
+	metaPath := path + ".meta.json"
+	url := c.baseURL + "/" + name
+	var meta cachedArtifact
+	if data, err := os.ReadFile(metaPath); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	if _, err := os.Stat(path); err != nil || meta.URL != url {
+		meta = cachedArtifact{}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", false, err
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	if meta.ETag != "" {
+		req.Header.Set("If-None-Match", meta.ETag)
+	}
+	if meta.LastModified != "" {
+		req.Header.Set("If-Modified-Since", meta.LastModified)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		log.Printf("%s not modified, using the cached copy", url)
+		return path, false, nil
+	case resp.StatusCode == http.StatusNotFound:
+		os.Remove(path)
+		os.Remove(metaPath)
+		return path, false, nil
+	case resp.StatusCode/100 != 2:
+		return "", false, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	// Stream to a temporary file renamed into place: the artifact may not fit
+	// in memory and a cut download must not replace a valid copy.
+	tmp, err := os.CreateTemp(c.dir, "."+name+"-*")
+	if err != nil {
+		return "", false, err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", false, err
+	}
+	meta = cachedArtifact{URL: url, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return "", false, err
+	}
+	if err := os.WriteFile(metaPath, data, 0o644); err != nil {
+		return "", false, err
+	}
+	log.Printf("Downloaded %s (%d bytes)", url, n)
+	return path, true, nil
+}
+
+// FetchAll refreshes manifest.json and catalog.json, telling whether one of
+// them changed. manifest.json is required.
+func (c *artifactCache) FetchAll(ctx context.Context) (changed bool, err error) {
+	for _, name := range []string{"manifest.json", "catalog.json"} {
+		path, fetched, err := c.Fetch(ctx, name)
+		if err != nil {
+			return false, err
+		}
+		if _, statErr := os.Stat(path); name == "manifest.json" && statErr != nil {
+			return false, errors.New("manifest.json not found at " + c.baseURL)
+		}
+		changed = changed || fetched
+	}
+	return changed, nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+func formatCoverage(covered, total int) string {
+	return percentFormat.Format(ratio(covered, total) * 100)
+}
+
+func renderMarkdownReport(w io.Writer, report JSONReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## 📊 Coverage Report (%s)\n\n", strings.ToUpper(report.CovType))
+	fmt.Fprintf(&b, "%d tables, %d columns, %s covered.\n\n", len(report.Tables), report.Total, formatCoverage(report.Covered, report.Total))
+	if len(report.Groups) > 0 {
+		fmt.Fprintf(&b, "| %s | Tables | Columns Ratio | Coverage |\n", strings.ToUpper(report.GroupBy[:1])+report.GroupBy[1:])
+		b.WriteString("|:------|-------:|:-------------:|---------:|\n")
+		for _, g := range report.Groups {
+			fmt.Fprintf(&b, "| %s | %d | (%d/%d) | %s |\n", g.Name, g.Tables, g.Covered, g.Total, formatCoverage(g.Covered, g.Total))
+		}
+		b.WriteString("\n")
+	}
+	withFiles := hasSchemaFiles(report.Tables)
+	if withFiles {
+		b.WriteString("| Model | Columns Ratio | Coverage | File to edit |\n")
+		b.WriteString("|:------|:-------------:|---------:|:-------------|\n")
+	} else {
+		b.WriteString("| Model | Columns Ratio | Coverage |\n")
+		b.WriteString("|:------|:-------------:|---------:|\n")
+	}
+	for _, t := range report.Tables {
+		fmt.Fprintf(&b, "| %s | (%d/%d) | %s |", t.Name, t.Covered, t.Total, formatCoverage(t.Covered, t.Total))
+		if withFiles {
+			if path := t.SchemaFilePath(); path != "" {
+				fmt.Fprintf(&b, " `%s` |", path)
+			} else {
+				b.WriteString("  |")
+			}
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "| **TOTAL** | **(%d/%d)** | **%s** |", report.Covered, report.Total, formatCoverage(report.Covered, report.Total))
+	if withFiles {
+		b.WriteString("  |")
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// hasSchemaFiles tells whether the report knows the yml file (patch_path) or
+// the .sql file of at least one table, so the reports can tell users which
+// file to edit.
+func hasSchemaFiles(tables []TableReport) bool {
+	for _, t := range tables {
+		if t.SchemaFilePath() != "" {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	trendChartWidth  = 600
+	trendChartHeight = 150
+	sparklineWidth   = 80
+	sparklineHeight  = 16
+)
+
+type htmlReportData struct {
+	JSONReport
+	Trend      string
+	TrendFrom  string
+	TrendTo    string
+	Sparklines map[string]string
+	WithFiles  bool
+	Page       *Page
+	Pages      []htmlPageLink
+}
+
+type htmlPageLink struct {
+	Number int
+	Href   string
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"coverage": formatCoverage,
+	"upper":    strings.ToUpper,
+	"inc":      func(i int) int { return i + 1 },
+}).Parse(`{{define "tables"}}<p>{{if .Page}}{{.Page.Total}}{{else}}{{len .Tables}}{{end}} tables, {{.Total}} columns, {{coverage .Covered .Total}} covered.</p>
+{{- if .Trend}}
+<figure>
+<svg width="600" height="150" viewBox="0 0 600 150" class="trend">
+<rect width="600" height="150" fill="#fafafa" stroke="#ddd"/>
+<polyline points="{{.Trend}}" fill="none" stroke="#2e86de" stroke-width="2"/>
+</svg>
+<figcaption>Coverage over time, {{.TrendFrom}} → {{.TrendTo}}</figcaption>
+</figure>
+{{- end}}
+<table>
+<thead><tr><th>Model</th><th>Columns Ratio</th><th>Coverage</th>{{if .Sparklines}}<th>Trend</th>{{end}}{{if .WithFiles}}<th>File to edit</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Tables}}
+<tr><td>{{.Name}}</td><td class="ratio">({{.Covered}}/{{.Total}})</td><td class="coverage">{{coverage .Covered .Total}}</td>
+{{- if $.Sparklines}}<td>{{with index $.Sparklines .Name}}<svg width="80" height="16" viewBox="0 0 80 16"><polyline points="{{.}}" fill="none" stroke="#2e86de"/></svg>{{end}}</td>{{end}}
+{{- if $.WithFiles}}<td><code>{{.SchemaFilePath}}</code></td>{{end}}</tr>
+{{- end}}
+</tbody>
+<tfoot><tr><td>TOTAL</td><td class="ratio">({{.Covered}}/{{.Total}})</td><td class="coverage">{{coverage .Covered .Total}}</td>{{if .Sparklines}}<td></td>{{end}}{{if .WithFiles}}<td></td>{{end}}</tr></tfoot>
+</table>
+{{- with .Page}}
+<nav class="pages">Page {{.Number}}/{{.Count}}, models {{inc .From}}–{{.To}} of {{.Total}}:
+{{- range $.Pages}} {{if eq .Number $.Page.Number}}<strong>{{.Number}}</strong>{{else}}<a href="{{.Href}}">{{.Number}}</a>{{end}}{{end}}</nav>
+{{- end}}
+{{end}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage Report ({{upper .CovType}})</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 12px; border-bottom: 1px solid #ddd; }
+td.ratio { text-align: center; }
+td.coverage { text-align: right; }
+tfoot td { font-weight: bold; }
+nav.pages { margin-top: 1em; }
+</style>
+</head>
+<body>
+<h1>📊 Coverage Report ({{upper .CovType}})</h1>
+{{template "tables" .}}</body>
+</html>
+`))
+
+func renderHTMLReport(w io.Writer, report JSONReport, history []HistoryEntry) error {
+	return htmlReportTemplate.Execute(w, newHTMLReportData(report, history))
+}
+
+func renderHTMLTables(w io.Writer, report JSONReport) error {
+	return htmlReportTemplate.ExecuteTemplate(w, "tables", newHTMLReportData(report, nil))
+}
+
+func newHTMLReportData(report JSONReport, history []HistoryEntry) htmlReportData {
+	data := htmlReportData{JSONReport: report, WithFiles: hasSchemaFiles(report.Tables)}
+	if len(history) < 2 {
+		return data
+	}
+	global := make([]trendPoint, len(history))
+	perModel := make(map[string][]trendPoint)
+	for i, entry := range history {
+		global[i] = trendPoint{entry.GeneratedAt, entry.Report.Coverage}
+		for _, t := range entry.Report.Tables {
+			perModel[t.Name] = append(perModel[t.Name], trendPoint{entry.GeneratedAt, t.Coverage})
+		}
+	}
+	data.Trend = polylinePoints(global, trendChartWidth, trendChartHeight)
+	data.TrendFrom = history[0].GeneratedAt.Format("2006-01-02")
+	data.TrendTo = history[len(history)-1].GeneratedAt.Format("2006-01-02")
+	data.Sparklines = make(map[string]string)
+	for _, t := range report.Tables {
+		if values := perModel[t.Name]; len(values) >= 2 {
+			data.Sparklines[t.Name] = polylinePoints(values, sparklineWidth, sparklineHeight)
+		}
+	}
+	return data
+}
+
+// trendPoint is a coverage value of the history at the date of its report.
+type trendPoint struct {
+	At    time.Time
+	Value float64
+}
+
+// polylinePoints draws points, sorted by date, with x proportional to the
+// date so that the gaps of the history show; they are evenly spaced when
+// they all share the same date.
+func polylinePoints(values []trendPoint, width, height int) string {
+	points := make([]string, len(values))
+	var span time.Duration
+	if len(values) > 0 {
+		span = values[len(values)-1].At.Sub(values[0].At)
+	}
+	step := float64(width) / float64(max(len(values)-1, 1))
+	for i, v := range values {
+		x := float64(i) * step
+		if span > 0 {
+			x = float64(width) * float64(v.At.Sub(values[0].At)) / float64(span)
+		}
+		y := float64(height) - v.Value*float64(height-2) - 1
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+	return strings.Join(points, " ")
+}
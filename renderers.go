@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RenderFunc writes a coverage report in one output format (--format).
+type RenderFunc func(w io.Writer, report JSONReport) error
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = make(map[string]RenderFunc)
+)
+
+// RegisterRenderer makes an output format available to --format. Custom
+// formats are compiled in by registering them from an init function.
+func RegisterRenderer(name string, fn RenderFunc) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	if _, exists := renderers[name]; exists || name == ReportFormatJSONL {
+		panic(fmt.Sprintf("renderer %s registered twice", name))
+	}
+	renderers[name] = fn
+}
+
+func lookupRenderer(name string) (RenderFunc, error) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	if fn, ok := renderers[name]; ok {
+		return fn, nil
+	}
+	return nil, fmt.Errorf("unknown report format %q, expected one of: %s", name, strings.Join(reportFormatNames(), ", "))
+}
+
+// reportFormatNames lists the registered formats and jsonl, which is streamed
+// from the catalog rather than rendered from the report. The caller holds
+// renderersMu.
+func reportFormatNames() []string {
+	names := []string{ReportFormatJSONL}
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateReportFormat(format string) error {
+	if format == ReportFormatJSONL {
+		return nil
+	}
+	_, err := lookupRenderer(format)
+	return err
+}
+
+// writeReport renders the report into path, - for stdout.
+func writeReport(report JSONReport, format, path string) error {
+	render, err := lookupRenderer(format)
+	if err != nil {
+		return err
+	}
+	if path == "-" {
+		return render(os.Stdout, report)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	log.Printf("Writing %s report into %s", format, path)
+	if err := render(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func renderJSONReport(w io.Writer, report JSONReport) error {
+	data, err := json.MarshalIndent(scaleReport(report, percentFormat.JSONScale), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// defaultOutputs replaces the default coverage.json output of the formats
+// that are not JSON.
+var defaultOutputs = map[string]string{
+	ReportFormatPDF:             "coverage.pdf",
+	ReportFormatEvaluator:       "coverage.csv",
+	string(FormatMarkdownTable): "coverage.md",
+	string(FormatHTMLReport):    "coverage.html",
+	ReportFormatSARIF:           "coverage.sarif",
+	ReportFormatRDJSON:          "coverage.rdjson",
+}
+
+func init() {
+	RegisterRenderer(ReportFormatJSON, renderJSONReport)
+	RegisterRenderer(ReportFormatPDF, renderPDFSummary)
+	RegisterRenderer(ReportFormatDBTScore, writeDBTScoreReport)
+	RegisterRenderer(ReportFormatEvaluator, writeEvaluatorReport)
+	RegisterRenderer(ReportFormatSARIF, renderSARIFReport)
+	RegisterRenderer(ReportFormatRDJSON, renderRDJSONReport)
+	RegisterRenderer(string(FormatMarkdownTable), renderMarkdownReport)
+	RegisterRenderer(string(FormatHTMLReport), func(w io.Writer, report JSONReport) error {
+		return renderHTMLReport(w, report, nil)
+	})
+}
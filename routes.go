@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RouteConfig sends the slice of the report selected by Select to a publish
+// target, e.g. the finance models to the Slack channel of the finance team.
+type RouteConfig struct {
+	Name   string   `yaml:"name"`
+	Select []string `yaml:"select"`
+	Target string   `yaml:"target"`
+	Args   []string `yaml:"args"`
+}
+
+func (r RouteConfig) validate() error {
+	if r.Name == "" {
+		return errors.New("routes: missing route name")
+	}
+	if len(r.Select) == 0 {
+		return fmt.Errorf("route %s: missing select", r.Name)
+	}
+	if _, err := ParseSelector(r.Select); err != nil {
+		return fmt.Errorf("route %s: %w", r.Name, err)
+	}
+	if _, ok := publishers[r.Target]; !ok && !isObjectStorageTarget(r.Target) {
+		return fmt.Errorf("route %s: unknown publish target %q, expected one of: %s, s3://bucket/prefix/ or gs://bucket/prefix/", r.Name, r.Target, strings.Join(publisherNames(), ", "))
+	}
+	return nil
+}
+
+// sliceReport keeps the tables matched by the selector, with the totals and
+// groups computed on them alone.
+func sliceReport(report JSONReport, selector Selector) JSONReport {
+	slice := JSONReport{
+		CovType:     report.CovType,
+		GeneratedAt: report.GeneratedAt,
+		GitSHA:      report.GitSHA,
+		GroupBy:     report.GroupBy,
+	}
+	for _, t := range report.Tables {
+		if selector.Matches(t) {
+			slice.Tables = append(slice.Tables, t)
+			slice.Covered += t.Covered
+			slice.Total += t.Total
+		}
+	}
+	slice.Coverage = ratio(slice.Covered, slice.Total)
+	if slice.GroupBy != "" {
+		slice.Groups = computeGroupReports(slice.Tables)
+	}
+	return slice
+}
+
+func publishRoutes(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("publish routes")
+	var (
+		reportPath = fs.String("report", "coverage.json", "Coverage report to route (JSON)")
+		projectDir = fs.String("dbt_dir", ".", "dbt project path")
+		configPath = fs.String("config", "", "Configuration file with the routes (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		only       = fs.String("only", "", "Only send these routes (split using ',')")
+		dryRun     = fs.Bool("dry_run", false, "Print what each route would send, without publishing")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	cfg, err := loadConfig(*configPath, *projectDir)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Routes) == 0 {
+		return errors.New("no route in the configuration, add a routes section")
+	}
+	report, err := readJSONReport(*reportPath)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "dbt-goverage-routes")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	names := splitList(*only)
+	var failed []string
+	for _, route := range cfg.Routes {
+		if len(names) > 0 && !containsString(names, route.Name) {
+			continue
+		}
+		slice := sliceReport(report, Selector(route.Select))
+		if len(slice.Tables) == 0 {
+			log.Printf("Route %s: no table selected, nothing sent", route.Name)
+			continue
+		}
+		fmt.Printf("Route %s → %s: %d tables, %s\n", route.Name, route.Target, len(slice.Tables), formatCoverage(slice.Covered, slice.Total))
+		if *dryRun {
+			continue
+		}
+		path := filepath.Join(dir, route.Name+".json")
+		if err := writeCoverageReport(slice, path); err != nil {
+			return err
+		}
+		// Secrets (webhook URLs, tokens) stay in the environment, not in the
+		// configuration.
+		routeArgs := []string{route.Target}
+		for _, arg := range route.Args {
+			routeArgs = append(routeArgs, os.ExpandEnv(arg))
+		}
+		if err := runPublish(ctx, append(routeArgs, "--report", path)); err != nil {
+			fmt.Fprintf(os.Stderr, "route %s: %v\n", route.Name, err)
+			failed = append(failed, route.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d routes failed: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
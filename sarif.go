@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	ReportFormatSARIF = "sarif"
+
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpURI          string       `json:"helpUri,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine int `json:"startLine"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver sarifDriver `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifLevel maps the severity of a gap to the SARIF levels, warning for the
+// reports without severities.
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case SeverityError:
+		return "error"
+	case SeverityInfo:
+		return "note"
+	}
+	return "warning"
+}
+
+// coverageRuleID names the rule of the diagnostics formats: doc-coverage,
+// test-coverage...
+func coverageRuleID(covType string) string {
+	return strings.ReplaceAll(covType, ":", "-") + "-coverage"
+}
+
+// sarifFingerprint identifies a gap across runs, whatever its line or
+// message, so code scanning tools track it instead of reopening it.
+func sarifFingerprint(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSARIFResult(ruleID, covType string, gap coverageGap) sarifResult {
+	var loc sarifLocation
+	loc.PhysicalLocation.ArtifactLocation.URI = slashPath(gap.Table.SchemaFilePath())
+	loc.PhysicalLocation.Region.StartLine = gap.Line
+	kind := "column"
+	if gap.Column == "" {
+		kind = "model"
+	}
+	loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: gap.Name(), Kind: kind}}
+	id := gap.Table.UniqueID
+	if id == "" {
+		id = gap.Table.Name
+	}
+	return sarifResult{
+		RuleID:              ruleID,
+		Level:               sarifLevel(gap.Severity),
+		Message:             sarifMessage{Text: gap.Message(covType)},
+		Locations:           []sarifLocation{loc},
+		PartialFingerprints: map[string]string{"dbtGoverage/v1": sarifFingerprint(ruleID, id, gap.Column)},
+	}
+}
+
+// renderSARIFReport writes the gaps of the report as SARIF 2.1.0 results, one
+// per uncovered column (per uncovered model for the table-level coverage
+// types) pointing at its schema file, for GitHub code scanning and the other
+// static analysis dashboards. Models without a file are left out.
+func renderSARIFReport(w io.Writer, report JSONReport) error {
+	ruleID := coverageRuleID(report.CovType)
+	var run sarifRun
+	run.Tool.Driver = sarifDriver{
+		Name:           "dbt-goverage",
+		Version:        currentVersion(),
+		InformationURI: "https://github.com/mickaelandrieu/dbt-goverage",
+		Rules: []sarifRule{{
+			ID:               ruleID,
+			Name:             "MissingCoverage",
+			ShortDescription: sarifMessage{Text: fmt.Sprintf("Missing %s coverage", report.CovType)},
+			HelpURI:          "https://github.com/mickaelandrieu/dbt-goverage#readme",
+		}},
+	}
+	run.Results = []sarifResult{}
+	for _, gap := range coverageGaps(report.Tables) {
+		if gap.Table.SchemaFilePath() != "" {
+			run.Results = append(run.Results, newSARIFResult(ruleID, report.CovType, gap))
+		}
+	}
+	data, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
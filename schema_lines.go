@@ -0,0 +1,168 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schemaBlock is where a model, seed, snapshot or source table is declared in
+// a .yml file, and its columns.
+type schemaBlock struct {
+	Line    int
+	Columns map[string]int
+}
+
+// schemaSections maps the top-level keys of the .yml files to the resource
+// types of the unique_ids.
+var schemaSections = map[string]string{
+	"models":    "model",
+	"seeds":     "seed",
+	"snapshots": "snapshot",
+}
+
+// mappingValue returns the value of key in a YAML mapping, nil when absent.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// namedItems returns the items of a YAML list of - name: blocks with their
+// name node.
+func namedItems(list *yaml.Node) map[*yaml.Node]*yaml.Node {
+	items := make(map[*yaml.Node]*yaml.Node)
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return items
+	}
+	for _, item := range list.Content {
+		if name := mappingValue(item, "name"); name != nil && name.Kind == yaml.ScalarNode {
+			items[name] = item
+		}
+	}
+	return items
+}
+
+func newSchemaBlock(name, item *yaml.Node, naming ColumnNaming) *schemaBlock {
+	block := &schemaBlock{Line: name.Line, Columns: make(map[string]int)}
+	for colName := range namedItems(mappingValue(item, "columns")) {
+		block.Columns[naming.Key(colName.Value)] = colName.Line
+	}
+	return block
+}
+
+// parseSchemaFile indexes the blocks of a .yml file by resource type and
+// name: model.orders, source.raw.orders...
+func parseSchemaFile(data []byte, naming ColumnNaming) (map[string]*schemaBlock, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	blocks := make(map[string]*schemaBlock)
+	if len(doc.Content) == 0 {
+		return blocks, nil
+	}
+	root := doc.Content[0]
+	for section, resourceType := range schemaSections {
+		for name, item := range namedItems(mappingValue(root, section)) {
+			blocks[resourceType+"."+strings.ToLower(name.Value)] = newSchemaBlock(name, item, naming)
+		}
+	}
+	for sourceName, source := range namedItems(mappingValue(root, "sources")) {
+		for name, item := range namedItems(mappingValue(source, "tables")) {
+			blocks["source."+strings.ToLower(sourceName.Value+"."+name.Value)] = newSchemaBlock(name, item, naming)
+		}
+	}
+	return blocks, nil
+}
+
+// schemaBlockKey derives the key of parseSchemaFile from a unique_id:
+// model.shop.orders gives model.orders, source.shop.raw.orders gives
+// source.raw.orders. The version of the versioned models is ignored.
+func schemaBlockKey(uniqueID string) string {
+	parts := strings.Split(uniqueID, ".")
+	switch {
+	case len(parts) >= 4 && parts[0] == "source":
+		return strings.ToLower("source." + parts[2] + "." + strings.Join(parts[3:], "."))
+	case len(parts) >= 3:
+		return strings.ToLower(parts[0] + "." + parts[2])
+	}
+	return ""
+}
+
+// schemaLines resolves the line numbers of the models and columns in the
+// .yml files of a dbt project, each file being parsed once.
+type schemaLines struct {
+	projectDir string
+	naming     ColumnNaming
+	files      map[string]map[string]*schemaBlock
+}
+
+func newSchemaLines(projectDir string, naming ColumnNaming) *schemaLines {
+	return &schemaLines{projectDir: projectDir, naming: naming, files: make(map[string]map[string]*schemaBlock)}
+}
+
+// file parses a .yml file, looked up in the project then in the installed
+// packages (patch_path is relative to the package of the node).
+func (s *schemaLines) file(patchPath, packageName string) map[string]*schemaBlock {
+	cacheKey := packageName + ":" + patchPath
+	if blocks, ok := s.files[cacheKey]; ok {
+		return blocks
+	}
+	rel := filepath.FromSlash(patchPath)
+	candidates := []string{filepath.Join(s.projectDir, rel)}
+	if packageName != "" {
+		candidates = append(candidates,
+			filepath.Join(s.projectDir, "dbt_packages", packageName, rel),
+			filepath.Join(s.projectDir, "dbt_modules", packageName, rel))
+	}
+	var blocks map[string]*schemaBlock
+	for _, candidate := range candidates {
+		data, err := os.ReadFile(candidate)
+		if err != nil {
+			continue
+		}
+		if blocks, err = parseSchemaFile(data, s.naming); err != nil {
+			log.Printf("Cannot read the line numbers of %s: %v", candidate, err)
+		}
+		break
+	}
+	s.files[cacheKey] = blocks
+	return blocks
+}
+
+// resolveSchemaLines sets the line of each table and column of the report in
+// its .yml file, left at 0 when the file is not found (artifacts analyzed
+// away from the project) or does not declare them.
+func resolveSchemaLines(report *JSONReport, projectDir string, naming ColumnNaming) {
+	lines := newSchemaLines(projectDir, naming)
+	for i := range report.Tables {
+		t := &report.Tables[i]
+		if t.PatchPath == "" {
+			continue
+		}
+		t.applySchemaBlocks(lines.file(t.PatchPath, t.PackageName))
+	}
+}
+
+// applySchemaBlocks sets the lines of the table and its columns from the
+// blocks of its .yml file.
+func (t *TableReport) applySchemaBlocks(blocks map[string]*schemaBlock) {
+	block := blocks[schemaBlockKey(t.UniqueID)]
+	if block == nil {
+		return
+	}
+	t.Line = block.Line
+	for j := range t.Columns {
+		t.Columns[j].Line = block.Columns[t.Columns[j].Name]
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SeedAudit cross-checks the columns of a seed, as loaded from its CSV header,
+// against the columns documented in its yml file.
+type SeedAudit struct {
+	UniqueID     string   `json:"unique_id"`
+	Name         string   `json:"name"`
+	PatchPath    string   `json:"patch_path,omitempty"`
+	Columns      int      `json:"columns"`
+	Documented   int      `json:"documented"`
+	Undocumented []string `json:"undocumented_columns,omitempty"`
+	NotInCSV     []string `json:"not_in_csv,omitempty"`
+}
+
+// auditSeeds lists the seeds with undocumented columns or with yml columns
+// missing from the CSV header.
+func auditSeeds(catalog Catalog) []SeedAudit {
+	var audits []SeedAudit
+	for _, table := range catalog.Tables {
+		if table.ResourceType != "seed" {
+			continue
+		}
+		audit := SeedAudit{UniqueID: table.UniqueID, Name: table.Name, PatchPath: table.PatchPath, Columns: len(table.Columns)}
+		for _, col := range table.SortedColumns() {
+			if col.Doc {
+				audit.Documented++
+			} else {
+				audit.Undocumented = append(audit.Undocumented, col.Name)
+			}
+		}
+		for _, name := range table.YAMLColumns {
+			if !seedHasColumn(table, name, catalog.Settings.Naming) {
+				audit.NotInCSV = append(audit.NotInCSV, name)
+			}
+		}
+		if len(audit.Undocumented) > 0 || len(audit.NotInCSV) > 0 {
+			audits = append(audits, audit)
+		}
+	}
+	sort.Slice(audits, func(i, j int) bool { return audits[i].UniqueID < audits[j].UniqueID })
+	return audits
+}
+
+func seedHasColumn(table Table, yamlName string, naming ColumnNaming) bool {
+	declared := map[string]interface{}{yamlName: map[string]interface{}{}}
+	for name := range table.Columns {
+		if lookupColumnInfo(declared, name, naming) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func printSeedAudits(w io.Writer, audits []SeedAudit) {
+	var undocumented, mismatched []SeedAudit
+	for _, a := range audits {
+		if a.Documented == 0 && a.Columns > 0 {
+			undocumented = append(undocumented, a)
+		}
+		if len(a.NotInCSV) > 0 {
+			mismatched = append(mismatched, a)
+		}
+	}
+	if len(undocumented) > 0 {
+		fmt.Fprintf(w, "\n🌱 %d seeds without any documented column:\n", len(undocumented))
+		for _, a := range undocumented {
+			fmt.Fprintf(w, "  - %s (%d columns)\n", a.UniqueID, a.Columns)
+		}
+	}
+	if len(mismatched) > 0 {
+		fmt.Fprintf(w, "\n🌱 %d seeds documenting columns missing from the CSV header:\n", len(mismatched))
+		for _, a := range mismatched {
+			fmt.Fprintf(w, "  - %s: %s\n", a.UniqueID, strings.Join(a.NotInCSV, ", "))
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+type Selector []string
+
+var selectorMethods = []string{"group", "owner", "path", "package", "resource_type", "tag"}
+
+func ParseSelector(values []string) (Selector, error) {
+	for _, v := range values {
+		method, pattern, ok := strings.Cut(v, ":")
+		if !ok {
+			continue
+		}
+		if !isSelectorMethod(method) {
+			return nil, fmt.Errorf("unknown selector method %q, expected one of: %s", method, strings.Join(selectorMethods, ", "))
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", v, err)
+		}
+	}
+	return Selector(values), nil
+}
+
+func isSelectorMethod(method string) bool {
+	for _, m := range selectorMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Selector) Matches(t TableReport) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, v := range s {
+		method, pattern, ok := strings.Cut(v, ":")
+		if !ok {
+			method, pattern = "name", v
+		}
+		var value string
+		switch method {
+		case "name":
+			value = t.Name
+		case "path":
+			value, pattern = slashPath(t.OriginalFilePath), slashPath(pattern)
+			if dir := strings.TrimSuffix(strings.TrimSuffix(pattern, "*"), "/"); hasPathPrefix(value, dir+"/") {
+				return true
+			}
+		case "group":
+			value = t.Group
+		case "package":
+			value = t.PackageName
+		case "resource_type":
+			value = t.ResourceType
+		case "tag":
+			for _, tag := range t.Tags {
+				if matchesAny(tag, []string{pattern}) {
+					return true
+				}
+			}
+			continue
+		case "owner":
+			for _, owner := range t.Owners {
+				if matchesAny(owner, []string{pattern}) {
+					return true
+				}
+			}
+			continue
+		}
+		if matchesAny(value, []string{pattern}) {
+			return true
+		}
+	}
+	return false
+}
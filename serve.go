@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxUploadSize = 32 << 20
+
+type StoredReport struct {
+	ID      string `json:"id"`
+	CovType string `json:"cov_type"`
+	ReportSummary
+}
+
+type TimelinePoint struct {
+	ReportID string `json:"report_id"`
+	ReportSummary
+}
+
+type reportServer struct {
+	historyDir string
+	// prefix is the URL prefix of the project, /acme-core, empty when serve
+	// hosts a single project.
+	prefix     string
+	live       *liveRecompute
+	adminToken string
+	mu         sync.RWMutex
+}
+
+func runServe(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("serve")
+	var (
+		addr       = fs.String("addr", ":8080", "Address to listen on")
+		historyDir = fs.String("history_dir", "coverage-history", "Directory where the uploaded reports are stored")
+		projects   = fs.String("projects", "", "Host several dbt projects, each under /<name>/ with its own history in <history_dir>/<name> or name=dir (split using ',')")
+		live       = fs.Bool("live", false, "Recompute the coverage when a dbt Cloud webhook announces a completed run (POST /webhooks/dbt-cloud)")
+		cloudURL   = fs.String("dbt_cloud_url", envOrDefault("DBT_CLOUD_URL", "https://cloud.getdbt.com"), "dbt Cloud URL the artifacts are downloaded from")
+		cloudToken = fs.String("dbt_cloud_token", os.Getenv("DBT_CLOUD_API_TOKEN"), "dbt Cloud API token (defaults to $DBT_CLOUD_API_TOKEN)")
+		secret     = fs.String("webhook_secret", os.Getenv("DBT_CLOUD_WEBHOOK_SECRET"), "Secret of the dbt Cloud webhook, to check its signature (defaults to $DBT_CLOUD_WEBHOOK_SECRET), required by --live")
+		insecure   = fs.Bool("insecure_webhooks", false, "Accept the unsigned dbt Cloud webhooks of --live without --webhook_secret, letting anyone trigger a recomputation")
+		jobIDs     = fs.String("job_ids", "", "Only recompute for these dbt Cloud jobs (split using ',')")
+		covTypes   = fs.String("types", "doc,test", "Coverage types computed for each run (split using ',')")
+		configPath = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the current directory), reloaded on SIGHUP")
+		apiTokens  = fs.String("api_tokens", os.Getenv("DBT_GOVERAGE_API_TOKENS"), "Bearer tokens accepted by the API, split using ',' (defaults to $DBT_GOVERAGE_API_TOKENS)")
+		basicAuth  = fs.String("basic_auth", os.Getenv("DBT_GOVERAGE_BASIC_AUTH"), "user:password accepted by the API with HTTP basic authentication (defaults to $DBT_GOVERAGE_BASIC_AUTH)")
+		tlsCert    = fs.String("tls_cert", "", "Certificate file (PEM) to serve HTTPS, with --tls_key")
+		tlsKey     = fs.String("tls_key", "", "Private key file (PEM) of --tls_cert")
+		adminToken = fs.String("admin_token", os.Getenv("DBT_GOVERAGE_ADMIN_TOKEN"), "Token of the POST /admin/reload endpoint reloading the configuration, disabled when empty (defaults to $DBT_GOVERAGE_ADMIN_TOKEN)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+
+	auth, err := newAPIAuth(splitList(*apiTokens), *basicAuth)
+	if err != nil {
+		return err
+	}
+	if !auth.enabled() {
+		fmt.Fprintln(os.Stderr, "warning: no --api_tokens nor --basic_auth, the API is not authenticated")
+	}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return errors.New("--tls_cert and --tls_key go together")
+	}
+	if *live && *projects != "" {
+		// The recompute has one configuration and one dbt Cloud token, it
+		// cannot tell the jobs of one project from those of another.
+		return errors.New("--live cannot be used with --projects, run one serve --live per project")
+	}
+	if *live && *cloudToken == "" {
+		return errors.New("missing dbt Cloud API token, use --dbt_cloud_token or $DBT_CLOUD_API_TOKEN")
+	}
+	if *live && *secret == "" && !*insecure {
+		return errors.New("missing dbt Cloud webhook secret, use --webhook_secret or $DBT_CLOUD_WEBHOOK_SECRET (or --insecure_webhooks to accept unsigned webhooks)")
+	}
+	// The coverage types of the configuration are those accepted in the
+	// uploaded reports, and those recomputed by --live.
+	cfg, err := loadConfig(*configPath, ".")
+	if err != nil {
+		return err
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		return err
+	}
+	defer closeCoverageProviders()
+	registerHeuristicProviders(cfg.Heuristics)
+	if err := registerPlugins(cfg.Plugins); err != nil {
+		return err
+	}
+	rs := &reportServer{historyDir: *historyDir, adminToken: *adminToken}
+	if *live {
+		rs.live = &liveRecompute{ctx: ctx, baseURL: *cloudURL, token: *cloudToken, secret: *secret, insecure: *insecure, jobIDs: splitList(*jobIDs),
+			configPath: *configPath, policy: newLivePolicy(cfg, settings)}
+		for _, name := range splitList(*covTypes) {
+			covType := CoverageType(name)
+			if _, err := lookupCoverageProvider(covType); err != nil {
+				return err
+			}
+			rs.live.covTypes = append(rs.live.covTypes, covType)
+		}
+		if *secret == "" {
+			fmt.Fprintln(os.Stderr, "warning: --insecure_webhooks, the dbt Cloud webhooks are not authenticated")
+		}
+		rs.live.reloadOnSignal(ctx)
+	}
+	handler := rs.handler()
+	if *projects != "" {
+		dirs, err := parseServeProjects(*projects, *historyDir)
+		if err != nil {
+			return err
+		}
+		hosted := make(map[string]*reportServer, len(dirs))
+		for name, dir := range dirs {
+			hosted[name] = &reportServer{historyDir: dir, prefix: "/" + name}
+			log.Printf("Project %s served under /%s/ (history in %s)", name, name, dir)
+		}
+		handler = projectsHandler(hosted)
+	}
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           auth.wrap(handler),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() {
+		if *tlsCert != "" {
+			log.Printf("Serving the coverage API on https://%s (history in %s)", *addr, *historyDir)
+			errc <- server.ListenAndServeTLS(*tlsCert, *tlsKey)
+			return
+		}
+		log.Printf("Serving the coverage API on %s (history in %s)", *addr, *historyDir)
+		errc <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stop()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil
+	}
+	return ctx.Err()
+}
+
+func (s *reportServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /reports", s.uploadReport)
+	mux.HandleFunc("GET /reports", s.listReports)
+	mux.HandleFunc("GET /reports/{id}", s.getReport)
+	mux.HandleFunc("GET /models/{name}/timeline", s.modelTimeline)
+	if s.live != nil {
+		mux.HandleFunc("POST /webhooks/dbt-cloud", s.dbtCloudWebhook)
+		if s.adminToken != "" {
+			mux.HandleFunc("POST /admin/reload", s.reloadConfig)
+		}
+	}
+	return mux
+}
+
+func (s *reportServer) uploadReport(w http.ResponseWriter, r *http.Request) {
+	var report JSONReport
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUploadSize)).Decode(&report); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid report: %w", err))
+		return
+	}
+	if report.CovType == "" {
+		writeAPIError(w, http.StatusBadRequest, errors.New("invalid report: cov_type is missing"))
+		return
+	}
+	if !historyCovTypeRegexp.MatchString(report.CovType) {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid report: invalid cov_type %q", report.CovType))
+		return
+	}
+	if _, err := lookupCoverageProvider(CoverageType(report.CovType)); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid report: %w", err))
+		return
+	}
+	report = scaleReport(report, 1)
+	if report.GeneratedAt == "" {
+		report.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	s.mu.Lock()
+	path, err := saveToHistory(s.historyDir, report)
+	s.mu.Unlock()
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	stored := storedReport(path, report)
+	log.Printf("Report %s stored (%s, %s)", stored.ID, report.CovType, formatCoverage(report.Covered, report.Total))
+	w.Header().Set("Location", s.prefix+"/reports/"+stored.ID)
+	writeAPIJSON(w, http.StatusCreated, stored)
+}
+
+func (s *reportServer) listReports(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.history(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	reports := make([]StoredReport, 0, len(entries))
+	for _, entry := range entries {
+		reports = append(reports, storedReport(entry.Path, entry.Report))
+	}
+	writeAPIJSON(w, http.StatusOK, reports)
+}
+
+func (s *reportServer) getReport(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("report %s not found", id))
+		return
+	}
+	path := filepath.Join(s.historyDir, id+".json")
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("report %s not found", id))
+		return
+	}
+	report, err := readJSONReport(path)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, report)
+}
+
+func (s *reportServer) modelTimeline(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	entries, err := s.history(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	points := []TimelinePoint{}
+	for _, entry := range entries {
+		for _, t := range entry.Report.Tables {
+			if t.Name != name && t.UniqueID != name {
+				continue
+			}
+			points = append(points, TimelinePoint{
+				ReportID: reportID(entry.Path),
+				ReportSummary: ReportSummary{
+					GeneratedAt: entry.Report.GeneratedAt,
+					GitSHA:      entry.Report.GitSHA,
+					Labels:      entry.Report.Labels,
+					Covered:     t.Covered,
+					Total:       t.Total,
+					Coverage:    t.Coverage,
+				},
+			})
+			break
+		}
+	}
+	if len(points) == 0 {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("model %s not found in the history", name))
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, points)
+}
+
+// history returns the stored reports of the requested type (?type=), generated
+// on or after ?since= (a date, today, yesterday or an RFC 3339 timestamp) and
+// carrying the labels of ?label=env=prod.
+func (s *reportServer) history(r *http.Request) ([]HistoryEntry, error) {
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		var err error
+		if since, err = parseHistoryDate(v); err != nil {
+			return nil, fmt.Errorf("invalid since %q, expected a date (2024-01-01, today, yesterday)", v)
+		}
+	}
+	labels, err := queryLabels(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	entries, err := loadHistory(s.historyDir, r.URL.Query().Get("type"))
+	s.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if !entry.GeneratedAt.Before(since) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filterHistoryByLabels(filtered, labels), nil
+}
+
+func storedReport(path string, report JSONReport) StoredReport {
+	return StoredReport{
+		ID:            reportID(path),
+		CovType:       report.CovType,
+		ReportSummary: summarizeReport(report),
+	}
+}
+
+func reportID(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".json")
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("error writing the response: %v", err)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
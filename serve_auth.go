@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// apiAuth protects the routes of serve with static Bearer tokens and HTTP
+// basic authentication, either being accepted. The dbt Cloud webhook and the
+// reload route check their own secret and are left to their handlers.
+type apiAuth struct {
+	tokens []string
+	user   string
+	pass   string
+}
+
+// newAPIAuth reads --api_tokens and --basic_auth (user:password).
+func newAPIAuth(tokens []string, basic string) (*apiAuth, error) {
+	a := &apiAuth{tokens: tokens}
+	if basic != "" {
+		user, pass, ok := strings.Cut(basic, ":")
+		if !ok || user == "" || pass == "" {
+			return nil, errors.New("invalid --basic_auth, expected user:password")
+		}
+		a.user, a.pass = user, pass
+	}
+	return a, nil
+}
+
+func (a *apiAuth) enabled() bool {
+	return len(a.tokens) > 0 || a.user != ""
+}
+
+func (a *apiAuth) allowed(r *http.Request) bool {
+	if user, pass, ok := r.BasicAuth(); ok && a.user != "" {
+		// Both compared so the timing does not tell which one is wrong.
+		userOK := subtle.ConstantTimeCompare([]byte(user), []byte(a.user))
+		passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(a.pass))
+		return userOK&passOK == 1
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// selfAuthenticatedRoutes lists the routes checking their own secret. They
+// are only served at the root: --live is refused with --projects.
+var selfAuthenticatedRoutes = []string{"POST /webhooks/dbt-cloud", "POST /admin/reload"}
+
+// selfAuthenticated tells whether r is exactly one of selfAuthenticatedRoutes.
+func selfAuthenticated(r *http.Request) bool {
+	return containsString(selfAuthenticatedRoutes, r.Method+" "+r.URL.Path)
+}
+
+func (a *apiAuth) wrap(next http.Handler) http.Handler {
+	if !a.enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !selfAuthenticated(r) && !a.allowed(r) {
+			if a.user != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="dbt-goverage"`)
+			}
+			writeAPIError(w, http.StatusUnauthorized, errors.New("authentication required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
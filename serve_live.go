@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dbtCloudRunCompleted = "job.run.completed"
+	maxWebhookSize       = 1 << 20
+)
+
+// dbtCloudWebhook is the part of a dbt Cloud webhook payload serve --live
+// needs.
+type dbtCloudWebhook struct {
+	AccountID int64  `json:"accountId"`
+	EventType string `json:"eventType"`
+	Data      struct {
+		JobID     string `json:"jobId"`
+		RunID     string `json:"runId"`
+		RunStatus string `json:"runStatus"`
+	} `json:"data"`
+}
+
+// liveRecompute downloads the artifacts of the dbt Cloud runs announced by a
+// webhook and stores their coverage in the history.
+type liveRecompute struct {
+	ctx      context.Context
+	baseURL  string
+	token    string
+	secret   string
+	insecure bool // --insecure_webhooks, accepting unsigned webhooks
+	jobIDs   []string
+	covTypes []CoverageType
+	// configPath is reloaded into the package-level settings and policy.
+	configPath string
+	policy     livePolicy
+	reloadedAt time.Time
+	// mu serializes the recomputations and the reloads, the heuristics
+	// being package state.
+	mu sync.Mutex
+}
+
+func (s *reportServer) dbtCloudWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.live.verify(body, r.Header.Get("Authorization")) {
+		writeAPIError(w, http.StatusUnauthorized, errors.New("invalid webhook signature"))
+		return
+	}
+	var event dbtCloudWebhook
+	if err := json.Unmarshal(body, &event); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid webhook: %w", err))
+		return
+	}
+	if reason := s.live.ignored(event); reason != "" {
+		writeAPIJSON(w, http.StatusOK, map[string]string{"status": "ignored", "reason": reason})
+		return
+	}
+	log.Printf("dbt Cloud run %s of job %s completed, recomputing the coverage", event.Data.RunID, event.Data.JobID)
+	go func() {
+		if err := s.recompute(event.AccountID, event.Data.RunID); err != nil {
+			fmt.Fprintf(os.Stderr, "error recomputing the coverage of run %s: %v\n", event.Data.RunID, err)
+		}
+	}()
+	writeAPIJSON(w, http.StatusAccepted, map[string]string{"status": "accepted", "run_id": event.Data.RunID})
+}
+
+// verify checks the Authorization header, the hex HMAC-SHA256 of the body
+// keyed by the webhook secret. Without secret, the webhooks are only accepted
+// with --insecure_webhooks.
+func (l *liveRecompute) verify(body []byte, signature string) bool {
+	if l.secret == "" {
+		return l.insecure
+	}
+	mac := hmac.New(sha256.New, []byte(l.secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(strings.TrimSpace(signature))))
+}
+
+func (l *liveRecompute) ignored(event dbtCloudWebhook) string {
+	switch {
+	case event.EventType != dbtCloudRunCompleted:
+		return "event " + event.EventType
+	case event.Data.RunStatus != "Success":
+		return "run status " + event.Data.RunStatus
+	case event.Data.RunID == "":
+		return "missing runId"
+	case len(l.jobIDs) > 0 && !containsString(l.jobIDs, event.Data.JobID):
+		return "job " + event.Data.JobID + " not followed"
+	}
+	return ""
+}
+
+func (s *reportServer) recompute(accountID int64, runID string) error {
+	l := s.live
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ctx, cancel := context.WithTimeout(l.ctx, 5*time.Minute)
+	defer cancel()
+
+	manifestData, err := l.artifact(ctx, accountID, runID, "manifest.json")
+	if err != nil {
+		return err
+	}
+	manifest, err := ParseManifest(manifestData, l.policy.settings)
+	if err != nil {
+		return err
+	}
+	var catalog Catalog
+	if catalogRequired(l.covTypes...) {
+		catalogData, err := l.artifact(ctx, accountID, runID, "catalog.json")
+		if err != nil {
+			return err
+		}
+		catalog, err = ParseCatalog(catalogData, manifest)
+		if err != nil {
+			return err
+		}
+	} else if catalog, err = CatalogFromManifest(manifest); err != nil {
+		return err
+	}
+	if catalog, err = EnrichCatalog(ctx, catalog, manifest); err != nil {
+		return err
+	}
+	catalog, _ = catalog.ApplyExemptions(l.policy.exemptions, time.Now())
+	generatedAt := time.Now().UTC().Format(time.RFC3339)
+	for _, covType := range l.covTypes {
+		if err := evaluateCoverage(ctx, catalog, covType); err != nil {
+			return err
+		}
+		report := computeJSONReport(catalog, covType, l.policy.groupBy)
+		if l.policy.severities != nil {
+			applySeverities(&report, *l.policy.severities)
+		}
+		report.GeneratedAt = generatedAt
+		report.Warnings = catalog.Warnings.sorted()
+		s.mu.Lock()
+		path, err := saveToHistory(s.historyDir, report)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		log.Printf("Report %s stored from dbt Cloud run %s (%s, %s)", reportID(path), runID, covType, formatCoverage(report.Covered, report.Total))
+	}
+	return nil
+}
+
+func (l *liveRecompute) artifact(ctx context.Context, accountID int64, runID, name string) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/api/v2/accounts/%d/runs/%s/artifacts/%s", strings.TrimRight(l.baseURL, "/"), accountID,
+		url.PathEscape(runID), url.PathEscape(name))
+	return sendJSON(ctx, http.MethodGet, endpoint, nil, map[string]string{"Authorization": "Token " + l.token})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var projectNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// reservedProjectNames would shadow the routes of the root of the server.
+var reservedProjectNames = []string{"admin", "models", "projects", "reports", "webhooks"}
+
+// parseServeProjects reads --projects: acme-core,acme-finance=/data/finance.
+// A project without a directory keeps its history in historyDir/<name>.
+func parseServeProjects(spec, historyDir string) (map[string]string, error) {
+	projects := make(map[string]string)
+	for _, item := range splitList(spec) {
+		name, dir, explicit := strings.Cut(item, "=")
+		if !projectNameRegexp.MatchString(name) || containsString(reservedProjectNames, strings.ToLower(name)) {
+			return nil, fmt.Errorf("invalid project name %q in --projects", name)
+		}
+		if _, ok := projects[name]; ok {
+			return nil, fmt.Errorf("project %s listed twice in --projects", name)
+		}
+		if !explicit || dir == "" {
+			dir = filepath.Join(historyDir, name)
+		}
+		projects[name] = dir
+	}
+	return projects, nil
+}
+
+// projectsHandler serves the API of each project under /<name>/ with its own
+// history: POST /acme-core/reports, GET /acme-finance/models/{name}/timeline.
+// GET /projects lists them.
+func projectsHandler(projects map[string]*reportServer) http.Handler {
+	mux := http.NewServeMux()
+	names := make([]string, 0, len(projects))
+	for name, rs := range projects {
+		names = append(names, name)
+		mux.Handle("/"+name+"/", http.StripPrefix("/"+name, rs.handler()))
+	}
+	sort.Strings(names)
+	mux.HandleFunc("GET /projects", func(w http.ResponseWriter, r *http.Request) {
+		type project struct {
+			Name    string `json:"name"`
+			Reports string `json:"reports"`
+		}
+		list := make([]project, len(names))
+		for i, name := range names {
+			list[i] = project{Name: name, Reports: "/" + name + "/reports"}
+		}
+		writeAPIJSON(w, http.StatusOK, list)
+	})
+	return mux
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ServeConfig is the section of the configuration specific to serve --live.
+type ServeConfig struct {
+	GroupBy string `yaml:"group_by"`
+}
+
+func (c ServeConfig) validate() error {
+	if _, err := ParseGroupBy(c.GroupBy); err != nil {
+		return fmt.Errorf("serve: %w", err)
+	}
+	return nil
+}
+
+// livePolicy is what the configuration changes in the reports recomputed by
+// serve --live, swapped as a whole when the configuration is reloaded.
+type livePolicy struct {
+	settings   ParseSettings
+	exemptions []Exemption
+	severities *SeverityConfig
+	groupBy    GroupBy
+	plugins    string
+}
+
+func newLivePolicy(cfg Config, settings ParseSettings) livePolicy {
+	p := livePolicy{settings: settings, exemptions: cfg.Exemptions, plugins: fmt.Sprint(cfg.Plugins)}
+	p.groupBy, _ = ParseGroupBy(cfg.Serve.GroupBy)
+	if cfg.Severities.enabled() {
+		p.severities = &cfg.Severities
+	}
+	return p
+}
+
+// reload reads the configuration again and applies it to the next
+// recomputations: column exclusions and naming, test packages, heuristics,
+// exemptions, severities and grouping. An invalid file leaves the current
+// configuration in place. The plugins run as processes and are kept until
+// the server restarts.
+func (l *liveRecompute) reload() error {
+	cfg, err := loadConfig(l.configPath, ".")
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	settings, err := cfg.apply()
+	if err != nil {
+		return err
+	}
+	replaceHeuristicProviders(cfg.Heuristics)
+	policy := newLivePolicy(cfg, settings)
+	if policy.plugins != l.policy.plugins {
+		fmt.Fprintln(os.Stderr, "warning: the plugins changed in the configuration, restart serve to apply them")
+		policy.plugins = l.policy.plugins
+	}
+	l.policy = policy
+	l.reloadedAt = time.Now().UTC()
+	return nil
+}
+
+// reloadOnSignal reloads the configuration on each SIGHUP until ctx is done.
+func (l *liveRecompute) reloadOnSignal(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-hup:
+				if err := l.reload(); err != nil {
+					fmt.Fprintf(os.Stderr, "error reloading the configuration, keeping the current one: %v\n", err)
+					continue
+				}
+				log.Printf("Configuration reloaded")
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// reloadConfig is POST /admin/reload, authenticated by the Bearer token of
+// --admin_token.
+func (s *reportServer) reloadConfig(w http.ResponseWriter, r *http.Request) {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+		writeAPIError(w, http.StatusUnauthorized, errors.New("invalid admin token"))
+		return
+	}
+	if err := s.live.reload(); err != nil {
+		writeAPIError(w, http.StatusUnprocessableEntity, fmt.Errorf("configuration not reloaded: %w", err))
+		return
+	}
+	log.Printf("Configuration reloaded by %s", r.RemoteAddr)
+	s.live.mu.Lock()
+	reloadedAt := s.live.reloadedAt
+	s.live.mu.Unlock()
+	writeAPIJSON(w, http.StatusOK, map[string]string{"status": "reloaded", "reloaded_at": reloadedAt.Format(time.RFC3339)})
+}
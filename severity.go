@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Severities lists the levels from the most to the least severe.
+var Severities = []Severity{SeverityError, SeverityWarning, SeverityInfo}
+
+func (s Severity) rank() int {
+	for i, known := range Severities {
+		if s == known {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+// SeverityRule gives a severity to the gaps of the matching columns. Empty
+// fields match everything: a rule with only columns: [id] applies to every
+// model and coverage type.
+type SeverityRule struct {
+	Columns  []string `yaml:"columns"`
+	Select   []string `yaml:"select"`
+	Types    []string `yaml:"types"`
+	Severity Severity `yaml:"severity"`
+}
+
+type SeverityConfig struct {
+	Default Severity       `yaml:"default"`
+	Rules   []SeverityRule `yaml:"rules"`
+}
+
+func (c SeverityConfig) enabled() bool {
+	return c.Default != "" || len(c.Rules) > 0
+}
+
+func validateSeverity(s Severity) error {
+	if s.rank() == len(Severities) {
+		names := make([]string, len(Severities))
+		for i, known := range Severities {
+			names[i] = string(known)
+		}
+		return fmt.Errorf("unknown severity %q, expected one of: %s", s, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+func (c SeverityConfig) validate() error {
+	if c.Default != "" {
+		if err := validateSeverity(c.Default); err != nil {
+			return fmt.Errorf("severities: %w", err)
+		}
+	}
+	for i, rule := range c.Rules {
+		if err := validateSeverity(rule.Severity); err != nil {
+			return fmt.Errorf("severities rule %d: %w", i+1, err)
+		}
+		if _, err := ParseSelector(rule.Select); err != nil {
+			return fmt.Errorf("severities rule %d: %w", i+1, err)
+		}
+		for _, pattern := range rule.Columns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("severities rule %d: invalid pattern %q: %w", i+1, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// severity returns the severity of a gap: the first matching rule wins, then
+// the default, warning unless configured. Table-level coverage types match
+// the rules without columns.
+func (c SeverityConfig) severity(t TableReport, column string, covType string) Severity {
+	for _, rule := range c.Rules {
+		if len(rule.Types) > 0 && !containsString(rule.Types, covType) {
+			continue
+		}
+		if len(rule.Columns) > 0 && (column == "" || !matchesAny(column, rule.Columns)) {
+			continue
+		}
+		if !Selector(rule.Select).Matches(t) {
+			continue
+		}
+		return rule.Severity
+	}
+	if c.Default != "" {
+		return c.Default
+	}
+	return SeverityWarning
+}
+
+// applySeverities sets the severity of each uncovered column, and of each
+// table to the most severe of its gaps, and counts the gaps per severity.
+func applySeverities(report *JSONReport, cfg SeverityConfig) {
+	report.Severities = make(map[Severity]int)
+	for i := range report.Tables {
+		t := &report.Tables[i]
+		if t.Covered == t.Total {
+			continue
+		}
+		if len(t.Columns) == 0 {
+			t.Severity = cfg.severity(*t, "", report.CovType)
+			report.Severities[t.Severity]++
+			continue
+		}
+		for j := range t.Columns {
+			c := &t.Columns[j]
+			if c.Covered == c.Total {
+				continue
+			}
+			c.Severity = cfg.severity(*t, c.Name, report.CovType)
+			report.Severities[c.Severity]++
+			if t.Severity == "" || c.Severity.rank() < t.Severity.rank() {
+				t.Severity = c.Severity
+			}
+		}
+	}
+}
+
+// coverageGap is an uncovered column, or an uncovered model for the
+// table-level coverage types (Column empty).
+type coverageGap struct {
+	Table    TableReport
+	Column   string
+	Severity Severity
+	Line     int
+}
+
+func (g coverageGap) Name() string {
+	if g.Column == "" {
+		return g.Table.Name
+	}
+	return g.Table.Name + "." + g.Column
+}
+
+// Message describes the gap for the diagnostics formats (SARIF, rdjson).
+func (g coverageGap) Message(covType string) string {
+	if g.Column == "" {
+		return fmt.Sprintf("Model %s has no %s coverage", g.Table.Name, covType)
+	}
+	return fmt.Sprintf("Column %s of %s has no %s coverage", g.Column, g.Table.Name, covType)
+}
+
+// coverageGaps lists the gaps of the tables, in the order of the report, on
+// the line of the column in the .yml file, else of the model, else 1.
+func coverageGaps(tables []TableReport) []coverageGap {
+	var gaps []coverageGap
+	for _, t := range tables {
+		if t.Covered == t.Total {
+			continue
+		}
+		if len(t.Columns) == 0 {
+			gaps = append(gaps, coverageGap{Table: t, Severity: t.Severity, Line: t.SchemaLine()})
+			continue
+		}
+		for _, c := range t.Columns {
+			if c.Covered < c.Total {
+				line := c.Line
+				if line == 0 {
+					line = t.SchemaLine()
+				}
+				gaps = append(gaps, coverageGap{Table: t, Column: c.Name, Severity: c.Severity, Line: line})
+			}
+		}
+	}
+	return gaps
+}
+
+// severityGaps lists the model.column gaps of a severity.
+func severityGaps(tables []TableReport, severity Severity) []string {
+	var names []string
+	for _, gap := range coverageGaps(tables) {
+		if gap.Severity == severity {
+			names = append(names, gap.Name())
+		}
+	}
+	return names
+}
+
+func printSeverities(w io.Writer, report JSONReport) {
+	if report.Severities == nil {
+		return
+	}
+	counts := make([]string, len(Severities))
+	for i, s := range Severities {
+		counts[i] = fmt.Sprintf("%d %s", report.Severities[s], s)
+	}
+	fmt.Fprintf(w, "\n🚨 Gaps by severity: %s\n", strings.Join(counts, ", "))
+	for _, gap := range severityGaps(report.Tables, SeverityError) {
+		fmt.Fprintf(w, "  ⛔ %s\n", gap)
+	}
+}
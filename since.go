@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of the creation date of the models, for --since.
+const (
+	CreatedAtManifest = "manifest"
+	CreatedAtGit      = "git"
+)
+
+// creationDates returns the creation date of the tables, by unique_id. The
+// source is the created_at of the manifest nodes, the first commit adding
+// their file (git), or a YAML file mapping unique_ids, file paths or names to
+// dates. Tables without known date are missing from the map.
+func creationDates(ctx context.Context, source, projectDir string, catalog Catalog) (map[string]time.Time, error) {
+	dates := make(map[string]time.Time)
+	switch source {
+	case CreatedAtManifest:
+		for id, table := range catalog.Tables {
+			if !table.CreatedAt.IsZero() {
+				dates[id] = table.CreatedAt
+			}
+		}
+		return dates, nil
+	case CreatedAtGit:
+		added, err := gitAddedDates(ctx, projectDir)
+		if err != nil {
+			return nil, err
+		}
+		for id, table := range catalog.Tables {
+			if date, ok := added[slashPath(table.OriginalFilePath)]; ok {
+				dates[id] = date
+			}
+		}
+		return dates, nil
+	}
+	mapping, err := loadCreationDates(source)
+	if err != nil {
+		return nil, err
+	}
+	for id, table := range catalog.Tables {
+		for _, key := range []string{id, slashPath(table.OriginalFilePath), table.Name} {
+			if date, ok := mapping[key]; ok {
+				dates[id] = date
+				break
+			}
+		}
+	}
+	return dates, nil
+}
+
+// gitAddedDates reads the date of the commit adding each file of the project,
+// with paths relative to the project directory.
+func gitAddedDates(ctx context.Context, projectDir string) (map[string]time.Time, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "-C", projectDir, "log", "--diff-filter=A", "--no-renames", "--relative", "--name-only", "--format=@%aI")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log in %s: %w: %s", projectDir, err, strings.TrimSpace(stderr.String()))
+	}
+	dates := make(map[string]time.Time)
+	var current time.Time
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if date, ok := strings.CutPrefix(line, "@"); ok {
+			if current, err = time.Parse(time.RFC3339, date); err != nil {
+				return nil, fmt.Errorf("git log in %s: %w", projectDir, err)
+			}
+			continue
+		}
+		// The log goes from the newest commit, the oldest addition wins when a
+		// file was deleted and added again.
+		if line != "" {
+			dates[line] = current
+		}
+	}
+	return dates, scanner.Err()
+}
+
+func loadCreationDates(path string) (map[string]time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("creation dates: %w", err)
+	}
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid creation dates %s: %w", path, err)
+	}
+	dates := make(map[string]time.Time, len(raw))
+	for key, value := range raw {
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid creation date of %s in %s: %q, expected YYYY-MM-DD", key, path, value)
+		}
+		dates[key] = date
+	}
+	return dates, nil
+}
+
+// FilterCreatedSince keeps the tables created on or after since. Tables
+// without known creation date are considered legacy and left out.
+func (c Catalog) FilterCreatedSince(dates map[string]time.Time, since time.Time) Catalog {
+	filtered := make(map[string]Table)
+	for id, table := range c.Tables {
+		if date, ok := dates[id]; ok && !date.Before(since) {
+			filtered[id] = table
+		}
+	}
+	log.Printf("Tables created since %s: %d (%d without creation date)", since.Format(time.DateOnly), len(filtered), len(c.Tables)-len(dates))
+	c.Tables = filtered
+	return c
+}
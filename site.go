@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type siteCoverage struct {
+	CovType string
+	Covered int
+	Total   int
+	Trend   string
+}
+
+// siteColumn lists, per coverage type, whether the column is "covered",
+// "uncovered" or left empty for table-level types.
+type siteColumn struct {
+	Column
+	Status []string
+}
+
+type siteModel struct {
+	Table
+	Page     string
+	Coverage []siteCoverage
+	Columns  []siteColumn
+}
+
+type siteData struct {
+	CovTypes []string
+	Totals   []siteCoverage
+	Models   []siteModel
+}
+
+var sitePageName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+const siteStyle = `<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 12px; border-bottom: 1px solid #ddd; text-align: left; }
+td.coverage { text-align: right; }
+.yes { color: #2e7d32; } .no { color: #c62828; }
+</style>`
+
+var siteTemplates = template.Must(template.New("site").Funcs(template.FuncMap{
+	"coverage": formatCoverage,
+	"upper":    strings.ToUpper,
+}).Parse(`{{define "trend"}}{{if .}}<svg width="80" height="16" viewBox="0 0 80 16"><polyline points="{{.}}" fill="none" stroke="#2e86de"/></svg>{{end}}{{end}}
+{{define "index"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage Report</title>
+` + siteStyle + `
+</head>
+<body>
+<h1>📊 Coverage Report</h1>
+<p>{{len .Models}} models.{{range .Totals}} {{upper .CovType}}: {{coverage .Covered .Total}} ({{.Covered}}/{{.Total}}) {{template "trend" .Trend}}{{end}}</p>
+<table>
+<thead><tr><th>Model</th><th>Type</th>{{range .CovTypes}}<th>{{upper .}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Models}}
+<tr><td><a href="{{.Page}}">{{.Name}}</a></td><td>{{.ResourceType}}</td>{{range .Coverage}}<td class="coverage">{{coverage .Covered .Total}} {{template "trend" .Trend}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+{{end}}
+{{define "model"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} - Coverage Report</title>
+` + siteStyle + `
+</head>
+<body>
+<p><a href="../index.html">← All models</a></p>
+<h1>{{.Name}}</h1>
+<p><code>{{.UniqueID}}</code>, {{.ResourceType}} defined in <code>{{.OriginalFilePath}}</code>{{if .PatchPath}}, documented in <code>{{.PatchPath}}</code>{{end}}.</p>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+<table>
+<thead><tr><th>Coverage</th><th>Columns Ratio</th><th>Coverage</th><th>Trend</th></tr></thead>
+<tbody>
+{{- range .Coverage}}
+<tr><td>{{upper .CovType}}</td><td>({{.Covered}}/{{.Total}})</td><td class="coverage">{{coverage .Covered .Total}}</td><td>{{template "trend" .Trend}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<h2>Columns</h2>
+<table>
+<thead><tr><th>Column</th><th>Description</th><th>Tests</th>{{range .Coverage}}<th>{{upper .CovType}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Columns}}
+<tr><td><code>{{.Name}}</code></td><td>{{.Description}}</td><td>{{range $i, $t := .TestNames}}{{if $i}}, {{end}}{{$t}}{{end}}</td>{{range .Status}}<td>{{if eq . "covered"}}<span class="yes">✔</span>{{else if eq . "uncovered"}}<span class="no">✘</span>{{end}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+{{end}}`))
+
+func runSite(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("site")
+	var (
+		projectDir      = fs.String("dbt_dir", ".", "dbt project path")
+		runArtifactsDir = fs.String("target_dir", "target", "dbt target path")
+		configPath      = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		nameFormat      = fs.String("name_format", DefaultNameFormat, "Model display name template (see the main command)")
+		covTypesStr     = fs.String("types", "doc,test", "Coverage types shown on the pages (split using ',')")
+		historyDir      = fs.String("history_dir", "", "History directory, to draw the coverage trends")
+		output          = fs.String("output", "public", "Directory where the site is written")
+		labels          = make(labelsFlag)
+	)
+	fs.Var(labels, "label", "Only draw the trends of the reports of --history_dir carrying this label, key=value, repeatable (e.g. --label env=prod)")
+	fs.StringVar(output, "o", "public", "Shorthand for --output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	if err := ValidateNameFormat(*nameFormat); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*configPath, *projectDir)
+	if err != nil {
+		return err
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		return err
+	}
+	settings.NameFormat = *nameFormat
+	defer closeCoverageProviders()
+	registerHeuristicProviders(cfg.Heuristics)
+	if err := registerPlugins(cfg.Plugins); err != nil {
+		return err
+	}
+	var covTypes []CoverageType
+	for _, name := range splitList(*covTypesStr) {
+		if _, err := lookupCoverageProvider(CoverageType(name)); err != nil {
+			return err
+		}
+		covTypes = append(covTypes, CoverageType(name))
+	}
+
+	catalog, err := loadFiles(ctx, *projectDir, *runArtifactsDir, catalogRequired(covTypes...), settings)
+	if err != nil {
+		return err
+	}
+	history := make(map[CoverageType][]HistoryEntry)
+	for _, covType := range covTypes {
+		if err := evaluateCoverage(ctx, catalog, covType); err != nil {
+			return err
+		}
+		if *historyDir != "" {
+			entries, err := loadHistory(*historyDir, string(covType))
+			if err != nil {
+				return err
+			}
+			history[covType] = filterHistoryByLabels(entries, labels)
+		}
+	}
+	pages, err := writeSite(*output, buildSiteData(catalog, covTypes, history))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Coverage site written into %s (%d model pages)\n", *output, pages)
+	return nil
+}
+
+func buildSiteData(catalog Catalog, covTypes []CoverageType, history map[CoverageType][]HistoryEntry) siteData {
+	var data siteData
+	for _, covType := range covTypes {
+		data.CovTypes = append(data.CovTypes, string(covType))
+		report := computeJSONReport(catalog, covType, GroupByNone)
+		total := siteCoverage{CovType: string(covType), Covered: report.Covered, Total: report.Total}
+		if entries := history[covType]; len(entries) >= 2 {
+			values := make([]trendPoint, len(entries))
+			for i, entry := range entries {
+				values[i] = trendPoint{entry.GeneratedAt, entry.Report.Coverage}
+			}
+			total.Trend = polylinePoints(values, sparklineWidth, sparklineHeight)
+		}
+		data.Totals = append(data.Totals, total)
+	}
+	for _, id := range sortedKeys(catalog.Tables) {
+		table := catalog.Tables[id]
+		model := siteModel{Table: table, Page: "models/" + sitePageName.ReplaceAllString(id, "_") + ".html"}
+		for _, col := range table.SortedColumns() {
+			column := siteColumn{Column: col}
+			for _, covType := range covTypes {
+				status := ""
+				if covered, applicable := col.Coverage[covType]; applicable {
+					status = "uncovered"
+					if covered {
+						status = "covered"
+					}
+				}
+				column.Status = append(column.Status, status)
+			}
+			model.Columns = append(model.Columns, column)
+		}
+		for _, covType := range covTypes {
+			cov := siteCoverage{CovType: string(covType)}
+			cov.Covered, cov.Total = tableLevelCoverage(table, covType)
+			for _, col := range table.Columns {
+				if covered, applicable := col.Coverage[covType]; applicable {
+					cov.Total++
+					if covered {
+						cov.Covered++
+					}
+				}
+			}
+			cov.Trend = modelTrend(history[covType], table.UniqueID)
+			model.Coverage = append(model.Coverage, cov)
+		}
+		data.Models = append(data.Models, model)
+	}
+	sort.SliceStable(data.Models, func(i, j int) bool { return data.Models[i].Name < data.Models[j].Name })
+	return data
+}
+
+func modelTrend(entries []HistoryEntry, uniqueID string) string {
+	var values []trendPoint
+	for _, entry := range entries {
+		for _, t := range entry.Report.Tables {
+			if t.UniqueID == uniqueID {
+				values = append(values, trendPoint{entry.GeneratedAt, t.Coverage})
+				break
+			}
+		}
+	}
+	if len(values) < 2 {
+		return ""
+	}
+	return polylinePoints(values, sparklineWidth, sparklineHeight)
+}
+
+func writeSite(dir string, data siteData) (int, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "models"), 0755); err != nil {
+		return 0, err
+	}
+	render := func(path, name string, v interface{}) error {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := siteTemplates.ExecuteTemplate(f, name, v); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	log.Printf("Writing the coverage site into %s", dir)
+	if err := render(filepath.Join(dir, "index.html"), "index", data); err != nil {
+		return 0, err
+	}
+	for _, model := range data.Models {
+		if err := render(filepath.Join(dir, filepath.FromSlash(model.Page)), "model", model); err != nil {
+			return 0, err
+		}
+	}
+	return len(data.Models), nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import "strings"
+
+// snapshotMetaColumns are the columns dbt adds to every snapshot, excluded
+// unless ParseSettings.IncludeSnapshotMetaColumns since nobody documents nor
+// tests them.
+var snapshotMetaColumns = []string{"dbt_scd_id", "dbt_updated_at", "dbt_valid_from", "dbt_valid_to", "dbt_is_deleted"}
+
+// isSnapshotMetaColumn tells whether a column of a manifest node is one of
+// the snapshot metadata columns, renamed or not through the
+// snapshot_meta_column_names config (dbt 1.9+).
+func isSnapshotMetaColumn(node map[string]interface{}, column string) bool {
+	if node["resource_type"] != "snapshot" {
+		return false
+	}
+	names := snapshotMetaColumns
+	config, _ := node["config"].(map[string]interface{})
+	renamed, _ := config["snapshot_meta_column_names"].(map[string]interface{})
+	for _, v := range renamed {
+		if name, ok := v.(string); ok && name != "" {
+			names = append(names[:len(names):len(names)], name)
+		}
+	}
+	for _, name := range names {
+		if strings.EqualFold(name, column) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// printSummaryLine writes the last line of a run, meant for the log scrapers
+// of CI coverage extractors (GitLab coverage regex, Jenkins). It is printed
+// whatever the verbosity, with result=fail when the run errors, and its format
+// must stay stable:
+//
+//	COVERAGE type=doc covered=812 total=1000 pct=81.2 threshold=80 result=pass
+func printSummaryLine(w io.Writer, report JSONReport, threshold float64, pass bool) {
+	thresholdValue := "none"
+	if threshold > 0 {
+		thresholdValue = strconv.FormatFloat(threshold, 'f', -1, 64)
+	}
+	result := "pass"
+	if !pass {
+		result = "fail"
+	}
+	fmt.Fprintf(w, "COVERAGE type=%s covered=%d total=%d pct=%.1f threshold=%s result=%s\n",
+		report.CovType, report.Covered, report.Total, ratio(report.Covered, report.Total)*100, thresholdValue, result)
+}
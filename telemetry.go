@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const telemetryScope = "dbt-goverage"
+
+// telemetry exports spans and coverage gauges to an OTLP/HTTP collector
+// (JSON encoding). A nil *telemetry is valid and exports nothing.
+type telemetry struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+}
+
+// newTelemetry configures the exporter from the given endpoint, falling back
+// to the standard OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS and
+// OTEL_SERVICE_NAME variables.
+func newTelemetry(endpoint string) *telemetry {
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		return nil
+	}
+	headers := make(map[string]string)
+	for _, pair := range splitList(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")) {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return &telemetry{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		headers:  headers,
+		service:  envOrDefault("OTEL_SERVICE_NAME", "dbt-goverage"),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitempty"`
+	Name         string          `json:"name"`
+	Kind         int             `json:"kind"`
+	Start        string          `json:"startTimeUnixNano"`
+	End          string          `json:"endTimeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+	Status       otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpDataPoint struct {
+	AsDouble   *float64        `json:"asDouble,omitempty"`
+	AsInt      string          `json:"asInt,omitempty"`
+	Time       string          `json:"timeUnixNano"`
+	Attributes []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpMetric struct {
+	Name  string `json:"name"`
+	Unit  string `json:"unit,omitempty"`
+	Gauge struct {
+		DataPoints []otlpDataPoint `json:"dataPoints"`
+	} `json:"gauge"`
+}
+
+func stringAttr(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]interface{}{"stringValue": value}}
+}
+
+func intAttr(key string, value int) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]interface{}{"intValue": strconv.Itoa(value)}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func randomID(size int) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func spanStatus(err error) otlpStatus {
+	if err != nil {
+		return otlpStatus{Code: 2, Message: err.Error()}
+	}
+	return otlpStatus{Code: 1}
+}
+
+// exportRun sends one trace for the coverage run (a root span and one child
+// span per completed phase) and, when it succeeded, the coverage gauges of
+// the report.
+func (t *telemetry) exportRun(ctx context.Context, timings *phaseTimings, covType CoverageType, report JSONReport, runErr error) {
+	if t == nil {
+		return
+	}
+	traceID, rootID := randomID(16), randomID(8)
+	attrs := []otlpAttribute{
+		stringAttr("dbt_coverage.cov_type", string(covType)),
+		intAttr("dbt_coverage.tables", len(report.Tables)),
+		intAttr("dbt_coverage.columns", report.Total),
+	}
+	if report.GitSHA != "" {
+		attrs = append(attrs, stringAttr("vcs.ref.head.revision", report.GitSHA))
+	}
+	spans := []otlpSpan{{
+		TraceID: traceID, SpanID: rootID, Name: "coverage", Kind: 1,
+		Start:      unixNano(timings.start),
+		End:        unixNano(time.Now()),
+		Attributes: attrs,
+		Status:     spanStatus(runErr),
+	}}
+	for _, p := range timings.phases {
+		spans = append(spans, otlpSpan{
+			TraceID: traceID, SpanID: randomID(8), ParentSpanID: rootID, Name: p.Name, Kind: 1,
+			Start:  unixNano(p.Start),
+			End:    unixNano(p.Start.Add(p.Duration)),
+			Status: spanStatus(nil),
+		})
+	}
+	t.exportSpans(ctx, spans)
+	if runErr != nil {
+		return
+	}
+
+	now := unixNano(time.Now())
+	covTypeAttr := stringAttr("dbt_coverage.cov_type", string(covType))
+	ratio := func(v float64) *float64 { return &v }
+	gauge := func(name, unit string, points ...otlpDataPoint) otlpMetric {
+		m := otlpMetric{Name: name, Unit: unit}
+		m.Gauge.DataPoints = points
+		return m
+	}
+	modelPoints := make([]otlpDataPoint, 0, len(report.Tables))
+	for _, table := range report.Tables {
+		modelPoints = append(modelPoints, otlpDataPoint{AsDouble: ratio(table.Coverage), Time: now,
+			Attributes: []otlpAttribute{covTypeAttr, stringAttr("dbt_coverage.model", table.Name)}})
+	}
+	t.post(ctx, "/v1/metrics", "resourceMetrics", "scopeMetrics", "metrics", []otlpMetric{
+		gauge("dbt_coverage.ratio", "1", otlpDataPoint{AsDouble: ratio(report.Coverage), Time: now, Attributes: []otlpAttribute{covTypeAttr}}),
+		gauge("dbt_coverage.columns.covered", "{column}", otlpDataPoint{AsInt: strconv.Itoa(report.Covered), Time: now, Attributes: []otlpAttribute{covTypeAttr}}),
+		gauge("dbt_coverage.columns.total", "{column}", otlpDataPoint{AsInt: strconv.Itoa(report.Total), Time: now, Attributes: []otlpAttribute{covTypeAttr}}),
+		gauge("dbt_coverage.model.ratio", "1", modelPoints...),
+	})
+}
+
+// exportSpan sends a single-span trace, used for the publish subcommands.
+func (t *telemetry) exportSpan(ctx context.Context, name string, start time.Time, err error, attrs ...otlpAttribute) {
+	if t == nil {
+		return
+	}
+	t.exportSpans(ctx, []otlpSpan{{
+		TraceID: randomID(16), SpanID: randomID(8), Name: name, Kind: 3,
+		Start: unixNano(start), End: unixNano(time.Now()), Attributes: attrs, Status: spanStatus(err),
+	}})
+}
+
+func (t *telemetry) exportSpans(ctx context.Context, spans []otlpSpan) {
+	t.post(ctx, "/v1/traces", "resourceSpans", "scopeSpans", "spans", spans)
+}
+
+func (t *telemetry) post(ctx context.Context, path, resourceKey, scopeKey, itemsKey string, items interface{}) {
+	payload := map[string]interface{}{
+		resourceKey: []map[string]interface{}{{
+			"resource": map[string]interface{}{"attributes": []otlpAttribute{stringAttr("service.name", t.service)}},
+			scopeKey: []map[string]interface{}{{
+				"scope":  map[string]string{"name": telemetryScope},
+				itemsKey: items,
+			}},
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		warnf("OpenTelemetry export failed: %v", err)
+		return
+	}
+	// The run context may already be canceled, the export gets its own deadline.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		warnf("OpenTelemetry export failed: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		warnf("OpenTelemetry export failed: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		warnf("OpenTelemetry export failed: %s returned %s", t.endpoint+path, resp.Status)
+	}
+}
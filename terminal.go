@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+)
+
+const (
+	defaultTerminalWidth = 120
+	minModelNameWidth    = 20
+)
+
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	return defaultTerminalWidth
+}
+
+// modelNameWidth returns the room left for the model names once the other
+// columns of the console table are laid out, 0 meaning no limit.
+func modelNameWidth(fullNames bool, withScore bool) int {
+	if fullNames {
+		return 0
+	}
+	width := terminalWidth() - 27
+	if withScore {
+		width -= 8
+	}
+	return max(width, minModelNameWidth)
+}
+
+func truncateName(name string, width int) string {
+	runes := []rune(name)
+	if width <= 0 || len(runes) <= width {
+		return name
+	}
+	head := (width - 1) / 2
+	tail := width - 1 - head
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+}
+
+// colorEnabled tells whether ANSI colors may be written to w, following the
+// NO_COLOR (https://no-color.org) and CLICOLOR/CLICOLOR_FORCE conventions:
+// NO_COLOR always wins, CLICOLOR_FORCE colors pipes too, CLICOLOR=0 disables
+// colors, and otherwise only terminals are colored so logs stay clean.
+func colorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if os.Getenv("CLICOLOR") == "0" {
+		return false
+	}
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd())) && enableVirtualTerminal(f)
+}
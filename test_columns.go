@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestColumnsConfig maps a test macro (name or namespace.name) to the paths,
+// relative to its test_metadata, of the kwargs holding the tested columns:
+// kwargs.column_name for a single column, kwargs.combination_of_columns[] for
+// a list.
+type TestColumnsConfig map[string]KwargsPaths
+
+// KwargsPaths accepts a single path or a list of paths.
+type KwargsPaths []string
+
+func (p *KwargsPaths) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*p = KwargsPaths{value.Value}
+		return nil
+	}
+	var paths []string
+	if err := value.Decode(&paths); err != nil {
+		return err
+	}
+	*p = paths
+	return nil
+}
+
+type kwargsSegment struct {
+	key  string
+	list bool
+}
+
+type kwargsPath []kwargsSegment
+
+func (c TestColumnsConfig) validate() error {
+	_, err := c.compile()
+	return err
+}
+
+func (c TestColumnsConfig) compile() (map[string][]kwargsPath, error) {
+	compiled := make(map[string][]kwargsPath, len(c))
+	for macro, paths := range c {
+		for _, raw := range paths {
+			path, err := parseKwargsPath(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid test_columns path %q for %s: %w", raw, macro, err)
+			}
+			compiled[strings.ToLower(macro)] = append(compiled[strings.ToLower(macro)], path)
+		}
+	}
+	return compiled, nil
+}
+
+func parseKwargsPath(s string) (kwargsPath, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	var path kwargsPath
+	for _, part := range strings.Split(s, ".") {
+		key, list := strings.CutSuffix(part, "[]")
+		if key == "" || strings.ContainsAny(key, "[]") {
+			return nil, fmt.Errorf("invalid segment %q, expected key or key[]", part)
+		}
+		path = append(path, kwargsSegment{key: key, list: list})
+	}
+	return path, nil
+}
+
+func (p kwargsPath) extract(v interface{}) []string {
+	if len(p) == 0 {
+		if s, ok := v.(string); ok && s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	next := m[p[0].key]
+	if !p[0].list {
+		return p[1:].extract(next)
+	}
+	items, _ := next.([]interface{})
+	var values []string
+	for _, item := range items {
+		values = append(values, p[1:].extract(item)...)
+	}
+	return values
+}
+
+// configuredTestColumns returns the columns of a test whose macro has paths in
+// test_columns, and whether the macro is configured at all.
+func configuredTestColumns(testMeta map[string]interface{}, testColumns map[string][]kwargsPath) ([]string, bool) {
+	paths, ok := testColumns[strings.ToLower(testMacroName(testMeta))]
+	if !ok {
+		name, _ := testMeta["name"].(string)
+		if paths, ok = testColumns[strings.ToLower(name)]; !ok {
+			return nil, false
+		}
+	}
+	var columns []string
+	for _, path := range paths {
+		columns = append(columns, path.extract(testMeta)...)
+	}
+	return columns, true
+}
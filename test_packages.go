@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// BuiltinTestPackage is the package of the generic tests shipped with dbt
+// (unique, not_null, accepted_values, relationships).
+const BuiltinTestPackage = "dbt"
+
+var builtinTests = []string{"unique", "not_null", "accepted_values", "relationships"}
+
+// TestPackagesConfig keeps or ignores the generic tests by the package
+// defining their macro: include: [dbt, dbt_utils] or exclude: [elementary].
+// An ignored test credits no column, as if it did not exist.
+type TestPackagesConfig struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+func (c TestPackagesConfig) validate() error {
+	for _, pattern := range append(append([]string(nil), c.Include...), c.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("test_packages: invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (c TestPackagesConfig) Includes(pkg string) bool {
+	if len(c.Include) > 0 && !matchesAny(pkg, c.Include) {
+		return false
+	}
+	return !matchesAny(pkg, c.Exclude)
+}
+
+// testPackage returns the package defining the macro of a generic test: the
+// namespace of dbt_utils.unique_combination_of_columns or
+// elementary.volume_anomalies, dbt for the built-in tests, and the package of
+// the test node for the generic tests of the project itself.
+func testPackage(node, testMeta map[string]interface{}) string {
+	if namespace, _ := testMeta["namespace"].(string); namespace != "" {
+		return namespace
+	}
+	if name, _ := testMeta["name"].(string); containsString(builtinTests, name) {
+		return BuiltinTestPackage
+	}
+	if pkg, _ := node["package_name"].(string); pkg != "" {
+		return pkg
+	}
+	return BuiltinTestPackage
+}
+
+// TestPackageUsage counts the tests of the analyzed tables defined by a
+// package. Native tests are the built-in ones and the generic tests written
+// in a package of the analyzed tables, the project itself in general.
+type TestPackageUsage struct {
+	Package  string `json:"package"`
+	Tests    int    `json:"tests"`
+	Native   bool   `json:"native"`
+	Excluded bool   `json:"excluded,omitempty"`
+}
+
+func testPackageUsage(tables map[string]Table, cfg TestPackagesConfig) []TestPackageUsage {
+	tablePackages := make(map[string]bool)
+	for _, table := range tables {
+		tablePackages[table.PackageName] = true
+	}
+	byPackage := make(map[string]*TestPackageUsage)
+	for _, table := range tables {
+		for pkg, n := range table.TestPackages {
+			usage, ok := byPackage[pkg]
+			if !ok {
+				usage = &TestPackageUsage{
+					Package:  pkg,
+					Native:   pkg == BuiltinTestPackage || tablePackages[pkg],
+					Excluded: !cfg.Includes(pkg),
+				}
+				byPackage[pkg] = usage
+			}
+			usage.Tests += n
+		}
+	}
+	usages := make([]TestPackageUsage, 0, len(byPackage))
+	for _, usage := range byPackage {
+		usages = append(usages, *usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Tests != usages[j].Tests {
+			return usages[i].Tests > usages[j].Tests
+		}
+		return usages[i].Package < usages[j].Package
+	})
+	return usages
+}
+
+func printTestPackages(w io.Writer, usages []TestPackageUsage) {
+	if len(usages) == 0 {
+		return
+	}
+	native, total := 0, 0
+	parts := make([]string, len(usages))
+	for i, u := range usages {
+		parts[i] = fmt.Sprintf("%s %d", u.Package, u.Tests)
+		if u.Excluded {
+			parts[i] += " (excluded)"
+		}
+		if u.Native {
+			native += u.Tests
+		}
+		total += u.Tests
+	}
+	fmt.Fprintf(w, "\n📦 Tests by package: %s — %d/%d native\n", strings.Join(parts, ", "), native, total)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+func runTUI(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("tui")
+	var (
+		projectDir      = fs.String("dbt_dir", ".", "dbt project path")
+		runArtifactsDir = fs.String("target_dir", "target", "dbt target path")
+		covTypeStr      = fs.String("type", "test", "Coverage type (doc, test, accepted_values, relationships, description, contract, unit_test, model_test, monitoring, freshness, meta:<key> or a plugin name)")
+		configPath      = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		reportPath      = fs.String("report", "", "Explore this coverage report (JSON) instead of computing one from the dbt artifacts")
+		nameFormat      = fs.String("name_format", DefaultNameFormat, "Model display name template (see the main command)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("tui needs an interactive terminal, use list or the console report instead")
+	}
+
+	report, closeProviders, err := loadOrComputeReport(ctx, *reportPath, *projectDir, *runArtifactsDir, *configPath, *covTypeStr, *nameFormat)
+	if err != nil {
+		return err
+	}
+	defer closeProviders()
+	return runTUILoop(ctx, os.Stdin, os.Stdout, newTUIModel(report))
+}
+
+// runTUILoop switches the terminal to raw mode on the alternate screen and
+// redraws the model after every key press until it quits.
+func runTUILoop(ctx context.Context, in *os.File, out *os.File, m *tuiModel) error {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return err
+	}
+	defer term.Restore(int(in.Fd()), state)
+	if !enableVirtualTerminal(out) {
+		return errors.New("this console does not support ANSI escape sequences")
+	}
+	io.WriteString(out, "\x1b[?1049h\x1b[?25l")
+	defer io.WriteString(out, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan string)
+	go func() {
+		defer close(keys)
+		buf := make([]byte, 64)
+		for {
+			n, err := in.Read(buf)
+			if err != nil {
+				return
+			}
+			for _, key := range decodeKeys(buf[:n]) {
+				keys <- key
+			}
+		}
+	}()
+	for !m.quit {
+		if width, height, err := term.GetSize(int(out.Fd())); err == nil {
+			m.width, m.height = width, height
+		}
+		io.WriteString(out, "\x1b[H\x1b[2J"+strings.ReplaceAll(m.View(), "\n", "\r\n"))
+		select {
+		case <-ctx.Done():
+			return nil
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			m.Update(key)
+		}
+	}
+	return nil
+}
+
+// decodeKeys turns the bytes read from a raw terminal into key names: the
+// arrows and the editing keys get a name, the other characters stand for
+// themselves.
+func decodeKeys(data []byte) []string {
+	var keys []string
+	for len(data) > 0 {
+		switch {
+		case len(data) >= 3 && data[0] == 0x1b && (data[1] == '[' || data[1] == 'O'):
+			switch data[2] {
+			case 'A':
+				keys = append(keys, "up")
+			case 'B':
+				keys = append(keys, "down")
+			case 'C':
+				keys = append(keys, "right")
+			case 'D':
+				keys = append(keys, "left")
+			case 'H':
+				keys = append(keys, "home")
+			case 'F':
+				keys = append(keys, "end")
+			}
+			data = data[3:]
+			// Skip the parameters of the longer sequences (page up: ESC [ 5 ~).
+			if len(data) > 0 && data[0] == '~' {
+				data = data[1:]
+			}
+			continue
+		case data[0] == 0x1b:
+			keys = append(keys, "esc")
+		case data[0] == '\r' || data[0] == '\n':
+			keys = append(keys, "enter")
+		case data[0] == 0x7f || data[0] == 0x08:
+			keys = append(keys, "backspace")
+		case data[0] == 0x03:
+			keys = append(keys, "ctrl+c")
+		default:
+			r := []rune(string(data))
+			keys = append(keys, string(r[0]))
+			data = data[len(string(r[0])):]
+			continue
+		}
+		data = data[1:]
+	}
+	return keys
+}
+
+type tuiLevel int
+
+const (
+	tuiFolders tuiLevel = iota
+	tuiModels
+	tuiColumns
+)
+
+type tuiSort int
+
+const (
+	tuiSortName tuiSort = iota
+	tuiSortCoverage
+	tuiSortGap
+)
+
+var tuiSortNames = []string{"name", "coverage", "uncovered"}
+
+type tuiRow struct {
+	Name    string
+	Covered int
+	Total   int
+	table   int
+}
+
+// tuiModel holds the state of the tui subcommand. Update applies a key press
+// and View draws the screen, so the navigation is tested without a terminal.
+type tuiModel struct {
+	report    JSONReport
+	level     tuiLevel
+	folder    string
+	table     int
+	cursor    int
+	filter    string
+	filtering bool
+	sort      tuiSort
+	width     int
+	height    int
+	quit      bool
+}
+
+func newTUIModel(report JSONReport) *tuiModel {
+	return &tuiModel{report: report, width: defaultTerminalWidth, height: 24}
+}
+
+func reportFolder(t TableReport) string {
+	return path.Dir(slashPath(t.OriginalFilePath))
+}
+
+// rows lists the entries of the current level once filtered and sorted.
+func (m *tuiModel) rows() []tuiRow {
+	var rows []tuiRow
+	switch m.level {
+	case tuiFolders:
+		byName := make(map[string]*tuiRow)
+		for _, t := range m.report.Tables {
+			folder := reportFolder(t)
+			row, ok := byName[folder]
+			if !ok {
+				row = &tuiRow{Name: folder}
+				byName[folder] = row
+			}
+			row.Covered += t.Covered
+			row.Total += t.Total
+		}
+		for _, row := range byName {
+			rows = append(rows, *row)
+		}
+	case tuiModels:
+		for i, t := range m.report.Tables {
+			if reportFolder(t) == m.folder {
+				rows = append(rows, tuiRow{Name: t.Name, Covered: t.Covered, Total: t.Total, table: i})
+			}
+		}
+	case tuiColumns:
+		for _, c := range m.report.Tables[m.table].Columns {
+			rows = append(rows, tuiRow{Name: c.Name, Covered: c.Covered, Total: c.Total})
+		}
+	}
+	if m.filter != "" {
+		filter := strings.ToLower(m.filter)
+		kept := rows[:0]
+		for _, row := range rows {
+			if strings.Contains(strings.ToLower(row.Name), filter) {
+				kept = append(kept, row)
+			}
+		}
+		rows = kept
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		switch m.sort {
+		case tuiSortCoverage:
+			if ra, rb := ratio(a.Covered, a.Total), ratio(b.Covered, b.Total); ra != rb {
+				return ra < rb
+			}
+		case tuiSortGap:
+			if ga, gb := a.Total-a.Covered, b.Total-b.Covered; ga != gb {
+				return ga > gb
+			}
+		}
+		return a.Name < b.Name
+	})
+	return rows
+}
+
+func (m *tuiModel) Update(key string) {
+	if m.filtering {
+		switch key {
+		case "enter":
+			m.filtering = false
+		case "esc":
+			m.filtering, m.filter, m.cursor = false, "", 0
+		case "backspace":
+			if r := []rune(m.filter); len(r) > 0 {
+				m.filter, m.cursor = string(r[:len(r)-1]), 0
+			}
+		case "ctrl+c":
+			m.quit = true
+		default:
+			if len([]rune(key)) == 1 {
+				m.filter, m.cursor = m.filter+key, 0
+			}
+		}
+		return
+	}
+	rows := m.rows()
+	switch key {
+	case "q", "ctrl+c":
+		m.quit = true
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, max(len(rows)-1, 0))
+	case "home", "g":
+		m.cursor = 0
+	case "end", "G":
+		m.cursor = max(len(rows)-1, 0)
+	case "enter", "right", "l":
+		if m.cursor >= len(rows) {
+			return
+		}
+		switch m.level {
+		case tuiFolders:
+			m.level, m.folder = tuiModels, rows[m.cursor].Name
+		case tuiModels:
+			m.level, m.table = tuiColumns, rows[m.cursor].table
+		default:
+			return
+		}
+		m.cursor, m.filter = 0, ""
+	case "esc", "left", "h", "backspace":
+		if m.level == tuiFolders {
+			return
+		}
+		m.level--
+		m.cursor, m.filter = 0, ""
+	case "/":
+		m.filtering = true
+	case "s":
+		m.sort = (m.sort + 1) % tuiSort(len(tuiSortNames))
+		m.cursor = 0
+	}
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+	title := fmt.Sprintf("📊 %s coverage: %s (%d/%d)", strings.ToUpper(m.report.CovType), formatCoverage(m.report.Covered, m.report.Total), m.report.Covered, m.report.Total)
+	switch m.level {
+	case tuiFolders:
+		b.WriteString(title + "\n")
+	case tuiModels:
+		b.WriteString(title + " › " + m.folder + "\n")
+	case tuiColumns:
+		b.WriteString(title + " › " + m.folder + " › " + m.report.Tables[m.table].Name + "\n")
+	}
+	switch {
+	case m.filtering:
+		fmt.Fprintf(&b, "/%s▏\n", m.filter)
+	case m.filter != "":
+		fmt.Fprintf(&b, "filter: %s\n", m.filter)
+	default:
+		b.WriteString("\n")
+	}
+
+	rows := m.rows()
+	// Three lines for the title, the filter and the help.
+	visible := max(m.height-3, 1)
+	first := 0
+	if m.cursor >= visible {
+		first = m.cursor - visible + 1
+	}
+	nameWidth := max(m.width-24, minModelNameWidth)
+	for i := first; i < len(rows) && i < first+visible; i++ {
+		row := rows[i]
+		line := fmt.Sprintf("%-*s %12s %8s", nameWidth, truncateName(row.Name, nameWidth),
+			fmt.Sprintf("(%d/%d)", row.Covered, row.Total), formatCoverage(row.Covered, row.Total))
+		if i == m.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(rows) == 0 {
+		b.WriteString("(nothing to show)\n")
+	}
+	fmt.Fprintf(&b, "↑/↓ move · enter open · ← back · / filter · s sort: %s · q quit", tuiSortNames[m.sort])
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// UnattributedTest is a generic test whose column could not be resolved
+// (no column_name nor arg), so it is not counted in the test coverage.
+type UnattributedTest struct {
+	UniqueID string                 `json:"unique_id"`
+	Model    string                 `json:"model,omitempty"`
+	TestName string                 `json:"test_name"`
+	Kwargs   map[string]interface{} `json:"kwargs,omitempty"`
+}
+
+func newUnattributedTest(id, tableID string, testMeta map[string]interface{}) UnattributedTest {
+	kwargs := make(map[string]interface{})
+	if raw, ok := testMeta["kwargs"].(map[string]interface{}); ok {
+		for k, v := range raw {
+			// model is the jinja reference to the tested node, already in Model.
+			if k != "model" {
+				kwargs[k] = v
+			}
+		}
+	}
+	return UnattributedTest{
+		UniqueID: id,
+		Model:    tableID,
+		TestName: testMacroName(testMeta),
+		Kwargs:   kwargs,
+	}
+}
+
+func sortUnattributedTests(tests []UnattributedTest) {
+	sort.Slice(tests, func(i, j int) bool { return tests[i].UniqueID < tests[j].UniqueID })
+}
+
+// unattributedFor keeps the unattributed tests of the analyzed tables.
+func unattributedFor(tests []UnattributedTest, tables map[string]Table) []UnattributedTest {
+	var kept []UnattributedTest
+	for _, t := range tests {
+		if _, ok := tables[t.Model]; ok || t.Model == "" {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+const unattributedExamples = 10
+
+func printUnattributedTests(w io.Writer, tests []UnattributedTest) {
+	if len(tests) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n🧩 %d tests not attributed to any column (not counted):\n", len(tests))
+	for _, t := range tests[:min(len(tests), unattributedExamples)] {
+		kwargs, _ := json.Marshal(t.Kwargs)
+		fmt.Fprintf(w, "  - %s (%s) %s\n", t.UniqueID, t.TestName, kwargs)
+	}
+	if len(tests) > unattributedExamples {
+		fmt.Fprintf(w, "  … %d more, see unattributed_tests in the JSON report\n", len(tests)-unattributedExamples)
+	}
+}
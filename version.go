@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// version is set at build time by the release workflow:
+// go build -ldflags "-X main.version=v1.4.0".
+var version = "dev"
+
+const (
+	defaultReleasesURL = "https://api.github.com/repos/mickaelandrieu/dbt-goverage/releases/latest"
+	releaseChecksums   = "checksums.txt"
+)
+
+func currentVersion() string {
+	if version != "dev" {
+		return version
+	}
+	// go install github.com/...@v1.4.0 records the module version instead;
+	// a local go build records a pseudo-version, still a dev build.
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" && !isPseudoVersion(info.Main.Version) {
+		return info.Main.Version
+	}
+	return version
+}
+
+// isPseudoVersion recognizes the v0.0.0-20240102150405-0123456789ab versions
+// Go records for untagged commits.
+func isPseudoVersion(v string) bool {
+	v, _, _ = strings.Cut(v, "+")
+	parts := strings.Split(v, "-")
+	if len(parts) < 3 {
+		return false
+	}
+	revision, timestamp := parts[len(parts)-1], parts[len(parts)-2]
+	if i := strings.LastIndex(timestamp, "."); i >= 0 {
+		timestamp = timestamp[i+1:]
+	}
+	_, errRevision := strconv.ParseUint(revision, 16, 64)
+	_, errTimestamp := strconv.ParseUint(timestamp, 10, 64)
+	return len(revision) == 12 && len(timestamp) == 14 && errRevision == nil && errTimestamp == nil
+}
+
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+	Assets  []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func (r githubRelease) assetURL(name string) string {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.BrowserDownloadURL
+		}
+	}
+	return ""
+}
+
+func fetchLatestRelease(ctx context.Context, releasesURL string) (githubRelease, error) {
+	headers := map[string]string{"Accept": "application/vnd.github+json"}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	data, err := sendJSON(ctx, http.MethodGet, releasesURL, nil, headers)
+	if err != nil {
+		return githubRelease{}, fmt.Errorf("cannot fetch the latest release: %w", err)
+	}
+	var release githubRelease
+	if err := json.Unmarshal(data, &release); err != nil {
+		return githubRelease{}, fmt.Errorf("invalid release: %w", err)
+	}
+	if release.TagName == "" {
+		return githubRelease{}, errors.New("invalid release: no tag_name")
+	}
+	return release, nil
+}
+
+// compareVersions compares two vMAJOR.MINOR.PATCH versions, a pre-release
+// suffix (v1.2.0-rc.1) being ignored. ok is false when one of them is not a
+// release version, such as dev builds.
+func compareVersions(a, b string) (cmp int, ok bool) {
+	parse := func(v string) ([3]int, bool) {
+		var parts [3]int
+		v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "-")
+		fields := strings.Split(v, ".")
+		if len(fields) != 3 {
+			return parts, false
+		}
+		for i, f := range fields {
+			n, err := strconv.Atoi(f)
+			if err != nil {
+				return parts, false
+			}
+			parts[i] = n
+		}
+		return parts, true
+	}
+	va, okA := parse(a)
+	vb, okB := parse(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+func runVersion(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("version")
+	var (
+		check       = fs.Bool("check", false, "Also check whether a newer release is available on GitHub")
+		releasesURL = fs.String("releases_url", defaultReleasesURL, "GitHub API URL of the latest release")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+	current := currentVersion()
+	fmt.Printf("dbt-goverage %s (%s/%s)\n", current, runtime.GOOS, runtime.GOARCH)
+	if !*check {
+		return nil
+	}
+	release, err := fetchLatestRelease(ctx, *releasesURL)
+	if err != nil {
+		return err
+	}
+	fmt.Println(versionCheckMessage(current, release))
+	return nil
+}
+
+func versionCheckMessage(current string, release githubRelease) string {
+	cmp, ok := compareVersions(current, release.TagName)
+	switch {
+	case !ok:
+		return fmt.Sprintf("Development build, the latest release is %s: %s", release.TagName, release.HTMLURL)
+	case cmp < 0:
+		return fmt.Sprintf("A newer release is available: %s (run dbt-goverage self-update): %s", release.TagName, release.HTMLURL)
+	}
+	return "dbt-goverage is up to date"
+}
+
+// releaseAssetName is the name of the binary published by the release
+// workflow for an OS and an architecture.
+func releaseAssetName(goos, goarch string) string {
+	name := "dbt-goverage_" + goos + "_" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func runSelfUpdate(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("self-update")
+	var (
+		releasesURL = fs.String("releases_url", defaultReleasesURL, "GitHub API URL of the latest release")
+		force       = fs.Bool("force", false, "Install the latest release even if it is not newer (or on a development build)")
+		target      = fs.String("binary", "", "Binary to replace (defaults to the running executable)")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+
+	current := currentVersion()
+	release, err := fetchLatestRelease(ctx, *releasesURL)
+	if err != nil {
+		return err
+	}
+	if cmp, ok := compareVersions(current, release.TagName); !*force {
+		switch {
+		case !ok:
+			return fmt.Errorf("cannot compare the %s build with %s, use --force to install the release", current, release.TagName)
+		case cmp >= 0:
+			fmt.Printf("dbt-goverage %s is up to date\n", current)
+			return nil
+		}
+	}
+
+	binary := *target
+	if binary == "" {
+		if binary, err = os.Executable(); err != nil {
+			return err
+		}
+		if binary, err = filepath.EvalSymlinks(binary); err != nil {
+			return err
+		}
+	}
+	asset := releaseAssetName(runtime.GOOS, runtime.GOARCH)
+	assetURL := release.assetURL(asset)
+	if assetURL == "" {
+		return fmt.Errorf("release %s has no %s binary", release.TagName, asset)
+	}
+	checksumsURL := release.assetURL(releaseChecksums)
+	if checksumsURL == "" {
+		return fmt.Errorf("release %s has no %s, refusing to install an unverified binary", release.TagName, releaseChecksums)
+	}
+	checksums, err := download(ctx, checksumsURL)
+	if err != nil {
+		return err
+	}
+	want, err := releaseChecksum(checksums, asset)
+	if err != nil {
+		return err
+	}
+	log.Printf("Downloading %s %s", asset, release.TagName)
+	data, err := download(ctx, assetURL)
+	if err != nil {
+		return err
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != want {
+		return fmt.Errorf("checksum mismatch for %s, the binary was not replaced", asset)
+	}
+	if err := replaceBinary(binary, data); err != nil {
+		return err
+	}
+	fmt.Printf("dbt-goverage updated from %s to %s\n", current, release.TagName)
+	return nil
+}
+
+// releaseChecksum finds the SHA-256 of name in a sha256sum output.
+func releaseChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no checksum for %s", releaseChecksums, name)
+}
+
+// download has no client timeout: the binaries are large, and --timeout
+// bounds the whole command through ctx.
+func download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// replaceBinary writes the new binary next to the old one and renames it over
+// the old one, so a failed download never leaves a broken install. Windows
+// cannot overwrite a running executable but can rename it aside.
+func replaceBinary(binary string, data []byte) error {
+	dir := filepath.Dir(binary)
+	tmp, err := os.CreateTemp(dir, ".dbt-goverage-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		return os.Rename(tmp.Name(), binary)
+	}
+	// A running executable cannot be replaced on Windows, only renamed.
+	old := binary + ".old"
+	os.Remove(old)
+	if err := os.Rename(binary, old); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), binary); err != nil {
+		if restoreErr := os.Rename(old, binary); restoreErr != nil {
+			return fmt.Errorf("%w (restoring %s failed: %v)", err, old, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
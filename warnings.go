@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	WarningGeneral             = "general"
+	WarningManifestVersion     = "manifest_version"
+	WarningStaleCatalog        = "stale_catalog"
+	WarningMissingOriginalPath = "missing_original_file_path"
+	WarningUnparseableNode     = "unparseable_node"
+	WarningUnmappedTest        = "unmapped_test"
+	WarningUnknownKwargs       = "unknown_kwargs"
+)
+
+// Warning is a problem found in the artifacts that does not stop the run. They
+// are listed in the JSON report and summarized after the console report.
+type Warning struct {
+	Code    string `json:"code"`
+	Node    string `json:"node,omitempty"`
+	Message string `json:"message"`
+}
+
+// runWarnings collects the warnings raised while parsing the artifacts of one
+// run. The manifest and the catalogs built from it share it, so the runs of
+// watch, lsp and serve --live do not mix their warnings.
+type runWarnings struct {
+	mu       sync.Mutex
+	warnings []Warning
+}
+
+// warnf logs a warning that is not about the artifacts, so it is not listed in
+// the report.
+func warnf(format string, args ...interface{}) {
+	log.Printf("warning: "+format, args...)
+}
+
+func (w *runWarnings) add(code, node, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Printf("warning: %s", msg)
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.warnings = append(w.warnings, Warning{Code: code, Node: node, Message: msg})
+}
+
+// sorted returns the warnings raised so far, sorted for stable reports.
+func (w *runWarnings) sorted() []Warning {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	warnings := append([]Warning(nil), w.warnings...)
+	w.mu.Unlock()
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Code != warnings[j].Code {
+			return warnings[i].Code < warnings[j].Code
+		}
+		return warnings[i].Node < warnings[j].Node
+	})
+	return warnings
+}
+
+const warningExamples = 3
+
+func printWarningsSummary(w io.Writer, warnings []Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+	byCode := make(map[string][]Warning)
+	for _, warning := range warnings {
+		byCode[warning.Code] = append(byCode[warning.Code], warning)
+	}
+	fmt.Fprintf(w, "\n⚠️ %d warnings raised while parsing the artifacts:\n", len(warnings))
+	for _, code := range sortedKeys(byCode) {
+		group := byCode[code]
+		fmt.Fprintf(w, "  %s (%d)\n", code, len(group))
+		for _, warning := range group[:min(len(group), warningExamples)] {
+			fmt.Fprintf(w, "    - %s\n", warning.Message)
+		}
+		if len(group) > warningExamples {
+			fmt.Fprintf(w, "    … %d more, see the warnings of the JSON report\n", len(group)-warningExamples)
+		}
+	}
+}
+
+var columnKwargHints = []string{"column", "field"}
+
+// modelTestColumnKwargs are the kwargs listing the columns checked by a
+// model-level generic test, e.g. dbt_utils.unique_combination_of_columns.
+var modelTestColumnKwargs = []string{"combination_of_columns", "column_list", "column_names", "columns"}
+
+func modelTestColumns(kwargs map[string]interface{}) []string {
+	var columns []string
+	for _, key := range modelTestColumnKwargs {
+		switch v := kwargs[key].(type) {
+		case string:
+			columns = append(columns, v)
+		case []interface{}:
+			columns = append(columns, stringList(v)...)
+		}
+	}
+	return columns
+}
+
+// unresolvedColumnKwargs lists the kwargs of a test that look like column
+// references but are not read (column_name and arg are).
+func unresolvedColumnKwargs(kwargs map[string]interface{}) []string {
+	var keys []string
+	for key := range kwargs {
+		for _, hint := range columnKwargHints {
+			if strings.Contains(strings.ToLower(key), hint) {
+				keys = append(keys, key)
+				break
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
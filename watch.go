@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+func runWatch(ctx context.Context, args []string) error {
+	fs, common := newFlagSet("watch")
+	var (
+		projectDir      = fs.String("dbt_dir", ".", "dbt project path")
+		runArtifactsDir = fs.String("target_dir", "target", "dbt target path, or the http(s) URL of a directory serving the artifacts")
+		cacheDir        = fs.String("cache_dir", defaultArtifactCacheDir(), "Cache of the artifacts downloaded from a --target_dir URL, revalidated with conditional requests")
+		covTypeStr      = fs.String("type", "test", "Coverage type (doc, test, accepted_values, relationships, description, contract, unit_test, model_test, monitoring, freshness, meta:<key> or a plugin name)")
+		configPath      = fs.String("config", "", "Configuration file (defaults to "+DefaultConfigFile+" in the dbt project path)")
+		interval        = fs.Duration("interval", 2*time.Second, "How often the artifacts are checked for changes")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := common.setup(ctx)
+	defer cancel()
+
+	cfg, err := loadConfig(*configPath, *projectDir)
+	if err != nil {
+		return err
+	}
+	settings, err := cfg.apply()
+	if err != nil {
+		return err
+	}
+	defer closeCoverageProviders()
+	registerHeuristicProviders(cfg.Heuristics)
+	if err := registerPlugins(cfg.Plugins); err != nil {
+		return err
+	}
+	covType := CoverageType(*covTypeStr)
+	if _, err := lookupCoverageProvider(covType); err != nil {
+		return err
+	}
+
+	// A remote target is polled with conditional requests into the cache; a
+	// download renames the cached file, which the modification times detect.
+	var remote *artifactCache
+	if isRemoteTarget(*runArtifactsDir) {
+		remote = newArtifactCache(*cacheDir, *runArtifactsDir)
+		*runArtifactsDir = remote.dir
+	}
+	manifestPath := artifactPath(*projectDir, *runArtifactsDir, "manifest.json")
+	catalogPath := artifactPath(*projectDir, *runArtifactsDir, "catalog.json")
+	log.Printf("Watching %s and %s (Ctrl+C to stop)", manifestPath, catalogPath)
+
+	incremental := IncrementalCatalog{Settings: settings}
+	var lastChange time.Time
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if remote != nil {
+			if _, err := remote.FetchAll(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "error downloading the artifacts: %v\n", runError(ctx, err))
+			}
+		}
+		if changed := latestModTime(manifestPath, catalogPath); changed.After(lastChange) {
+			lastChange = changed
+			if err := watchCompute(ctx, &incremental, manifestPath, catalogPath, covType); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", runError(ctx, err))
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func watchCompute(ctx context.Context, incremental *IncrementalCatalog, manifestPath, catalogPath string, covType CoverageType) error {
+	start := time.Now()
+	manifestData, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	catalogData, err := os.ReadFile(catalogPath)
+	if err != nil {
+		return err
+	}
+	catalog, recomputed, err := incremental.Update(ctx, manifestData, catalogData)
+	if err != nil {
+		return err
+	}
+	if err := evaluateCoverage(ctx, catalog, covType); err != nil {
+		return err
+	}
+	report := computeDetailedCoverage(catalog, covType, GroupByNone)
+	if err := printDetailedCoverageReport(os.Stdout, report, tableRenderer{}); err != nil {
+		return err
+	}
+	log.Printf("%d/%d tables recomputed in %s", recomputed, len(catalog.Tables), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func latestModTime(paths ...string) time.Time {
+	var latest time.Time
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}